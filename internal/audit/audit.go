@@ -0,0 +1,95 @@
+// Package audit проверяет стойкость, повторное использование и возраст сохраненных паролей.
+package audit
+
+import (
+	"sort"
+	"time"
+
+	"github.com/Dorrrke/GophKeeper-client/internal/domain/models"
+)
+
+// weakScore - оценка, начиная с которой пароль считается стойким.
+const weakScore = 3
+
+// Entry - результат проверки одной сохраненной пары логин/пароль.
+type Entry struct {
+	Name       string   `json:"name"`
+	Login      string   `json:"login"`
+	Score      int      `json:"score"`
+	Entropy    float64  `json:"entropy"`
+	Weak       bool     `json:"weak"`
+	ReusedWith []string `json:"reused_with,omitempty"`
+	AgeDays    int      `json:"age_days"`
+	Old        bool     `json:"old"`
+	Issues     []string `json:"issues,omitempty"`
+	// Risk - итоговая оценка риска, по которой сортируется отчет.
+	Risk int `json:"risk"`
+}
+
+// Report - отчет проверки паролей.
+type Report struct {
+	Total   int     `json:"total"`
+	Weak    int     `json:"weak"`
+	Reused  int     `json:"reused"`
+	Old     int     `json:"old"`
+	Entries []Entry `json:"entries"`
+}
+
+// Analyze проверяет пароли и возвращает отчет, отсортированный по убыванию риска.
+// Пароль считается старым, если он не менялся дольше maxAge. maxAge = 0 отключает проверку возраста.
+func Analyze(logins []models.LoginModel, maxAge time.Duration, now time.Time) Report {
+	byPassword := make(map[string][]string)
+	for _, login := range logins {
+		byPassword[login.Password] = append(byPassword[login.Password], login.Name)
+	}
+
+	report := Report{Total: len(logins), Entries: make([]Entry, 0, len(logins))}
+	for _, login := range logins {
+		strength := EstimateStrength(login.Password, login.Login, login.Name)
+		entry := Entry{
+			Name:    login.Name,
+			Login:   login.Login,
+			Score:   strength.Score,
+			Entropy: strength.Entropy,
+			Weak:    strength.Score < weakScore,
+			Issues:  strength.Issues,
+		}
+		for _, name := range byPassword[login.Password] {
+			if name != login.Name {
+				entry.ReusedWith = append(entry.ReusedWith, name)
+			}
+		}
+		if len(entry.ReusedWith) != 0 {
+			entry.Issues = append(entry.Issues, IssueReused)
+		}
+		if !login.Updated.IsZero() {
+			age := now.Sub(login.Updated)
+			entry.AgeDays = int(age.Hours() / 24)
+			entry.Old = maxAge > 0 && age > maxAge
+		}
+		if entry.Old {
+			entry.Issues = append(entry.Issues, IssueOld)
+		}
+		entry.Risk = (4-entry.Score)*10 + len(entry.ReusedWith)*15
+		if entry.Old {
+			entry.Risk += 10
+		}
+		if entry.Weak {
+			report.Weak++
+		}
+		if len(entry.ReusedWith) != 0 {
+			report.Reused++
+		}
+		if entry.Old {
+			report.Old++
+		}
+		report.Entries = append(report.Entries, entry)
+	}
+	sort.SliceStable(report.Entries, func(i, j int) bool {
+		if report.Entries[i].Risk != report.Entries[j].Risk {
+			return report.Entries[i].Risk > report.Entries[j].Risk
+		}
+		return report.Entries[i].Name < report.Entries[j].Name
+	})
+	return report
+}
@@ -0,0 +1,93 @@
+package audit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Dorrrke/GophKeeper-client/internal/domain/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEstimateStrength(t *testing.T) {
+	type want struct {
+		maxScore int
+		minScore int
+		issues   []string
+	}
+	type test struct {
+		name     string
+		password string
+		hints    []string
+		want     want
+	}
+	tests := []test{
+		{
+			name:     "Test EstimateStrength function #1; Common password",
+			password: "password",
+			want:     want{maxScore: 0, issues: []string{IssueCommon, IssueShort}},
+		},
+		{
+			name:     "Test EstimateStrength function #2; Leet common password",
+			password: "P@ssw0rd",
+			want:     want{maxScore: 1, issues: []string{IssueCommon, IssueShort}},
+		},
+		{
+			name:     "Test EstimateStrength function #3; Keyboard and year",
+			password: "qwerty2019",
+			want:     want{maxScore: 1, issues: []string{IssueCommon, IssueYear, IssueShort}},
+		},
+		{
+			name:     "Test EstimateStrength function #4; Repeats and sequences",
+			password: "aaaaaaabcdefg",
+			want:     want{maxScore: 0, issues: []string{IssueSequence, IssueRepeat}},
+		},
+		{
+			name:     "Test EstimateStrength function #5; Contains login",
+			password: "gopher",
+			hints:    []string{"Gopher"},
+			want:     want{maxScore: 0, issues: []string{IssueContainsName, IssueShort}},
+		},
+		{
+			name:     "Test EstimateStrength function #6; Random password",
+			password: "hvGHv(9yWGRc!+3ox*o6",
+			want:     want{minScore: 4},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			res := EstimateStrength(tc.password, tc.hints...)
+			if tc.want.minScore > 0 {
+				assert.GreaterOrEqual(t, res.Score, tc.want.minScore)
+			} else {
+				assert.LessOrEqual(t, res.Score, tc.want.maxScore)
+			}
+			assert.Equal(t, tc.want.issues, res.Issues)
+		})
+	}
+}
+
+func TestAnalyze(t *testing.T) {
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	logins := []models.LoginModel{
+		{Name: "github", Login: "gopher", Password: "hvGHv(9yWGRc!+3ox*o6", Updated: now.AddDate(0, 0, -10)},
+		{Name: "mail", Login: "gopher", Password: "Summer2019!", Updated: now.AddDate(-1, 0, 0)},
+		{Name: "bank", Login: "gopher", Password: "Summer2019!", Updated: now.AddDate(0, 0, -1)},
+	}
+
+	report := Analyze(logins, 180*24*time.Hour, now)
+	assert.Equal(t, 3, report.Total)
+	assert.Equal(t, 2, report.Weak)
+	assert.Equal(t, 2, report.Reused)
+	assert.Equal(t, 1, report.Old)
+
+	assert.Equal(t, "mail", report.Entries[0].Name)
+	assert.Equal(t, []string{"bank"}, report.Entries[0].ReusedWith)
+	assert.True(t, report.Entries[0].Old)
+	assert.Equal(t, 366, report.Entries[0].AgeDays)
+	assert.Contains(t, report.Entries[0].Issues, IssueReused)
+	assert.Equal(t, "bank", report.Entries[1].Name)
+	assert.Equal(t, "github", report.Entries[2].Name)
+	assert.False(t, report.Entries[2].Weak)
+	assert.Empty(t, report.Entries[2].Issues)
+}
@@ -0,0 +1,189 @@
+package audit
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	errText "github.com/Dorrrke/GophKeeper-client/internal/domain/errors"
+	"github.com/Dorrrke/GophKeeper-client/internal/domain/models"
+)
+
+var ErrPwnedLine = errors.New(errText.PwnedLineError)
+
+// prefixLen - длина префикса SHA-1 в формате диапазонов Have I Been Pwned.
+const prefixLen = 5
+
+// PwnedSource - локальный список утекших паролей Have I Been Pwned.
+type PwnedSource interface {
+	// Lookup возвращает, сколько раз пароль с данным SHA-1 хешем встречался в утечках.
+	Lookup(hash string) (int, error)
+	Close() error
+}
+
+// PwnedEntry - сохраненный пароль, найденный в списке утечек.
+type PwnedEntry struct {
+	Name  string `json:"name"`
+	Login string `json:"login"`
+	Count int    `json:"count"`
+}
+
+// OpenPwned открывает список утечек. Каталог читается как набор файлов диапазонов
+// (имя файла - первые 5 символов хеша, строки SUFFIX:COUNT), файл - как отсортированный
+// по хешу список строк HASH:COUNT, в котором выполняется двоичный поиск.
+func OpenPwned(path string) (PwnedSource, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		return RangeDir{Dir: path}, nil
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	return &SortedFile{f: f, size: info.Size()}, nil
+}
+
+// CheckPwned проверяет пароли по списку утечек и возвращает найденные записи.
+func CheckPwned(logins []models.LoginModel, src PwnedSource) ([]PwnedEntry, error) {
+	var res []PwnedEntry
+	for _, login := range logins {
+		count, err := src.Lookup(PasswordHash(login.Password))
+		if err != nil {
+			return nil, err
+		}
+		if count > 0 {
+			res = append(res, PwnedEntry{Name: login.Name, Login: login.Login, Count: count})
+		}
+	}
+	return res, nil
+}
+
+// PasswordHash возвращает SHA-1 хеш пароля в верхнем регистре, как в списках Have I Been Pwned.
+func PasswordHash(password string) string {
+	sum := sha1.Sum([]byte(password))
+	return strings.ToUpper(hex.EncodeToString(sum[:]))
+}
+
+// RangeDir - каталог с файлами диапазонов, выгруженными через API range.
+type RangeDir struct {
+	Dir string
+}
+
+func (r RangeDir) Lookup(hash string) (int, error) {
+	prefix, suffix := hash[:prefixLen], hash[prefixLen:]
+	for _, name := range []string{prefix, prefix + ".txt", strings.ToLower(prefix), strings.ToLower(prefix) + ".txt"} {
+		f, err := os.Open(filepath.Join(r.Dir, name))
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				continue
+			}
+			return 0, err
+		}
+		defer f.Close()
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			lineHash, count, err := parsePwnedLine(scanner.Text())
+			if err != nil {
+				return 0, err
+			}
+			if lineHash == suffix {
+				return count, nil
+			}
+		}
+		return 0, scanner.Err()
+	}
+	return 0, nil
+}
+
+func (r RangeDir) Close() error {
+	return nil
+}
+
+// SortedFile - файл, отсортированный по хешу (pwned-passwords-sha1-ordered-by-hash).
+// Поиск не читает файл целиком, поэтому подходит для списков размером в гигабайты.
+type SortedFile struct {
+	f    *os.File
+	size int64
+}
+
+func (s *SortedFile) Lookup(hash string) (int, error) {
+	lo, hi := int64(0), s.size
+	for lo < hi {
+		mid := lo + (hi-lo)/2
+		start, line, err := s.lineAt(mid)
+		if err != nil {
+			return 0, err
+		}
+		if start >= hi {
+			hi = mid
+			continue
+		}
+		lineHash, count, err := parsePwnedLine(strings.TrimRight(line, "\r\n"))
+		if err != nil {
+			return 0, err
+		}
+		switch strings.Compare(lineHash, hash) {
+		case 0:
+			return count, nil
+		case -1:
+			lo = start + int64(len(line))
+		default:
+			hi = start
+		}
+	}
+	return 0, nil
+}
+
+// lineAt возвращает первую строку, начинающуюся не раньше pos, и смещение ее начала.
+func (s *SortedFile) lineAt(pos int64) (int64, string, error) {
+	start := pos
+	if pos > 0 {
+		start = pos - 1
+	}
+	r := bufio.NewReader(io.NewSectionReader(s.f, start, s.size-start))
+	if pos > 0 {
+		skipped, err := r.ReadString('\n')
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return s.size, "", nil
+			}
+			return 0, "", err
+		}
+		start += int64(len(skipped))
+	}
+	line, err := r.ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return 0, "", err
+	}
+	if line == "" {
+		return s.size, "", nil
+	}
+	return start, line, nil
+}
+
+func (s *SortedFile) Close() error {
+	return s.f.Close()
+}
+
+// parsePwnedLine разбирает строку HASH:COUNT.
+func parsePwnedLine(line string) (string, int, error) {
+	hash, countStr, ok := strings.Cut(line, ":")
+	if !ok {
+		return "", 0, fmt.Errorf("%w: %q", ErrPwnedLine, line)
+	}
+	count, err := strconv.Atoi(strings.TrimSpace(countStr))
+	if err != nil {
+		return "", 0, fmt.Errorf("%w: %q", ErrPwnedLine, line)
+	}
+	return strings.ToUpper(strings.TrimSpace(hash)), count, nil
+}
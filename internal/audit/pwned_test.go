@@ -0,0 +1,82 @@
+package audit
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/Dorrrke/GophKeeper-client/internal/domain/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPwnedSources(t *testing.T) {
+	seen := map[string]int{
+		"password":  9659365,
+		"qwerty":    4679,
+		"123456":    37359195,
+		"letmein":   22,
+		"trustno1":  1,
+		"iloveyou!": 3,
+	}
+	var lines []string
+	byPrefix := map[string][]string{}
+	for pass, count := range seen {
+		hash := PasswordHash(pass)
+		lines = append(lines, hash+":"+strconv.Itoa(count))
+		byPrefix[hash[:prefixLen]] = append(byPrefix[hash[:prefixLen]], hash[prefixLen:]+":"+strconv.Itoa(count))
+	}
+	for i := 0; i < 500; i++ {
+		lines = append(lines, PasswordHash("filler"+strconv.Itoa(i))+":1")
+	}
+	sort.Strings(lines)
+
+	dir := t.TempDir()
+	sortedPath := filepath.Join(dir, "sorted.txt")
+	require.NoError(t, os.WriteFile(sortedPath, []byte(strings.Join(lines, "\r\n")+"\r\n"), 0600))
+	rangeDir := filepath.Join(dir, "ranges")
+	require.NoError(t, os.Mkdir(rangeDir, 0700))
+	for prefix, suffixes := range byPrefix {
+		require.NoError(t, os.WriteFile(filepath.Join(rangeDir, prefix), []byte(strings.Join(suffixes, "\n")), 0600))
+	}
+
+	logins := []models.LoginModel{
+		{Name: "mail", Login: "gopher", Password: "password"},
+		{Name: "bank", Login: "gopher", Password: "hvGHv(9yWGRc!+3ox*o6"},
+		{Name: "forum", Login: "gopher", Password: "trustno1"},
+	}
+	want := []PwnedEntry{
+		{Name: "mail", Login: "gopher", Count: 9659365},
+		{Name: "forum", Login: "gopher", Count: 1},
+	}
+
+	for _, path := range []string{sortedPath, rangeDir} {
+		t.Run(filepath.Base(path), func(t *testing.T) {
+			src, err := OpenPwned(path)
+			require.NoError(t, err)
+			defer src.Close()
+			for pass, count := range seen {
+				got, err := src.Lookup(PasswordHash(pass))
+				assert.NoError(t, err)
+				assert.Equal(t, count, got, pass)
+			}
+			if path == sortedPath {
+				for i := 0; i < 500; i++ {
+					got, err := src.Lookup(PasswordHash("filler" + strconv.Itoa(i)))
+					assert.NoError(t, err)
+					assert.Equal(t, 1, got)
+				}
+			}
+			got, err := src.Lookup(PasswordHash("not in list"))
+			assert.NoError(t, err)
+			assert.Zero(t, got)
+
+			res, err := CheckPwned(logins, src)
+			assert.NoError(t, err)
+			assert.Equal(t, want, res)
+		})
+	}
+}
@@ -0,0 +1,212 @@
+package audit
+
+import (
+	"bufio"
+	_ "embed"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Проблемы пароля, найденные при оценке стойкости.
+const (
+	IssueShort        = "short"
+	IssueCommon       = "common password"
+	IssueRepeat       = "repeated characters"
+	IssueSequence     = "sequence"
+	IssueKeyboard     = "keyboard pattern"
+	IssueYear         = "year"
+	IssueContainsName = "contains login or name"
+	IssueReused       = "reused"
+	IssueOld          = "old"
+)
+
+// minLength - минимальная длина пароля без замечания IssueShort.
+const minLength = 12
+
+// keyboardRows - ряды клавиатуры, последовательности из которых считаются шаблоном.
+var keyboardRows = []string{"1234567890", "qwertyuiop", "asdfghjkl", "zxcvbnm", "1qaz2wsx3edc4rfv5tgb"}
+
+// leetReplacer приводит замены символов вида p@ssw0rd к буквам.
+var leetReplacer = strings.NewReplacer("0", "o", "1", "l", "3", "e", "4", "a", "5", "s", "7", "t", "@", "a", "$", "s", "!", "i")
+
+//go:embed common_passwords.txt
+var commonPasswordsData string
+
+var commonPasswords = parseList(commonPasswordsData)
+
+// Strength - оценка стойкости пароля.
+type Strength struct {
+	// Entropy - оценка энтропии в битах с учетом найденных шаблонов.
+	Entropy float64
+	// Score - оценка от 0 (очень слабый) до 4 (стойкий).
+	Score  int
+	Issues []string
+}
+
+// EstimateStrength оценивает стойкость пароля. Пароль разбивается на участки: известные пароли,
+// повторы, последовательности, ряды клавиатуры и годы оцениваются как шаблоны, остальные символы -
+// по размеру алфавита. Личные данные из hints (логин, имя записи) считаются угадываемыми.
+func EstimateStrength(password string, hints ...string) Strength {
+	issues := map[string]bool{}
+	lower := strings.ToLower(password)
+	runes := []rune(lower)
+	charBits := math.Log2(float64(poolSize(password)))
+
+	var entropy float64
+	for i := 0; i < len(runes); {
+		n, bits, issue := matchPattern(runes[i:], hints)
+		if n == 0 {
+			entropy += charBits
+			i++
+			continue
+		}
+		issues[issue] = true
+		entropy += bits
+		i += n
+	}
+	if len(runes) < minLength {
+		issues[IssueShort] = true
+	}
+
+	var res Strength
+	res.Entropy = math.Round(entropy*10) / 10
+	res.Score = score(entropy)
+	for _, issue := range []string{IssueCommon, IssueContainsName, IssueKeyboard, IssueSequence, IssueRepeat, IssueYear, IssueShort} {
+		if issues[issue] {
+			res.Issues = append(res.Issues, issue)
+		}
+	}
+	return res
+}
+
+// matchPattern ищет самый длинный шаблон в начале пароля и возвращает его длину и стоимость в битах.
+func matchPattern(runes []rune, hints []string) (int, float64, string) {
+	best, bestBits, bestIssue := 0, 0.0, ""
+	try := func(n int, bits float64, issue string) {
+		if n >= 3 && n > best {
+			best, bestBits, bestIssue = n, bits, issue
+		}
+	}
+	s := string(runes)
+	normalized := leetReplacer.Replace(s)
+	for word := range commonPasswords {
+		if strings.HasPrefix(s, word) || strings.HasPrefix(normalized, word) {
+			try(len([]rune(word)), math.Log2(float64(len(commonPasswords))), IssueCommon)
+		}
+	}
+	for _, hint := range hints {
+		hint = strings.ToLower(hint)
+		if hint != "" && (strings.HasPrefix(s, hint) || strings.HasPrefix(normalized, hint)) {
+			try(len([]rune(hint)), 1, IssueContainsName)
+		}
+	}
+	n := 1
+	for n < len(runes) && runes[n] == runes[0] {
+		n++
+	}
+	try(n, math.Log2(float64(poolSize(string(runes[0]))))+math.Log2(float64(n)), IssueRepeat)
+	for _, step := range []rune{1, -1} {
+		n = 1
+		for n < len(runes) && runes[n]-runes[n-1] == step {
+			n++
+		}
+		try(n, math.Log2(float64(poolSize(string(runes[0]))))+math.Log2(float64(n))+1, IssueSequence)
+	}
+	for _, row := range keyboardRows {
+		for _, r := range []string{row, reverse(row)} {
+			n = commonPrefixLen(r, runes)
+			try(n, math.Log2(float64(len(keyboardRows)*len(row)))+math.Log2(float64(n))+1, IssueKeyboard)
+		}
+	}
+	if len(runes) >= 4 {
+		if year, err := strconv.Atoi(string(runes[:4])); err == nil && year >= 1900 && year <= 2099 {
+			try(4, math.Log2(200), IssueYear)
+		}
+	}
+	return best, bestBits, bestIssue
+}
+
+// poolSize возвращает размер алфавита по классам символов пароля.
+func poolSize(password string) int {
+	var lower, upper, digit, other bool
+	for _, r := range password {
+		switch {
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsDigit(r):
+			digit = true
+		default:
+			other = true
+		}
+	}
+	size := 0
+	if lower {
+		size += 26
+	}
+	if upper {
+		size += 26
+	}
+	if digit {
+		size += 10
+	}
+	if other {
+		size += 33
+	}
+	if size == 0 {
+		return 1
+	}
+	return size
+}
+
+// score переводит энтропию в оценку от 0 до 4.
+func score(entropy float64) int {
+	switch {
+	case entropy < 28:
+		return 0
+	case entropy < 36:
+		return 1
+	case entropy < 60:
+		return 2
+	case entropy < 80:
+		return 3
+	default:
+		return 4
+	}
+}
+
+// commonPrefixLen возвращает длину общего начала ряда клавиатуры, начинающегося с runes[0], и пароля.
+func commonPrefixLen(row string, runes []rune) int {
+	start := strings.IndexRune(row, runes[0])
+	if start < 0 {
+		return 0
+	}
+	rowRunes := []rune(row[start:])
+	n := 0
+	for n < len(rowRunes) && n < len(runes) && rowRunes[n] == runes[n] {
+		n++
+	}
+	return n
+}
+
+func reverse(s string) string {
+	runes := []rune(s)
+	for i, j := 0, len(runes)-1; i < j; i, j = i+1, j-1 {
+		runes[i], runes[j] = runes[j], runes[i]
+	}
+	return string(runes)
+}
+
+func parseList(data string) map[string]struct{} {
+	list := make(map[string]struct{})
+	scanner := bufio.NewScanner(strings.NewReader(data))
+	for scanner.Scan() {
+		if word := strings.TrimSpace(scanner.Text()); word != "" {
+			list[word] = struct{}{}
+		}
+	}
+	return list
+}
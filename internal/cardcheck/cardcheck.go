@@ -0,0 +1,181 @@
+// Package cardcheck проверяет реквизиты банковских карт и определяет платежную систему по номеру.
+package cardcheck
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	errText "github.com/Dorrrke/GophKeeper-client/internal/domain/errors"
+	"github.com/Dorrrke/GophKeeper-client/internal/domain/models"
+)
+
+var (
+	ErrNumber = errors.New(errText.CardNumberError)
+	ErrDate   = errors.New(errText.CardDateError)
+	ErrCVV    = errors.New(errText.CardCVVError)
+)
+
+// Платежные системы.
+const (
+	BrandVisa       = "Visa"
+	BrandMastercard = "Mastercard"
+	BrandMir        = "Mir"
+	BrandAmex       = "American Express"
+	BrandDiscover   = "Discover"
+	BrandJCB        = "JCB"
+	BrandUnionPay   = "UnionPay"
+	BrandDiners     = "Diners Club"
+	BrandMaestro    = "Maestro"
+	BrandUnknown    = "Unknown"
+)
+
+// iinRange - диапазон префиксов номера карты одной платежной системы.
+type iinRange struct {
+	from, to int
+	brand    string
+}
+
+// iinRanges проверяются по порядку, поэтому более узкие диапазоны идут раньше широких.
+var iinRanges = []iinRange{
+	{2200, 2204, BrandMir},
+	{2221, 2720, BrandMastercard},
+	{51, 55, BrandMastercard},
+	{34, 34, BrandAmex},
+	{37, 37, BrandAmex},
+	{300, 305, BrandDiners},
+	{36, 36, BrandDiners},
+	{38, 39, BrandDiners},
+	{3528, 3589, BrandJCB},
+	{6011, 6011, BrandDiscover},
+	{644, 649, BrandDiscover},
+	{65, 65, BrandDiscover},
+	{62, 62, BrandUnionPay},
+	{4, 4, BrandVisa},
+	{50, 50, BrandMaestro},
+	{56, 69, BrandMaestro},
+}
+
+// Normalize проверяет карту и приводит ее к каноническому виду:
+// номер без пробелов и дефисов, срок действия в формате MM/YY.
+func Normalize(card models.CardModel) (models.CardModel, error) {
+	number, err := NormalizeNumber(card.Number)
+	if err != nil {
+		return models.CardModel{}, err
+	}
+	expiry, err := ParseDate(card.Date)
+	if err != nil {
+		return models.CardModel{}, err
+	}
+	if err := checkCVV(card.CVVCode, Brand(number)); err != nil {
+		return models.CardModel{}, err
+	}
+	card.Number = number
+	card.Date = expiry.Format("01/06")
+	return card, nil
+}
+
+// NormalizeNumber убирает из номера пробелы и дефисы и проверяет его длину и контрольную цифру.
+func NormalizeNumber(number string) (string, error) {
+	number = strings.NewReplacer(" ", "", "-", "").Replace(number)
+	if len(number) < 12 || len(number) > 19 {
+		return "", fmt.Errorf("%w: length must be 12-19 digits", ErrNumber)
+	}
+	for _, r := range number {
+		if r < '0' || r > '9' {
+			return "", fmt.Errorf("%w: only digits are allowed", ErrNumber)
+		}
+	}
+	if !Luhn(number) {
+		return "", fmt.Errorf("%w: checksum mismatch", ErrNumber)
+	}
+	return number, nil
+}
+
+// Luhn проверяет контрольную цифру номера по алгоритму Луна.
+func Luhn(number string) bool {
+	sum := 0
+	double := false
+	for i := len(number) - 1; i >= 0; i-- {
+		d := int(number[i] - '0')
+		if d < 0 || d > 9 {
+			return false
+		}
+		if double {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+	return number != "" && sum%10 == 0
+}
+
+// Brand определяет платежную систему по первым цифрам номера (IIN).
+func Brand(number string) string {
+	for _, r := range iinRanges {
+		digits := len(strconv.Itoa(r.from))
+		if len(number) < digits {
+			continue
+		}
+		prefix, err := strconv.Atoi(number[:digits])
+		if err != nil {
+			return BrandUnknown
+		}
+		if prefix >= r.from && prefix <= r.to {
+			return r.brand
+		}
+	}
+	return BrandUnknown
+}
+
+// ParseDate разбирает срок действия в формате MM/YY (допускается MM/YYYY)
+// и возвращает первый день указанного месяца.
+func ParseDate(date string) (time.Time, error) {
+	month, year, ok := strings.Cut(strings.TrimSpace(date), "/")
+	if !ok || len(month) != 2 || (len(year) != 2 && len(year) != 4) {
+		return time.Time{}, ErrDate
+	}
+	layout := "01/06"
+	if len(year) == 4 {
+		layout = "01/2006"
+	}
+	t, err := time.Parse(layout, month+"/"+year)
+	if err != nil {
+		return time.Time{}, ErrDate
+	}
+	return t, nil
+}
+
+// Expired сообщает, истек ли срок действия карты к моменту now.
+// Карта действует до конца указанного месяца.
+func Expired(date string, now time.Time) bool {
+	t, err := ParseDate(date)
+	if err != nil {
+		return false
+	}
+	return !now.UTC().Before(t.AddDate(0, 1, 0))
+}
+
+// checkCVV проверяет код безопасности: 4 цифры у American Express, 3 у остальных известных систем.
+// Для неизвестной платежной системы допускаются оба варианта.
+func checkCVV(cvv string, brand string) error {
+	switch {
+	case brand == BrandAmex && len(cvv) != 4:
+		return fmt.Errorf("%w: %s requires 4 digits", ErrCVV, brand)
+	case brand == BrandUnknown && len(cvv) != 3 && len(cvv) != 4:
+		return fmt.Errorf("%w: 3 or 4 digits required", ErrCVV)
+	case brand != BrandAmex && brand != BrandUnknown && len(cvv) != 3:
+		return fmt.Errorf("%w: %s requires 3 digits", ErrCVV, brand)
+	}
+	for _, r := range cvv {
+		if r < '0' || r > '9' {
+			return fmt.Errorf("%w: only digits are allowed", ErrCVV)
+		}
+	}
+	return nil
+}
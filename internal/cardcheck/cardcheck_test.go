@@ -0,0 +1,117 @@
+package cardcheck
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Dorrrke/GophKeeper-client/internal/domain/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLuhn(t *testing.T) {
+	assert.True(t, Luhn("4111111111111111"))
+	assert.True(t, Luhn("2200000000000004"))
+	assert.True(t, Luhn("378282246310005"))
+	assert.False(t, Luhn("4111111111111112"))
+	assert.False(t, Luhn("41111a1111111111"))
+	assert.False(t, Luhn(""))
+}
+
+func TestBrand(t *testing.T) {
+	tests := map[string]string{
+		"4111111111111111": BrandVisa,
+		"5500000000000004": BrandMastercard,
+		"2221000000000009": BrandMastercard,
+		"2200000000000004": BrandMir,
+		"378282246310005":  BrandAmex,
+		"6011111111111117": BrandDiscover,
+		"3530111333300000": BrandJCB,
+		"6200000000000005": BrandUnionPay,
+		"30569309025904":   BrandDiners,
+		"6759649826438453": BrandMaestro,
+		"9999999999999995": BrandUnknown,
+	}
+	for number, brand := range tests {
+		assert.Equal(t, brand, Brand(number), number)
+	}
+}
+
+func TestParseDate(t *testing.T) {
+	d, err := ParseDate("09/33")
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2033, time.September, 1, 0, 0, 0, 0, time.UTC), d)
+
+	d, err = ParseDate("01/2030")
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2030, time.January, 1, 0, 0, 0, 0, time.UTC), d)
+
+	for _, bad := range []string{"13/30", "9/30", "0930", "09/3", "aa/bb", ""} {
+		_, err := ParseDate(bad)
+		assert.ErrorIs(t, err, ErrDate, bad)
+	}
+}
+
+func TestExpired(t *testing.T) {
+	now := time.Date(2030, time.March, 31, 23, 0, 0, 0, time.UTC)
+	assert.False(t, Expired("03/30", now))
+	assert.True(t, Expired("02/30", now))
+	assert.True(t, Expired("03/30", now.Add(time.Hour)))
+}
+
+func TestNormalize(t *testing.T) {
+	type test struct {
+		name string
+		card models.CardModel
+		want models.CardModel
+		err  error
+	}
+	tests := []test{
+		{
+			name: "Test Normalize #1; Spaces and long year",
+			card: models.CardModel{Name: "visa", Number: "4111 1111 1111 1111", Date: "09/2033", CVVCode: "012"},
+			want: models.CardModel{Name: "visa", Number: "4111111111111111", Date: "09/33", CVVCode: "012"},
+		},
+		{
+			name: "Test Normalize #2; Amex",
+			card: models.CardModel{Name: "amex", Number: "3782-822463-10005", Date: "01/30", CVVCode: "1234"},
+			want: models.CardModel{Name: "amex", Number: "378282246310005", Date: "01/30", CVVCode: "1234"},
+		},
+		{
+			name: "Test Normalize #3; Luhn failure",
+			card: models.CardModel{Number: "4111111111111112", Date: "01/30", CVVCode: "123"},
+			err:  ErrNumber,
+		},
+		{
+			name: "Test Normalize #4; Short number",
+			card: models.CardModel{Number: "4111", Date: "01/30", CVVCode: "123"},
+			err:  ErrNumber,
+		},
+		{
+			name: "Test Normalize #5; Bad date",
+			card: models.CardModel{Number: "4111111111111111", Date: "2030-01", CVVCode: "123"},
+			err:  ErrDate,
+		},
+		{
+			name: "Test Normalize #6; Amex CVV too short",
+			card: models.CardModel{Number: "378282246310005", Date: "01/30", CVVCode: "123"},
+			err:  ErrCVV,
+		},
+		{
+			name: "Test Normalize #7; Visa CVV with letters",
+			card: models.CardModel{Number: "4111111111111111", Date: "01/30", CVVCode: "12a"},
+			err:  ErrCVV,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			card, err := Normalize(tc.card)
+			if tc.err != nil {
+				assert.ErrorIs(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, card)
+		})
+	}
+}
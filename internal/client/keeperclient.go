@@ -2,23 +2,30 @@ package client
 
 import (
 	"context"
-	"strconv"
+	"strings"
 
+	"github.com/Dorrrke/GophKeeper-client/internal/coder"
+	"github.com/Dorrrke/GophKeeper-client/internal/config"
 	"github.com/Dorrrke/GophKeeper-client/internal/domain/models"
+	"github.com/Dorrrke/GophKeeper-client/internal/hlc"
 	gophkeeperv1 "github.com/Dorrrke/goph-keeper-proto/gen/go/gophkeeper"
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials/insecure"
 	"google.golang.org/grpc/metadata"
 )
 
 type KeeperClient struct {
-	client gophkeeperv1.GophKeeperClient
-	conn   *grpc.ClientConn
-	token  string
+	client  gophkeeperv1.GophKeeperClient
+	conn    *grpc.ClientConn
+	tokens  models.TokensModel
+	syncKey []byte
 }
 
-func New(ctx context.Context, addr string) (*KeeperClient, error) {
-	conn, err := grpc.DialContext(ctx, addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+func New(ctx context.Context, addr string, tlsCfg config.TLSConfig) (*KeeperClient, error) {
+	creds, err := transportCredentials(tlsCfg)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := grpc.DialContext(ctx, addr, grpc.WithTransportCredentials(creds))
 	if err != nil {
 		return nil, err
 	}
@@ -29,150 +36,349 @@ func New(ctx context.Context, addr string) (*KeeperClient, error) {
 	}, nil
 }
 
-func (c *KeeperClient) Register(ctx context.Context, login, password string) error {
+// SetSyncKey задает ключ, которым шифруется содержимое записей перед отправкой на сервер.
+func (c *KeeperClient) SetSyncKey(key []byte) {
+	c.syncKey = key
+}
+
+// SetTokens задает токены, сохраненные в сессии пользователя.
+func (c *KeeperClient) SetTokens(tokens models.TokensModel) {
+	c.tokens = tokens
+}
+
+// Tokens возвращает текущие токены. Сервер может обновить их при синхронизации.
+func (c *KeeperClient) Tokens() models.TokensModel {
+	return c.tokens
+}
+
+func (c *KeeperClient) Register(ctx context.Context, login, password string) (models.TokensModel, error) {
 	var header metadata.MD
 	_, err := c.client.SignUp(ctx, &gophkeeperv1.SignUpRequest{
 		Login:    login,
 		Password: password,
 	}, grpc.Header(&header))
 	if err != nil {
-		return err
+		return models.TokensModel{}, err
+	}
+	if !c.updateTokens(header) {
+		return models.TokensModel{}, ErrNoToken
 	}
-	tokens := header.Get("Authorization")
-	c.token = tokens[0]
-	return nil
+	return c.tokens, nil
 }
 
-func (c *KeeperClient) Login(ctx context.Context, login, password string) error {
+func (c *KeeperClient) Login(ctx context.Context, login, password string) (models.TokensModel, error) {
 	var header metadata.MD
 	_, err := c.client.SignIn(ctx, &gophkeeperv1.SingInRequest{
 		Login:    login,
 		Password: password,
 	}, grpc.Header(&header))
 	if err != nil {
-		return err
+		return models.TokensModel{}, err
 	}
-	c.token = header.Get("Authorization")[0]
-	return nil
+	if !c.updateTokens(header) {
+		return models.TokensModel{}, ErrNoToken
+	}
+	return c.tokens, nil
 }
 
 func (c *KeeperClient) Sync(ctx context.Context, model models.SyncModel, uID int64) (models.SyncModel, error) {
-	protoModel := modelToProtoModel(model)
-	md := metadata.Pairs("Authorization", c.token)
+	protoModel, err := c.modelToProtoModel(model)
+	if err != nil {
+		return models.SyncModel{}, err
+	}
+	md := metadata.Pairs(authHeader, c.tokens.AccessToken)
+	if c.tokens.RefreshToken != "" {
+		md.Append(refreshHeader, c.tokens.RefreshToken)
+	}
 	mCtx := metadata.NewOutgoingContext(ctx, md)
+	var header metadata.MD
 	res, err := c.client.SyncDB(mCtx, &gophkeeperv1.SyncDBRequest{
 		Auth:  protoModel.Auth,
 		Bins:  protoModel.Bins,
 		Cards: protoModel.Cards,
 		Texts: protoModel.Texts,
-	})
+	}, grpc.Header(&header))
 	if err != nil {
 		return models.SyncModel{}, err
 	}
+	c.updateTokens(header)
 
-	resModel, err := protoModelToModel(models.ProtoSyncModel{
+	return c.protoModelToModel(models.ProtoSyncModel{
 		Cards: res.Cards,
 		Auth:  res.Auth,
 		Texts: res.Texts,
 		Bins:  res.Bins,
 	}, uID)
-	if err != nil {
-		return models.SyncModel{}, err
-	}
-	return resModel, nil
 }
 
-func modelToProtoModel(model models.SyncModel) models.ProtoSyncModel {
+// modelToProtoModel переводит локальные записи в формат сервера.
+// Секретное содержимое каждой записи шифруется ключом синхронизации,
+// в открытом виде остаются только имя и поля, нужные серверу для слияния.
+func (c *KeeperClient) modelToProtoModel(model models.SyncModel) (models.ProtoSyncModel, error) {
 	var pModel models.ProtoSyncModel
 	for _, data := range model.Bins {
+		payload, err := c.sealPayload(kindBin, data.Name, binPayload{
+			Data:   data.Data,
+			ID:     data.UUID,
+			Meta:   data.Meta,
+			Folder: data.Folder,
+			Tags:   data.Tags,
+		})
+		if err != nil {
+			return models.ProtoSyncModel{}, err
+		}
 		bin := &gophkeeperv1.SyncBinData{
 			Name:    data.Name,
-			Data:    data.Data,
+			Data:    []byte(payload),
 			Deleted: data.Deleted,
-			Updated: data.Updated,
+			Updated: data.Updated.String(),
 		}
 		pModel.Bins = append(pModel.Bins, bin)
 	}
 	for _, data := range model.Auth {
-		auth := &gophkeeperv1.SyncAuth{
-			Name:     data.Name,
+		payload, err := c.sealPayload(kindAuth, data.Name, authPayload{
 			Login:    data.Login,
 			Password: data.Password,
+			ID:       data.UUID,
+			Meta:     data.Meta,
+			Folder:   data.Folder,
+			Tags:     data.Tags,
+		})
+		if err != nil {
+			return models.ProtoSyncModel{}, err
+		}
+		auth := &gophkeeperv1.SyncAuth{
+			Name:     data.Name,
+			Password: payload,
 			Deleted:  data.Deleted,
-			Updated:  data.Updated,
+			Updated:  data.Updated.String(),
 		}
 		pModel.Auth = append(pModel.Auth, auth)
 	}
 	for _, data := range model.Cards {
+		payload, err := c.sealPayload(kindCard, data.Name, cardPayload{
+			Number: data.Number,
+			Date:   data.Date,
+			CVV:    cvvCode(data.CVVCode),
+			ID:     data.UUID,
+			Meta:   data.Meta,
+			Folder: data.Folder,
+			Tags:   data.Tags,
+		})
+		if err != nil {
+			return models.ProtoSyncModel{}, err
+		}
 		card := &gophkeeperv1.SyncCard{
 			Name:    data.Name,
-			Number:  data.Number,
-			Date:    data.Date,
-			Cvv:     strconv.Itoa(data.CVVCode),
+			Number:  payload,
 			Deleted: data.Deleted,
-			Updated: data.Updated,
+			Updated: data.Updated.String(),
 		}
 		pModel.Cards = append(pModel.Cards, card)
 	}
 	for _, data := range model.Texts {
+		payload, err := c.sealPayload(kindText, data.Name, textPayload{
+			Data:   data.Data,
+			ID:     data.UUID,
+			Meta:   data.Meta,
+			Folder: data.Folder,
+			Tags:   data.Tags,
+		})
+		if err != nil {
+			return models.ProtoSyncModel{}, err
+		}
 		text := &gophkeeperv1.SyncText{
 			Name:    data.Name,
-			Data:    data.Data,
+			Data:    payload,
+			Deleted: data.Deleted,
+			Updated: data.Updated.String(),
+		}
+		pModel.Texts = append(pModel.Texts, text)
+	}
+	for _, data := range model.OTPs {
+		payload, err := c.sealPayload(kindOTP, data.Name, otpPayload{
+			Type:      data.Type,
+			Secret:    data.Secret,
+			Issuer:    data.Issuer,
+			Account:   data.Account,
+			Algorithm: data.Algorithm,
+			Digits:    data.Digits,
+			Period:    data.Period,
+			Counter:   data.Counter,
+			ID:        data.UUID,
+			Meta:      data.Meta,
+			Folder:    data.Folder,
+			Tags:      data.Tags,
+		})
+		if err != nil {
+			return models.ProtoSyncModel{}, err
+		}
+		text := &gophkeeperv1.SyncText{
+			Name:    otpTextPrefix + data.Name,
+			Data:    payload,
 			Deleted: data.Deleted,
-			Updated: data.Updated,
+			Updated: data.Updated.String(),
 		}
 		pModel.Texts = append(pModel.Texts, text)
 	}
+	for _, data := range model.Folders {
+		name, err := c.folderTextName(data.Path)
+		if err != nil {
+			return models.ProtoSyncModel{}, err
+		}
+		payload, err := c.sealPayload(kindFolder, name, folderPayload{Path: data.Path})
+		if err != nil {
+			return models.ProtoSyncModel{}, err
+		}
+		pModel.Texts = append(pModel.Texts, &gophkeeperv1.SyncText{
+			Name:    name,
+			Data:    payload,
+			Deleted: data.Deleted,
+			Updated: data.Updated.String(),
+		})
+	}
 
-	return pModel
+	return pModel, nil
 }
 
-func protoModelToModel(model models.ProtoSyncModel, uID int64) (models.SyncModel, error) {
+// protoModelToModel переводит записи сервера в локальный формат.
+// Записи, сохраненные на сервере до появления сквозного шифрования, принимаются в открытом виде.
+func (c *KeeperClient) protoModelToModel(model models.ProtoSyncModel, uID int64) (models.SyncModel, error) {
 	var sModel models.SyncModel
 	for _, data := range model.Bins {
+		updated, err := hlc.Parse(data.Updated)
+		if err != nil {
+			return models.SyncModel{}, err
+		}
+		payload := binPayload{Data: data.Data}
+		if coder.IsSealed(string(data.Data)) {
+			if err := c.openPayload(kindBin, data.Name, string(data.Data), &payload); err != nil {
+				return models.SyncModel{}, err
+			}
+		}
 		bin := models.SyncBinaryDataModel{
 			UserID:  uID,
 			Name:    data.Name,
-			Data:    data.Data,
+			UUID:    payload.ID,
+			Data:    payload.Data,
+			Meta:    payload.Meta,
+			Folder:  payload.Folder,
+			Tags:    payload.Tags,
 			Deleted: data.Deleted,
-			Updated: data.Updated,
+			Updated: updated,
 		}
 		sModel.Bins = append(sModel.Bins, bin)
 	}
 	for _, data := range model.Auth {
+		updated, err := hlc.Parse(data.Updated)
+		if err != nil {
+			return models.SyncModel{}, err
+		}
+		payload := authPayload{Login: data.Login, Password: data.Password}
+		if coder.IsSealed(data.Password) {
+			if err := c.openPayload(kindAuth, data.Name, data.Password, &payload); err != nil {
+				return models.SyncModel{}, err
+			}
+		}
 		auth := models.SyncLoginModel{
 			UserID:   uID,
 			Name:     data.Name,
-			Login:    data.Login,
-			Password: data.Password,
+			UUID:     payload.ID,
+			Login:    payload.Login,
+			Password: payload.Password,
+			Meta:     payload.Meta,
+			Folder:   payload.Folder,
+			Tags:     payload.Tags,
 			Deleted:  data.Deleted,
-			Updated:  data.Updated,
+			Updated:  updated,
 		}
 		sModel.Auth = append(sModel.Auth, auth)
 	}
 	for _, data := range model.Cards {
-		cvv, err := strconv.Atoi(data.Cvv)
+		updated, err := hlc.Parse(data.Updated)
 		if err != nil {
 			return models.SyncModel{}, err
 		}
+		payload := cardPayload{Number: data.Number, Date: data.Date, CVV: cvvCode(data.Cvv)}
+		if coder.IsSealed(data.Number) {
+			if err := c.openPayload(kindCard, data.Name, data.Number, &payload); err != nil {
+				return models.SyncModel{}, err
+			}
+		}
 		card := models.SyncCardModel{
 			UserID:  uID,
 			Name:    data.Name,
-			Number:  data.Number,
-			Date:    data.Date,
-			CVVCode: cvv,
+			UUID:    payload.ID,
+			Number:  payload.Number,
+			Date:    payload.Date,
+			CVVCode: string(payload.CVV),
+			Meta:    payload.Meta,
+			Folder:  payload.Folder,
+			Tags:    payload.Tags,
 			Deleted: data.Deleted,
-			Updated: data.Updated,
+			Updated: updated,
 		}
 		sModel.Cards = append(sModel.Cards, card)
 	}
 	for _, data := range model.Texts {
+		updated, err := hlc.Parse(data.Updated)
+		if err != nil {
+			return models.SyncModel{}, err
+		}
+		if name, ok := strings.CutPrefix(data.Name, otpTextPrefix); ok {
+			var payload otpPayload
+			if err := c.openPayload(kindOTP, name, data.Data, &payload); err != nil {
+				return models.SyncModel{}, err
+			}
+			sModel.OTPs = append(sModel.OTPs, models.SyncOTPModel{
+				UserID:    uID,
+				Name:      name,
+				UUID:      payload.ID,
+				Type:      payload.Type,
+				Secret:    payload.Secret,
+				Issuer:    payload.Issuer,
+				Account:   payload.Account,
+				Algorithm: payload.Algorithm,
+				Digits:    payload.Digits,
+				Period:    payload.Period,
+				Counter:   payload.Counter,
+				Meta:      payload.Meta,
+				Folder:    payload.Folder,
+				Tags:      payload.Tags,
+				Deleted:   data.Deleted,
+				Updated:   updated,
+			})
+			continue
+		}
+		if strings.HasPrefix(data.Name, folderTextPrefix) {
+			var payload folderPayload
+			if err := c.openPayload(kindFolder, data.Name, data.Data, &payload); err != nil {
+				return models.SyncModel{}, err
+			}
+			sModel.Folders = append(sModel.Folders, models.SyncFolderModel{
+				UserID:  uID,
+				Path:    payload.Path,
+				Deleted: data.Deleted,
+				Updated: updated,
+			})
+			continue
+		}
+		payload := textPayload{Data: data.Data}
+		if coder.IsSealed(data.Data) {
+			if err := c.openPayload(kindText, data.Name, data.Data, &payload); err != nil {
+				return models.SyncModel{}, err
+			}
+		}
 		text := models.SyncTextDataModel{
 			UserID:  uID,
 			Name:    data.Name,
-			Data:    data.Data,
+			UUID:    payload.ID,
+			Data:    payload.Data,
+			Meta:    payload.Meta,
+			Folder:  payload.Folder,
+			Tags:    payload.Tags,
 			Deleted: data.Deleted,
-			Updated: data.Updated,
+			Updated: updated,
 		}
 		sModel.Texts = append(sModel.Texts, text)
 	}
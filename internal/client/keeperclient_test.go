@@ -0,0 +1,150 @@
+package client
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Dorrrke/GophKeeper-client/internal/domain/models"
+	"github.com/Dorrrke/GophKeeper-client/internal/hlc"
+	gophkeeperv1 "github.com/Dorrrke/goph-keeper-proto/gen/go/gophkeeper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestSyncPayloadEncryption(t *testing.T) {
+	c := &KeeperClient{}
+	c.SetSyncKey([]byte("0123456789abcdef0123456789abcdef"))
+	model := models.SyncModel{
+		Cards: []models.SyncCardModel{
+			{UserID: 1, Name: "visa", UUID: "0b9e5b4c-3f0a-4c52-9d7e-2f4a1c6e8b10", Number: "4111111111111111", Date: "12/30", CVVCode: "123", Updated: hlc.MustParse("2024-03-01T10:00:00Z"),
+				Meta: map[string]string{"bank": "Sber"}, Folder: "Work/Banks", Tags: []string{"salary", "visa"}},
+		},
+		Auth: []models.SyncLoginModel{
+			{UserID: 1, Name: "github", Login: "gopher", Password: "secret", Updated: hlc.MustParse("2024-03-01T10:00:00Z"),
+				Meta: map[string]string{"url": "https://github.com"}},
+		},
+		Texts: []models.SyncTextDataModel{
+			{UserID: 1, Name: "note", Data: "text", Deleted: true, Updated: hlc.MustParse("2024-03-01T10:00:00Z")},
+		},
+		Bins: []models.SyncBinaryDataModel{
+			{UserID: 1, Name: "file", Data: []byte{0, 1, 2}, Updated: hlc.MustParse("2024-03-01T10:00:00Z")},
+		},
+		OTPs: []models.SyncOTPModel{
+			{UserID: 1, Name: "github", UUID: "5d2c7a91-8e4b-4f36-a1c2-7b9e0d3f6a48", Type: "totp", Secret: "JBSWY3DPEHPK3PXP", Account: "gopher",
+				Algorithm: "SHA1", Digits: 6, Period: 30, Updated: hlc.MustParse("2024-03-01T10:00:00Z")},
+		},
+		Folders: []models.SyncFolderModel{
+			{UserID: 1, Path: "Work/Banks", Updated: hlc.MustParse("2024-03-01T10:00:00Z")},
+			{UserID: 1, Path: "Old", Deleted: true, Updated: hlc.MustParse("2024-03-01T10:00:00Z")},
+		},
+	}
+
+	pModel, err := c.modelToProtoModel(model)
+	require.NoError(t, err)
+	assert.NotContains(t, pModel.Cards[0].Number, "4111")
+	assert.Empty(t, pModel.Cards[0].Cvv)
+	assert.Empty(t, pModel.Auth[0].Login)
+	assert.NotContains(t, pModel.Auth[0].Password, "secret")
+	assert.Equal(t, "visa", pModel.Cards[0].Name)
+	assert.True(t, pModel.Texts[0].Deleted)
+	assert.Equal(t, otpTextPrefix+"github", pModel.Texts[1].Name)
+	assert.NotContains(t, pModel.Texts[1].Data, "JBSWY3DPEHPK3PXP")
+	assert.NotContains(t, pModel.Texts[1].Data, "5d2c7a91")
+	assert.NotContains(t, pModel.Cards[0].Number, "Sber")
+	assert.NotContains(t, pModel.Cards[0].Number, "salary")
+	assert.NotContains(t, pModel.Cards[0].Number, "0b9e5b4c")
+	assert.True(t, strings.HasPrefix(pModel.Texts[2].Name, folderTextPrefix))
+	assert.NotContains(t, pModel.Texts[2].Name, "Work")
+	assert.NotEqual(t, pModel.Texts[2].Name, pModel.Texts[3].Name)
+	again, err := c.folderTextName("Work/Banks")
+	require.NoError(t, err)
+	assert.Equal(t, pModel.Texts[2].Name, again)
+	assert.True(t, pModel.Texts[3].Deleted)
+
+	res, err := c.protoModelToModel(pModel, 1)
+	require.NoError(t, err)
+	assert.Equal(t, model, res)
+
+	pModel.Auth[0].Name = "gitlab"
+	_, err = c.protoModelToModel(pModel, 1)
+	assert.Error(t, err)
+
+	// Папка, отправленная прежними версиями клиента под открытым путем, по-прежнему принимается.
+	legacy, err := c.sealPayload(kindFolder, folderTextPrefix+"Old", folderPayload{Path: "Old"})
+	require.NoError(t, err)
+	res, err = c.protoModelToModel(models.ProtoSyncModel{
+		Texts: []*gophkeeperv1.SyncText{{Name: folderTextPrefix + "Old", Data: legacy}},
+	}, 1)
+	require.NoError(t, err)
+	assert.Equal(t, []models.SyncFolderModel{{UserID: 1, Path: "Old"}}, res.Folders)
+
+	other := &KeeperClient{}
+	other.SetSyncKey([]byte("fedcba9876543210fedcba9876543210"))
+	_, err = other.protoModelToModel(models.ProtoSyncModel{Cards: pModel.Cards}, 1)
+	assert.Error(t, err)
+}
+
+func TestSyncPlaintextRecords(t *testing.T) {
+	c := &KeeperClient{}
+	c.SetSyncKey([]byte("0123456789abcdef0123456789abcdef"))
+	res, err := c.protoModelToModel(models.ProtoSyncModel{
+		Cards: []*gophkeeperv1.SyncCard{
+			{Name: "old", Number: "5500000000000004", Date: "01/29", Cvv: "321", Updated: "2024-01-01T00:00:00Z"},
+		},
+		Auth: []*gophkeeperv1.SyncAuth{
+			{Name: "old", Login: "user", Password: "pass", Updated: "2024-01-01T00:00:00Z"},
+		},
+	}, 7)
+	require.NoError(t, err)
+	assert.Equal(t, models.SyncCardModel{
+		UserID: 7, Name: "old", Number: "5500000000000004", Date: "01/29", CVVCode: "321", Updated: hlc.MustParse("2024-01-01T00:00:00Z"),
+	}, res.Cards[0])
+	assert.Equal(t, "pass", res.Auth[0].Password)
+
+	_, err = c.protoModelToModel(models.ProtoSyncModel{
+		Cards: []*gophkeeperv1.SyncCard{{Name: "old", Number: "5500000000000004", Updated: "yesterday"}},
+	}, 7)
+	assert.ErrorIs(t, err, hlc.ErrTimestamp)
+
+	_, err = (&KeeperClient{}).modelToProtoModel(models.SyncModel{Texts: []models.SyncTextDataModel{{Name: "n"}}})
+	assert.ErrorIs(t, err, ErrNoSyncKey)
+}
+
+func TestSyncCardCVV(t *testing.T) {
+	c := &KeeperClient{}
+	c.SetSyncKey([]byte("0123456789abcdef0123456789abcdef"))
+	pModel, err := c.modelToProtoModel(models.SyncModel{
+		Cards: []models.SyncCardModel{{UserID: 1, Name: "mir", Number: "2200000000000004", Date: "05/31", CVVCode: "012"}},
+	})
+	require.NoError(t, err)
+	res, err := c.protoModelToModel(pModel, 1)
+	require.NoError(t, err)
+	assert.Equal(t, "012", res.Cards[0].CVVCode)
+
+	// Содержимое, зашифрованное до перехода на строковый CVV.
+	legacy, err := c.sealPayload(kindCard, "old", map[string]any{"number": "4111111111111111", "date": "01/30", "cvv": 123})
+	require.NoError(t, err)
+	res, err = c.protoModelToModel(models.ProtoSyncModel{
+		Cards: []*gophkeeperv1.SyncCard{{Name: "old", Number: legacy}},
+	}, 1)
+	require.NoError(t, err)
+	assert.Equal(t, "123", res.Cards[0].CVVCode)
+}
+
+func TestUpdateTokens(t *testing.T) {
+	exp := time.Unix(1893456000, 0)
+	payload := base64.RawURLEncoding.EncodeToString([]byte(`{"exp":1893456000}`))
+	jwt := "eyJhbGciOiJIUzI1NiJ9." + payload + ".sig"
+
+	c := &KeeperClient{}
+	assert.False(t, c.updateTokens(metadata.MD{}))
+	assert.True(t, c.updateTokens(metadata.Pairs(authHeader, jwt, refreshHeader, "refresh")))
+	assert.Equal(t, models.TokensModel{AccessToken: jwt, RefreshToken: "refresh", ExpiresAt: exp}, c.Tokens())
+
+	assert.True(t, c.updateTokens(metadata.Pairs(authHeader, "opaque")))
+	assert.Equal(t, "refresh", c.Tokens().RefreshToken)
+	assert.True(t, c.Tokens().ExpiresAt.IsZero())
+}
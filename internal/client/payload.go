@@ -0,0 +1,158 @@
+package client
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+
+	"github.com/Dorrrke/GophKeeper-client/internal/coder"
+	errText "github.com/Dorrrke/GophKeeper-client/internal/domain/errors"
+)
+
+var ErrNoSyncKey = errors.New(errText.NoSyncKeyError)
+
+// Виды записей, к которым привязывается зашифрованное содержимое.
+const (
+	kindCard   = "card"
+	kindAuth   = "auth"
+	kindText   = "text"
+	kindBin    = "bin"
+	kindOTP    = "otp"
+	kindFolder = "folder"
+)
+
+// otpTextPrefix - префикс имени SyncText, под которым на сервере хранятся записи OTP.
+// В протоколе нет отдельного сообщения для OTP, поэтому они передаются как текстовые записи.
+const otpTextPrefix = "gophkeeper:otp:"
+
+// folderTextPrefix - префикс имени SyncText, под которым на сервере хранятся папки.
+// Папки передаются отдельно от записей, чтобы синхронизировались и пустые папки.
+const folderTextPrefix = "gophkeeper:folder:"
+
+// folderNamePurpose - назначение подключа, которым получается имя папки на сервере.
+const folderNamePurpose = "gophkeeper folder names v1"
+
+// cardPayload - зашифрованное содержимое SyncCard.
+type cardPayload struct {
+	Number string            `json:"number"`
+	Date   string            `json:"date"`
+	CVV    cvvCode           `json:"cvv"`
+	ID     string            `json:"id,omitempty"`
+	Meta   map[string]string `json:"meta,omitempty"`
+	Folder string            `json:"folder,omitempty"`
+	Tags   []string          `json:"tags,omitempty"`
+}
+
+// cvvCode - CVV в содержимом карты. Раньше CVV передавался числом,
+// поэтому при разборе принимаются и строка, и число.
+type cvvCode string
+
+func (c *cvvCode) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		*c = cvvCode(s)
+		return nil
+	}
+	var n json.Number
+	if err := json.Unmarshal(data, &n); err != nil {
+		return err
+	}
+	*c = cvvCode(n.String())
+	return nil
+}
+
+// authPayload - зашифрованное содержимое SyncAuth.
+type authPayload struct {
+	Login    string            `json:"login"`
+	Password string            `json:"password"`
+	ID       string            `json:"id,omitempty"`
+	Meta     map[string]string `json:"meta,omitempty"`
+	Folder   string            `json:"folder,omitempty"`
+	Tags     []string          `json:"tags,omitempty"`
+}
+
+// textPayload - зашифрованное содержимое SyncText.
+type textPayload struct {
+	Data   string            `json:"data"`
+	ID     string            `json:"id,omitempty"`
+	Meta   map[string]string `json:"meta,omitempty"`
+	Folder string            `json:"folder,omitempty"`
+	Tags   []string          `json:"tags,omitempty"`
+}
+
+// binPayload - зашифрованное содержимое SyncBinData.
+type binPayload struct {
+	Data   []byte            `json:"data"`
+	ID     string            `json:"id,omitempty"`
+	Meta   map[string]string `json:"meta,omitempty"`
+	Folder string            `json:"folder,omitempty"`
+	Tags   []string          `json:"tags,omitempty"`
+}
+
+// otpPayload - зашифрованное содержимое записи OTP.
+type otpPayload struct {
+	Type      string            `json:"type"`
+	Secret    string            `json:"secret"`
+	Issuer    string            `json:"issuer"`
+	Account   string            `json:"account"`
+	Algorithm string            `json:"algorithm"`
+	Digits    int               `json:"digits"`
+	Period    int               `json:"period"`
+	Counter   uint64            `json:"counter"`
+	ID        string            `json:"id,omitempty"`
+	Meta      map[string]string `json:"meta,omitempty"`
+	Folder    string            `json:"folder,omitempty"`
+	Tags      []string          `json:"tags,omitempty"`
+}
+
+// folderPayload - зашифрованное содержимое записи папки.
+type folderPayload struct {
+	Path string `json:"path"`
+}
+
+// sealPayload шифрует содержимое записи ключом синхронизации.
+// Шифротекст привязан к виду и имени записи, чтобы сервер не мог подменить одну запись другой.
+func (c *KeeperClient) sealPayload(kind string, name string, payload any) (string, error) {
+	if c.syncKey == nil {
+		return "", ErrNoSyncKey
+	}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return "", err
+	}
+	return coder.Seal(c.syncKey, data, payloadAD(kind, name))
+}
+
+// openPayload расшифровывает содержимое записи, полученной с сервера.
+func (c *KeeperClient) openPayload(kind string, name string, sealed string, payload any) error {
+	if c.syncKey == nil {
+		return ErrNoSyncKey
+	}
+	data, err := coder.Open(c.syncKey, sealed, payloadAD(kind, name))
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, payload)
+}
+
+// folderTextName возвращает имя SyncText для папки. Вместо пути используется его HMAC на ключе синхронизации:
+// имя одинаково на всех устройствах пользователя, но не раскрывает серверу структуру папок.
+// Сам путь передается в зашифрованном содержимом.
+func (c *KeeperClient) folderTextName(path string) (string, error) {
+	if c.syncKey == nil {
+		return "", ErrNoSyncKey
+	}
+	key, err := coder.SubKey(c.syncKey, folderNamePurpose)
+	if err != nil {
+		return "", err
+	}
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(path))
+	return folderTextPrefix + hex.EncodeToString(mac.Sum(nil)), nil
+}
+
+func payloadAD(kind string, name string) []byte {
+	return []byte(kind + ":" + name)
+}
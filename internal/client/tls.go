@@ -0,0 +1,83 @@
+package client
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/Dorrrke/GophKeeper-client/internal/config"
+	errText "github.com/Dorrrke/GophKeeper-client/internal/domain/errors"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+var (
+	ErrPinMismatch     = errors.New(errText.PinMismatchError)
+	ErrInvalidCABundle = errors.New(errText.InvalidCABundleError)
+	ErrClientCertPair  = errors.New(errText.ClientCertPairError)
+)
+
+// transportCredentials собирает параметры защищенного соединения из конфигурации.
+func transportCredentials(cfg config.TLSConfig) (credentials.TransportCredentials, error) {
+	if cfg.Insecure {
+		return insecure.NewCredentials(), nil
+	}
+	tlsCfg := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		ServerName: cfg.ServerName,
+	}
+	if cfg.CAFile != "" {
+		caData, err := os.ReadFile(cfg.CAFile)
+		if err != nil {
+			return nil, err
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caData) {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidCABundle, cfg.CAFile)
+		}
+		tlsCfg.RootCAs = pool
+	}
+	if cfg.CertFile != "" || cfg.KeyFile != "" {
+		if cfg.CertFile == "" || cfg.KeyFile == "" {
+			return nil, ErrClientCertPair
+		}
+		cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+		if err != nil {
+			return nil, err
+		}
+		tlsCfg.Certificates = []tls.Certificate{cert}
+	}
+	if len(cfg.Pins) != 0 {
+		pins := make(map[string]struct{}, len(cfg.Pins))
+		for _, pin := range cfg.Pins {
+			pins[strings.TrimPrefix(pin, "sha256/")] = struct{}{}
+		}
+		tlsCfg.VerifyConnection = func(cs tls.ConnectionState) error {
+			return verifyPins(cs.PeerCertificates, pins)
+		}
+	}
+	return credentials.NewTLS(tlsCfg), nil
+}
+
+// verifyPins проверяет, что открытый ключ сертификата сервера совпадает с одним из закрепленных.
+// Вызывается после стандартной проверки цепочки сертификатов.
+func verifyPins(certs []*x509.Certificate, pins map[string]struct{}) error {
+	if len(certs) == 0 {
+		return ErrPinMismatch
+	}
+	if _, ok := pins[SPKIHash(certs[0])]; !ok {
+		return ErrPinMismatch
+	}
+	return nil
+}
+
+// SPKIHash возвращает SHA-256 хеш SubjectPublicKeyInfo сертификата в base64.
+func SPKIHash(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
@@ -0,0 +1,91 @@
+package client
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Dorrrke/GophKeeper-client/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransportCredentials(t *testing.T) {
+	dir := t.TempDir()
+	badCA := filepath.Join(dir, "bad_ca.pem")
+	require.NoError(t, os.WriteFile(badCA, []byte("not a pem"), 0600))
+
+	type test struct {
+		name     string
+		cfg      config.TLSConfig
+		protocol string
+		err      error
+	}
+	tests := []test{
+		{
+			name:     "Test transportCredentials function #1; Insecure",
+			cfg:      config.TLSConfig{Insecure: true},
+			protocol: "insecure",
+		},
+		{
+			name:     "Test transportCredentials function #2; System roots",
+			cfg:      config.TLSConfig{ServerName: "keeper.local", Pins: []string{"sha256/AAAA"}},
+			protocol: "tls",
+		},
+		{
+			name: "Test transportCredentials function #3; Invalid CA bundle",
+			cfg:  config.TLSConfig{CAFile: badCA},
+			err:  ErrInvalidCABundle,
+		},
+		{
+			name: "Test transportCredentials function #4; Cert without key",
+			cfg:  config.TLSConfig{CertFile: "client.pem"},
+			err:  ErrClientCertPair,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			creds, err := transportCredentials(tc.cfg)
+			if tc.err != nil {
+				assert.ErrorIs(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.protocol, creds.Info().SecurityProtocol)
+		})
+	}
+}
+
+func TestVerifyPins(t *testing.T) {
+	cert := selfSignedCert(t)
+	other := selfSignedCert(t)
+
+	pins := map[string]struct{}{SPKIHash(cert): {}}
+	assert.NoError(t, verifyPins([]*x509.Certificate{cert}, pins))
+	assert.ErrorIs(t, verifyPins([]*x509.Certificate{other}, pins), ErrPinMismatch)
+	assert.ErrorIs(t, verifyPins(nil, pins), ErrPinMismatch)
+}
+
+func selfSignedCert(t *testing.T) *x509.Certificate {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "keeper.local"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return cert
+}
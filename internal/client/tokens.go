@@ -0,0 +1,55 @@
+package client
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	errText "github.com/Dorrrke/GophKeeper-client/internal/domain/errors"
+	"google.golang.org/grpc/metadata"
+)
+
+// Заголовки, в которых сервер передает токены доступа.
+const (
+	authHeader    = "authorization"
+	refreshHeader = "refresh-token"
+)
+
+var ErrNoToken = errors.New(errText.NoServerTokenError)
+
+// updateTokens сохраняет токены из заголовков ответа сервера.
+// Возвращает false, если сервер не прислал токен доступа.
+func (c *KeeperClient) updateTokens(header metadata.MD) bool {
+	access := header.Get(authHeader)
+	if len(access) == 0 || access[0] == "" {
+		return false
+	}
+	c.tokens.AccessToken = access[0]
+	c.tokens.ExpiresAt = tokenExpiry(access[0])
+	if refresh := header.Get(refreshHeader); len(refresh) != 0 {
+		c.tokens.RefreshToken = refresh[0]
+	}
+	return true
+}
+
+// tokenExpiry возвращает время истечения токена из claim exp, если токен является JWT.
+// Подпись не проверяется: значение используется только для определения срока жизни сессии.
+func tokenExpiry(token string) time.Time {
+	parts := strings.Split(strings.TrimPrefix(token, "Bearer "), ".")
+	if len(parts) != 3 {
+		return time.Time{}
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return time.Time{}
+	}
+	var claims struct {
+		Exp int64 `json:"exp"`
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil || claims.Exp == 0 {
+		return time.Time{}
+	}
+	return time.Unix(claims.Exp, 0)
+}
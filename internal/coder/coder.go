@@ -1,30 +1,222 @@
 package coder
 
 import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
 	"errors"
+	"fmt"
+	"io"
+	"strings"
 
 	errText "github.com/Dorrrke/GophKeeper-client/internal/domain/errors"
 	"github.com/fernet/fernet-go"
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/chacha20poly1305"
+	"golang.org/x/crypto/hkdf"
 )
 
-const DecodeKey = "cx_0x654RpI-jtBZ7oE8h_eQsKImvJlKFeSbXpwM7e4="
+// legacyDecodeKey - ключ, которым шифровался auth_conf до появления ключа хранилища.
+// Используется только для чтения старых файлов при миграции.
+const legacyDecodeKey = "cx_0x654RpI-jtBZ7oE8h_eQsKImvJlKFeSbXpwM7e4="
 
-var ErrDecodeData = errors.New(errText.DataDecryptError)
+const (
+	// KeyLen - длина ключа хранилища в байтах.
+	KeyLen = 32
+	// SaltLen - длина соли Argon2id в байтах.
+	SaltLen = 16
+)
 
-func Decoder(encData []byte) ([]byte, error) {
-	key := fernet.MustDecodeKeys(DecodeKey)
-	data := fernet.VerifyAndDecrypt(encData, -1, key)
+var (
+	ErrDecodeData      = errors.New(errText.DataDecryptError)
+	ErrInvalidKey      = errors.New(errText.InvalidVaultKeyError)
+	ErrInvalidKDFParam = errors.New(errText.InvalidKDFParamsError)
+)
+
+// KDFParams - параметры Argon2id, с которыми из мастер-пароля получается ключ хранилища.
+type KDFParams struct {
+	Time    uint32
+	Memory  uint32
+	Threads uint8
+}
+
+// DefaultKDFParams - параметры Argon2id для новых пользователей.
+var DefaultKDFParams = KDFParams{
+	Time:    3,
+	Memory:  64 * 1024,
+	Threads: 4,
+}
+
+// String возвращает параметры в виде "m=65536,t=3,p=4" для хранения в таблице users.
+func (p KDFParams) String() string {
+	return fmt.Sprintf("m=%d,t=%d,p=%d", p.Memory, p.Time, p.Threads)
+}
+
+// ParseKDFParams разбирает строку, полученную из KDFParams.String.
+func ParseKDFParams(s string) (KDFParams, error) {
+	var p KDFParams
+	_, err := fmt.Sscanf(s, "m=%d,t=%d,p=%d", &p.Memory, &p.Time, &p.Threads)
+	if err != nil {
+		return KDFParams{}, fmt.Errorf("%w: %s", ErrInvalidKDFParam, s)
+	}
+	if p.Memory == 0 || p.Time == 0 || p.Threads == 0 {
+		return KDFParams{}, fmt.Errorf("%w: %s", ErrInvalidKDFParam, s)
+	}
+	return p, nil
+}
+
+// NewSalt генерирует случайную соль для Argon2id.
+func NewSalt() ([]byte, error) {
+	salt := make([]byte, SaltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	return salt, nil
+}
+
+// NewKey генерирует случайный ключ длины KeyLen.
+func NewKey() ([]byte, error) {
+	key := make([]byte, KeyLen)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// DeriveKey получает ключ хранилища из мастер-пароля пользователя.
+func DeriveKey(password string, salt []byte, params KDFParams) []byte {
+	return argon2.IDKey([]byte(password), salt, params.Time, params.Memory, params.Threads, KeyLen)
+}
+
+// Decoder расшифровывает данные ключом хранилища.
+func Decoder(key []byte, encData []byte) ([]byte, error) {
+	fKey, err := fernetKey(key)
+	if err != nil {
+		return nil, err
+	}
+	data := fernet.VerifyAndDecrypt(encData, -1, []*fernet.Key{fKey})
 	if len(data) == 0 {
 		return nil, ErrDecodeData
 	}
 	return data, nil
 }
 
-func Encoder(data []byte) ([]byte, error) {
-	key := fernet.MustDecodeKeys(DecodeKey)
-	encData, err := fernet.EncryptAndSign(data, key[0])
+// Encoder шифрует данные ключом хранилища.
+func Encoder(key []byte, data []byte) ([]byte, error) {
+	fKey, err := fernetKey(key)
+	if err != nil {
+		return nil, err
+	}
+	encData, err := fernet.EncryptAndSign(data, fKey)
 	if err != nil {
 		return nil, err
 	}
 	return encData, nil
 }
+
+// LegacyDecoder расшифровывает данные, зашифрованные встроенным в бинарник ключом.
+func LegacyDecoder(encData []byte) ([]byte, error) {
+	key := fernet.MustDecodeKeys(legacyDecodeKey)
+	data := fernet.VerifyAndDecrypt(encData, -1, key)
+	if len(data) == 0 {
+		return nil, ErrDecodeData
+	}
+	return data, nil
+}
+
+func fernetKey(key []byte) (*fernet.Key, error) {
+	if len(key) != KeyLen {
+		return nil, ErrInvalidKey
+	}
+	var fKey fernet.Key
+	copy(fKey[:], key)
+	return &fKey, nil
+}
+
+// sealedPrefix - признак значения, зашифрованного Seal.
+const sealedPrefix = "enc:v1:"
+
+// Seal шифрует значение поля с помощью XChaCha20-Poly1305.
+// ad связывает шифротекст с местом хранения, чтобы значение нельзя было перенести в другое поле.
+func Seal(key []byte, data []byte, ad []byte) (string, error) {
+	aead, err := chacha20poly1305.NewX(key)
+	if err != nil {
+		return "", ErrInvalidKey
+	}
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(data)+aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	encData := aead.Seal(nonce, nonce, data, ad)
+	return sealedPrefix + base64.RawStdEncoding.EncodeToString(encData), nil
+}
+
+// Open расшифровывает значение, полученное из Seal.
+func Open(key []byte, sealed string, ad []byte) ([]byte, error) {
+	if !IsSealed(sealed) {
+		return nil, ErrDecodeData
+	}
+	aead, err := chacha20poly1305.NewX(key)
+	if err != nil {
+		return nil, ErrInvalidKey
+	}
+	encData, err := base64.RawStdEncoding.DecodeString(strings.TrimPrefix(sealed, sealedPrefix))
+	if err != nil || len(encData) < aead.NonceSize() {
+		return nil, ErrDecodeData
+	}
+	nonce, encData := encData[:aead.NonceSize()], encData[aead.NonceSize():]
+	data, err := aead.Open(nil, nonce, encData, ad)
+	if err != nil {
+		return nil, ErrDecodeData
+	}
+	return data, nil
+}
+
+// IsSealed проверяет, зашифровано ли значение с помощью Seal.
+func IsSealed(value string) bool {
+	return strings.HasPrefix(value, sealedPrefix)
+}
+
+// SubKey получает из ключа хранилища отдельный ключ для указанного назначения.
+func SubKey(key []byte, purpose string) ([]byte, error) {
+	subKey := make([]byte, KeyLen)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, key, nil, []byte(purpose)), subKey); err != nil {
+		return nil, err
+	}
+	return subKey, nil
+}
+
+// serverSaltPrefix - префикс соли корневого ключа, из которого получаются ключи для работы с сервером.
+const serverSaltPrefix = "gophkeeper server keys v1:"
+
+// Назначения ключей, получаемых из корневого ключа для работы с сервером.
+const (
+	authKeyPurpose = "gophkeeper server auth v1"
+	syncKeyPurpose = "gophkeeper sync key v2"
+)
+
+// ServerKeys - ключи для работы с сервером, полученные из мастер-пароля.
+type ServerKeys struct {
+	// Auth - пароль входа на сервер. Мастер-пароль на сервер не передается.
+	Auth string
+	// Sync - ключ, которым шифруются данные, отправляемые на сервер.
+	Sync []byte
+}
+
+// DeriveServerKeys получает из мастер-пароля пароль входа на сервер и ключ синхронизации.
+// Оба ключа получаются из общего корневого ключа для разных назначений, поэтому по паролю входа,
+// который видит сервер, нельзя получить ключ синхронизации.
+// В отличие от ключа хранилища соль зависит только от логина, поэтому ключи совпадают на всех устройствах пользователя.
+func DeriveServerKeys(password string, login string) (ServerKeys, error) {
+	salt := sha256.Sum256([]byte(serverSaltPrefix + login))
+	root := DeriveKey(password, salt[:SaltLen], DefaultKDFParams)
+	auth, err := SubKey(root, authKeyPurpose)
+	if err != nil {
+		return ServerKeys{}, err
+	}
+	sync, err := SubKey(root, syncKeyPurpose)
+	if err != nil {
+		return ServerKeys{}, err
+	}
+	return ServerKeys{Auth: base64.RawStdEncoding.EncodeToString(auth), Sync: sync}, nil
+}
@@ -0,0 +1,139 @@
+package coder
+
+import (
+	"encoding/base64"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testParams - облегченные параметры Argon2id, чтобы тесты выполнялись быстро.
+var testParams = KDFParams{Time: 1, Memory: 1024, Threads: 1}
+
+func TestDeriveKey(t *testing.T) {
+	salt := []byte("0123456789abcdef")
+	key := DeriveKey("master", salt, testParams)
+	assert.Len(t, key, KeyLen)
+	assert.Equal(t, key, DeriveKey("master", salt, testParams))
+
+	assert.NotEqual(t, key, DeriveKey("Master", salt, testParams))
+	assert.NotEqual(t, key, DeriveKey("master", []byte("fedcba9876543210"), testParams))
+	assert.NotEqual(t, key, DeriveKey("master", salt, KDFParams{Time: 2, Memory: 1024, Threads: 1}))
+
+}
+
+func TestDeriveServerKeys(t *testing.T) {
+	keys, err := DeriveServerKeys("master", "gopher")
+	require.NoError(t, err)
+	assert.Len(t, keys.Sync, KeyLen)
+	again, err := DeriveServerKeys("master", "gopher")
+	require.NoError(t, err)
+	assert.Equal(t, keys, again)
+	other, err := DeriveServerKeys("master", "rustacean")
+	require.NoError(t, err)
+	assert.NotEqual(t, keys.Sync, other.Sync)
+	assert.NotEqual(t, keys.Auth, other.Auth)
+
+	// Сервер получает только пароль входа: он не совпадает ни с мастер-паролем, ни с ключом синхронизации,
+	// и ключ синхронизации из него не получить.
+	assert.NotEqual(t, "master", keys.Auth)
+	auth, err := base64.RawStdEncoding.DecodeString(keys.Auth)
+	require.NoError(t, err)
+	assert.NotEqual(t, keys.Sync, auth)
+	assert.NotContains(t, keys.Auth, base64.RawStdEncoding.EncodeToString(keys.Sync))
+	fromAuth, err := DeriveServerKeys(keys.Auth, "gopher")
+	require.NoError(t, err)
+	assert.NotEqual(t, keys.Sync, fromAuth.Sync)
+	sub, err := SubKey(auth, syncKeyPurpose)
+	require.NoError(t, err)
+	assert.NotEqual(t, keys.Sync, sub)
+}
+
+func TestKDFParams(t *testing.T) {
+	p, err := ParseKDFParams(DefaultKDFParams.String())
+	require.NoError(t, err)
+	assert.Equal(t, DefaultKDFParams, p)
+
+	for _, s := range []string{"", "m=0,t=3,p=4", "t=3,m=65536,p=4", "m=65536,t=3"} {
+		_, err := ParseKDFParams(s)
+		assert.ErrorIs(t, err, ErrInvalidKDFParam, s)
+	}
+}
+
+func TestSealOpen(t *testing.T) {
+	key := DeriveKey("master", []byte("0123456789abcdef"), testParams)
+	other := DeriveKey("other", []byte("0123456789abcdef"), testParams)
+	ad := []byte("cards:number:visa")
+
+	sealed, err := Seal(key, []byte("4111111111111111"), ad)
+	require.NoError(t, err)
+	assert.True(t, IsSealed(sealed))
+	assert.NotContains(t, sealed, "4111")
+	again, err := Seal(key, []byte("4111111111111111"), ad)
+	require.NoError(t, err)
+	assert.NotEqual(t, sealed, again)
+
+	data, err := Open(key, sealed, ad)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("4111111111111111"), data)
+
+	_, err = Open(other, sealed, ad)
+	assert.ErrorIs(t, err, ErrDecodeData)
+	_, err = Open(key, sealed, []byte("cards:cvv:visa"))
+	assert.ErrorIs(t, err, ErrDecodeData)
+	_, err = Open(key, "4111111111111111", ad)
+	assert.ErrorIs(t, err, ErrDecodeData)
+	_, err = Open(key, sealedPrefix+"AAAA", ad)
+	assert.ErrorIs(t, err, ErrDecodeData)
+
+	tampered := []byte(sealed)
+	i := len(sealedPrefix) + 10
+	if tampered[i] == 'A' {
+		tampered[i] = 'B'
+	} else {
+		tampered[i] = 'A'
+	}
+	_, err = Open(key, string(tampered), ad)
+	assert.ErrorIs(t, err, ErrDecodeData)
+
+	_, err = Seal(key[:16], []byte("data"), ad)
+	assert.ErrorIs(t, err, ErrInvalidKey)
+	_, err = Open(key[:16], sealed, ad)
+	assert.ErrorIs(t, err, ErrInvalidKey)
+}
+
+func TestEncoder(t *testing.T) {
+	key, err := NewKey()
+	require.NoError(t, err)
+	assert.Len(t, key, KeyLen)
+
+	encData, err := Encoder(key, []byte(`{"login":"gopher"}`))
+	require.NoError(t, err)
+	assert.NotContains(t, string(encData), "gopher")
+	data, err := Decoder(key, encData)
+	require.NoError(t, err)
+	assert.Equal(t, []byte(`{"login":"gopher"}`), data)
+
+	other, err := NewKey()
+	require.NoError(t, err)
+	_, err = Decoder(other, encData)
+	assert.ErrorIs(t, err, ErrDecodeData)
+	_, err = Encoder(key[:8], []byte("data"))
+	assert.ErrorIs(t, err, ErrInvalidKey)
+}
+
+func TestSubKey(t *testing.T) {
+	key, err := NewKey()
+	require.NoError(t, err)
+	sync, err := SubKey(key, "sync")
+	require.NoError(t, err)
+	assert.Len(t, sync, KeyLen)
+	again, err := SubKey(key, "sync")
+	require.NoError(t, err)
+	assert.Equal(t, sync, again)
+	meta, err := SubKey(key, "meta")
+	require.NoError(t, err)
+	assert.NotEqual(t, sync, meta)
+	assert.NotEqual(t, key, sync)
+}
@@ -0,0 +1,48 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/Dorrrke/GophKeeper-client/internal/render"
+	"github.com/spf13/cobra"
+)
+
+// accesslogCmd represents the access_log command
+var accesslogCmd = &cobra.Command{
+	Use:   "access_log",
+	Short: "Отображает журнал показа секретных данных",
+	Long: `При вызове отображает последние записи журнала доступа:
+	когда и какие номера карт или пароли были показаны без маски с флагом --reveal.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		keepService, err := setupService(false)
+		if err != nil {
+			fmt.Printf("Ошибка при конфигурации сервиса %s", err.Error())
+			return
+		}
+		userModel, err := getUserID()
+		if err != nil {
+			fmt.Printf("Ошибка при получении данных %s", err.Error())
+			return
+		}
+		limit, err := cmd.Flags().GetInt("limit")
+		if err != nil {
+			fmt.Printf("Ошибка при получении флага: %s", err.Error())
+			return
+		}
+		entries, err := keepService.AccessLog(userModel.UserID, limit)
+		if err != nil {
+			fmt.Printf("Ошибка при получении данных: %s", err.Error())
+			return
+		}
+		if len(entries) == 0 {
+			fmt.Printf("Журнал доступа пуст")
+			return
+		}
+		fmt.Print(render.AccessLog(entries))
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(accesslogCmd)
+	accesslogCmd.Flags().Int("limit", 50, "Количество последних записей")
+}
@@ -0,0 +1,89 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	errText "github.com/Dorrrke/GophKeeper-client/internal/domain/errors"
+	"github.com/Dorrrke/GophKeeper-client/internal/services"
+	"github.com/spf13/cobra"
+)
+
+var errInvalidMeta = errors.New(errText.InvalidMetaError)
+
+// recordAttrs - атрибуты записи из флагов команды сохранения.
+// Незаданные флаги дают nil и пустую папку, и при обновлении записи эти атрибуты не меняются.
+type recordAttrs struct {
+	meta   map[string]string
+	folder string
+	tags   []string
+}
+
+// addAttrFlags добавляет команде сохранения флаги --meta, --folder и --tag.
+func addAttrFlags(cmd *cobra.Command) {
+	cmd.Flags().StringArray("meta", nil,
+		"Метаданные записи в виде key=value, флаг можно повторять. При --update заменяют все метаданные записи")
+	cmd.Flags().String("folder", "", "Папка записи вида Работа/Банки, при --update \"/\" переносит запись в корень")
+	cmd.Flags().StringArray("tag", nil, "Тег записи, флаг можно повторять. При --update заменяет все теги, --tag \"\" удаляет их")
+}
+
+// attrsFromFlags разбирает флаги --meta, --folder и --tag.
+func attrsFromFlags(cmd *cobra.Command) (recordAttrs, error) {
+	meta, err := metaFromFlags(cmd)
+	if err != nil {
+		return recordAttrs{}, err
+	}
+	folder, err := cmd.Flags().GetString("folder")
+	if err != nil {
+		return recordAttrs{}, err
+	}
+	var tags []string
+	if cmd.Flags().Changed("tag") {
+		tags, err = cmd.Flags().GetStringArray("tag")
+		if err != nil {
+			return recordAttrs{}, err
+		}
+	}
+	return recordAttrs{meta: meta, folder: folder, tags: tags}, nil
+}
+
+// metaFromFlags разбирает флаги --meta. Если флаг не указан, возвращает nil.
+func metaFromFlags(cmd *cobra.Command) (map[string]string, error) {
+	if !cmd.Flags().Changed("meta") {
+		return nil, nil
+	}
+	pairs, err := cmd.Flags().GetStringArray("meta")
+	if err != nil {
+		return nil, err
+	}
+	meta := make(map[string]string, len(pairs))
+	for _, pair := range pairs {
+		key, value, ok := strings.Cut(pair, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			return nil, fmt.Errorf("%w: %q", errInvalidMeta, pair)
+		}
+		meta[key] = value
+	}
+	return meta, nil
+}
+
+// addFilterFlags добавляет команде вывода списка фильтры --folder и --tag.
+func addFilterFlags(cmd *cobra.Command) {
+	cmd.Flags().String("folder", "", "Показать только записи из папки и вложенных в нее папок")
+	cmd.Flags().StringArray("tag", nil, "Показать только записи с тегом, при повторении флага - со всеми указанными тегами")
+}
+
+// filterFromFlags собирает фильтр записей из флагов --folder и --tag.
+func filterFromFlags(cmd *cobra.Command) (services.RecordFilter, error) {
+	path, err := cmd.Flags().GetString("folder")
+	if err != nil {
+		return services.RecordFilter{}, err
+	}
+	tags, err := cmd.Flags().GetStringArray("tag")
+	if err != nil {
+		return services.RecordFilter{}, err
+	}
+	return services.NewRecordFilter(path, tags)
+}
@@ -0,0 +1,83 @@
+/*
+Copyright © 2024 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Dorrrke/GophKeeper-client/internal/audit"
+	"github.com/spf13/cobra"
+)
+
+// auditCmd represents the audit command
+var auditCmd = &cobra.Command{
+	Use:   "audit",
+	Short: "Проверка сохраненных паролей",
+	Long: `Проверяет сохраненные пары логин/пароль: слабые пароли, повторное использование одного пароля
+	и пароли, которые не менялись дольше заданного срока. Записи выводятся по убыванию риска.
+	С флагом --json отчет выводится в формате JSON.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		keepService, err := setupService(false)
+		if err != nil {
+			fmt.Printf("Ошибка при конфигурации сервиса %s", err.Error())
+			return
+		}
+		userModel, err := getUserID()
+		if err != nil {
+			fmt.Printf("Ошибка при получении данных %s", err.Error())
+			return
+		}
+		maxAgeDays, err := cmd.Flags().GetInt("max-age-days")
+		if err != nil {
+			fmt.Printf("Ошибка при получении флага: %s", err.Error())
+			return
+		}
+		jsonFlag, err := cmd.Flags().GetBool("json")
+		if err != nil {
+			fmt.Printf("Ошибка при получении флага: %s", err.Error())
+			return
+		}
+		report, err := keepService.AuditLogins(userModel.UserID, time.Duration(maxAgeDays)*24*time.Hour)
+		if err != nil {
+			fmt.Printf("Ошибка при получении данных: %s", err.Error())
+			return
+		}
+		if jsonFlag {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			if err := enc.Encode(report); err != nil {
+				fmt.Printf("Ошибка при формировании отчета: %s", err.Error())
+			}
+			return
+		}
+		printAuditReport(report)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(auditCmd)
+	auditCmd.Flags().Bool("json", false, "Вывести отчет в формате JSON")
+	auditCmd.Flags().Int("max-age-days", 180, "Срок в днях, после которого пароль считается старым, 0 - не проверять")
+}
+
+func printAuditReport(report audit.Report) {
+	if report.Total == 0 {
+		fmt.Println("Нет сохраненных данных")
+		return
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "RISK\tNAME\tLOGIN\tSCORE\tENTROPY\tAGE\tREUSED WITH\tISSUES")
+	for _, entry := range report.Entries {
+		fmt.Fprintf(w, "%d\t%s\t%s\t%d/4\t%.1f\t%dd\t%s\t%s\n", entry.Risk, entry.Name, entry.Login, entry.Score,
+			entry.Entropy, entry.AgeDays, strings.Join(entry.ReusedWith, ","), strings.Join(entry.Issues, ", "))
+	}
+	w.Flush()
+	fmt.Printf("\nВсего: %d, слабых: %d, повторяющихся: %d, старых: %d\n", report.Total, report.Weak, report.Reused, report.Old)
+}
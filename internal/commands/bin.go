@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"os"
 
+	"github.com/Dorrrke/GophKeeper-client/internal/render"
 	"github.com/Dorrrke/GophKeeper-client/internal/storage"
 	"github.com/spf13/cobra"
 )
@@ -53,6 +54,7 @@ var binCmd = &cobra.Command{
 		}
 		writeBinFile(bin.Name, bin.Data)
 		fmt.Println("Created saved bin file with name " + bin.Name)
+		fmt.Print(render.Attrs(bin.Folder, bin.Tags, bin.Meta))
 	},
 }
 
@@ -5,7 +5,10 @@ package cmd
 
 import (
 	"fmt"
+	"slices"
 
+	"github.com/Dorrrke/GophKeeper-client/internal/domain/models"
+	"github.com/Dorrrke/GophKeeper-client/internal/render"
 	"github.com/spf13/cobra"
 )
 
@@ -31,22 +34,25 @@ var binListCmd = &cobra.Command{
 			fmt.Printf("Ошибка при получении данных: %s", err.Error())
 			return
 		}
+		filter, err := filterFromFlags(cmd)
+		if err != nil {
+			fmt.Printf("Ошибка в параметрах фильтра: %s\n", err.Error())
+			return
+		}
+		bins = slices.DeleteFunc(bins, func(r models.BinaryDataModel) bool {
+			return !filter.Match(r.Folder, r.Tags)
+		})
 		if len(bins) == 0 {
 			fmt.Printf("Нет сохраненных данных")
 			return
 		}
-		bList := ""
-		for _, bin := range bins {
-			binStr := fmt.Sprintf("\n\tBin name: %s\n",
-				bin.Name)
-			bList += binStr
-		}
-		fmt.Printf("Bin list: %s", bList)
+		fmt.Print(render.Bins(bins))
 	},
 }
 
 func init() {
 	rootCmd.AddCommand(binListCmd)
+	addFilterFlags(binListCmd)
 
 	// Here you will define your flags and configuration settings.
 
@@ -5,20 +5,26 @@ package cmd
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
 	"fmt"
-	"os"
+	"sync"
+	"time"
 
 	"github.com/Dorrrke/GophKeeper-client/internal/client"
-	"github.com/Dorrrke/GophKeeper-client/internal/coder"
 	"github.com/Dorrrke/GophKeeper-client/internal/config"
-	"github.com/Dorrrke/GophKeeper-client/internal/domain/models"
+	"github.com/Dorrrke/GophKeeper-client/internal/render"
 	"github.com/Dorrrke/GophKeeper-client/internal/services"
 	"github.com/Dorrrke/GophKeeper-client/internal/storage"
 	"github.com/spf13/cobra"
 )
 
+var (
+	// appConfig заполняется глобальными флагами команды, см. init в root.go.
+	appConfig  *config.Config
+	configOnce sync.Once
+	configErr  error
+)
+
 // cardCmd represents the card command
 var cardCmd = &cobra.Command{
 	Use:   "card",
@@ -59,14 +65,14 @@ var cardCmd = &cobra.Command{
 			fmt.Printf("Ошибка при получении данных %s", err.Error())
 			return
 		}
-		fmt.Printf("\nCard name: %s \n\tNumber: %s \n\tDate: %s\n\tCVV: %v\n",
-			card.Name, card.Number, card.Date, card.CVVCode)
+		fmt.Print(render.Card(card, revealRequested(cmd, keepService, userModel.UserID, services.AccessKindCard, card.Name), time.Now()))
 	},
 }
 
 func init() {
 	rootCmd.AddCommand(cardCmd)
 	cardCmd.Flags().Bool("delete", false, "Удаление данных")
+	addRevealFlag(cardCmd)
 
 	// Here you will define your flags and configuration settings.
 
@@ -80,37 +86,52 @@ func init() {
 }
 
 func setupService(sync bool) (*services.KeepService, error) {
-	cfg := config.ReadConfig()
+	cfg, err := readConfig()
+	if err != nil {
+		return nil, err
+	}
 	storage, err := storage.New(cfg.DBPath)
 	if err != nil {
 		return nil, err
 	}
+	if vaultKey, err := readVaultKey(); err == nil {
+		if err := storage.SetVaultKey(vaultKey); err != nil {
+			return nil, err
+		}
+	}
+	storage.SetHistoryRetention(cfg.HistoryRetention)
+	hasher, err := services.NewHasher(cfg.Hash)
+	if err != nil {
+		return nil, err
+	}
+	policy := services.DefaultLoginPolicy
+	policy.LockoutAfter = cfg.Login.LockoutAfter
+	policy.LockoutCooldown = cfg.Login.LockoutCooldown
 	if sync {
-		clietn, err := client.New(context.Background(), cfg.ServerAddr)
+		clietn, err := client.New(context.Background(), cfg.ServerAddr, cfg.TLS)
 		if err != nil {
 			return nil, fmt.Errorf("clietn init error: %w", err)
 		}
-		keepService := services.New(clietn, storage, storage, storage, storage, storage, storage)
+		if session, err := getUserID(); err == nil {
+			clietn.SetSyncKey(session.SyncKey)
+			clietn.SetTokens(session.Tokens)
+		}
+		keepService := services.New(clietn, storage, storage, storage, storage, storage, storage, storage)
+		keepService.SetHasher(hasher)
+		keepService.SetLoginPolicy(policy)
+		keepService.SetTrashRetention(cfg.TrashRetention)
 		return keepService, nil
 	}
 	client := client.KeeperClient{}
-	keepService := services.New(&client, storage, storage, storage, storage, storage, storage)
+	keepService := services.New(&client, storage, storage, storage, storage, storage, storage, storage)
+	keepService.SetHasher(hasher)
+	keepService.SetLoginPolicy(policy)
+	keepService.SetTrashRetention(cfg.TrashRetention)
 	return keepService, nil
 }
 
-func getUserID() (models.UserModel, error) {
-	f, err := os.ReadFile("auth_conf")
-	if err != nil {
-		return models.UserModel{}, err
-	}
-	data, err := coder.Decoder(f)
-	if err != nil {
-		return models.UserModel{}, err
-	}
-	var userModel models.UserModel
-	err = json.Unmarshal(data, &userModel)
-	if err != nil {
-		return models.UserModel{}, err
-	}
-	return userModel, nil
+// readConfig дополняет конфигурацию из флагов переменными окружения один раз за запуск команды.
+func readConfig() (*config.Config, error) {
+	configOnce.Do(func() { configErr = appConfig.ReadEnv() })
+	return appConfig, configErr
 }
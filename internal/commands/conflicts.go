@@ -0,0 +1,174 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/Dorrrke/GophKeeper-client/internal/domain/models"
+	"github.com/Dorrrke/GophKeeper-client/internal/render"
+	"github.com/Dorrrke/GophKeeper-client/internal/services"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+// askPolicy - политика разрешения конфликтов, при которой способ выбирается в терминале для каждого конфликта.
+const askPolicy = "ask"
+
+// conflictsCmd represents the conflicts command
+var conflictsCmd = &cobra.Command{
+	Use:   "conflicts",
+	Short: "Отображает неразрешенные конфликты синхронизации",
+	Long: `Конфликт возникает, если запись изменили и на устройстве, и на другом устройстве после последней синхронизации.
+	Способ разрешения при синхронизации задается флагом --conflict-policy или переменной окружения CONFLICT_POLICY: ask - спросить в терминале,
+	local - оставить версию с устройства, remote - принять версию с сервера, both - сохранить обе,
+	skip - отложить. Отложенные конфликты разрешаются командой conflicts resolve,
+	до этого запись не отправляется на сервер.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		keepService, err := setupService(false)
+		if err != nil {
+			fmt.Printf("Ошибка при конфигурации сервиса %s", err.Error())
+			return
+		}
+		userModel, err := getUserID()
+		if err != nil {
+			fmt.Printf("Ошибка при получении данных %s", err.Error())
+			return
+		}
+		conflicts, err := keepService.Conflicts(userModel.UserID)
+		if err != nil {
+			fmt.Printf("Ошибка при получении данных: %s", err.Error())
+			return
+		}
+		if len(conflicts) == 0 {
+			fmt.Printf("Нет неразрешенных конфликтов")
+			return
+		}
+		fmt.Print(render.Conflicts(conflicts))
+	},
+}
+
+// conflictsResolveCmd represents the conflicts resolve command
+var conflictsResolveCmd = &cobra.Command{
+	Use:   "resolve <card|auth|text|bin|otp> <name> [--keep local|remote|both]",
+	Short: "Разрешает отложенный конфликт синхронизации",
+	Long: `Без флага --keep показывает различия версий и запрашивает способ разрешения в терминале.
+	local оставляет версию с устройства, remote принимает версию с сервера,
+	both принимает версию с сервера и сохраняет версию с устройства копией под новым именем.
+	Результат отправляется на сервер при следующей синхронизации.
+	Пример использование: gophkeeper conflicts resolve auth github --keep remote`,
+	Args: cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		keepService, err := setupService(false)
+		if err != nil {
+			fmt.Printf("Ошибка при конфигурации сервиса %s", err.Error())
+			return
+		}
+		userModel, err := getUserID()
+		if err != nil {
+			fmt.Printf("Ошибка при получении данных %s", err.Error())
+			return
+		}
+		keep, err := cmd.Flags().GetString("keep")
+		if err != nil {
+			fmt.Printf("Ошибка при получении флага: %s", err.Error())
+			return
+		}
+		var res services.ConflictResolution
+		if keep != "" {
+			res, err = services.ParseConflictResolution(keep)
+		} else {
+			res, err = askConflict(keepService, args[0], args[1], userModel.UserID)
+		}
+		if err != nil {
+			printConflictError(err)
+			return
+		}
+		if res == services.ResolveLater {
+			fmt.Printf("Конфликт отложен\n")
+			return
+		}
+		if err := keepService.ResolveConflict(args[0], args[1], res, userModel.UserID); err != nil {
+			printConflictError(err)
+			return
+		}
+		fmt.Printf("Конфликт разрешен, изменения будут отправлены при следующей синхронизации\n")
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(conflictsCmd)
+	conflictsCmd.AddCommand(conflictsResolveCmd)
+	conflictsResolveCmd.Flags().String("keep", "", "Способ разрешения: local, remote или both")
+}
+
+// askConflict находит отложенный конфликт записи и запрашивает способ его разрешения.
+func askConflict(keepService *services.KeepService, kind string, name string, uID int64) (services.ConflictResolution, error) {
+	conflicts, err := keepService.Conflicts(uID)
+	if err != nil {
+		return "", err
+	}
+	for _, conflict := range conflicts {
+		if conflict.Kind == kind && conflict.Name == name {
+			return promptConflict(conflict)
+		}
+	}
+	return "", services.ErrConflictNotExist
+}
+
+// conflictResolver возвращает способ разрешения конфликтов при синхронизации по политике из конфигурации.
+func conflictResolver(policy string) (services.ConflictResolver, error) {
+	if policy == askPolicy {
+		return promptConflict, nil
+	}
+	res, err := services.ParseConflictResolution(policy)
+	if err != nil {
+		return nil, err
+	}
+	return services.PolicyResolver(res), nil
+}
+
+// promptConflict запрашивает способ разрешения конфликта в терминале.
+// Без терминала конфликт откладывается, чтобы синхронизация из скриптов не ждала ввода.
+func promptConflict(conflict models.ConflictModel) (services.ConflictResolution, error) {
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		return services.ResolveLater, nil
+	}
+	fmt.Fprintf(os.Stderr, "Запись %s %s изменена и на устройстве, и на сервере.\n", conflict.Kind, conflict.Name)
+	for {
+		fmt.Fprint(os.Stderr, "[l] оставить версию с устройства, [r] принять версию с сервера, [b] сохранить обе, [d] показать различия, [s] отложить: ")
+		answer, err := readLine()
+		if errors.Is(err, errEmptySecret) {
+			continue
+		}
+		if err != nil {
+			return "", err
+		}
+		switch strings.ToLower(strings.TrimSpace(answer)) {
+		case "l":
+			return services.KeepLocal, nil
+		case "r":
+			return services.KeepRemote, nil
+		case "b":
+			return services.KeepBoth, nil
+		case "s":
+			return services.ResolveLater, nil
+		case "d":
+			fmt.Fprint(os.Stderr, render.ConflictDiff(conflict))
+		}
+	}
+}
+
+// printConflictError выводит понятное сообщение об ошибке разрешения конфликта.
+func printConflictError(err error) {
+	switch {
+	case errors.Is(err, services.ErrConflictNotExist):
+		fmt.Printf("Неразрешенного конфликта для такой записи нет.")
+	case errors.Is(err, services.ErrConflictPolicy):
+		fmt.Printf("Неизвестный способ разрешения, укажите local, remote или both.")
+	default:
+		printRecordError(err)
+	}
+}
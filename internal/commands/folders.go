@@ -0,0 +1,146 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/Dorrrke/GophKeeper-client/internal/folder"
+	"github.com/Dorrrke/GophKeeper-client/internal/render"
+	"github.com/Dorrrke/GophKeeper-client/internal/storage"
+	"github.com/spf13/cobra"
+)
+
+// foldersCmd represents the folders command
+var foldersCmd = &cobra.Command{
+	Use:   "folders",
+	Short: "Отображает дерево папок пользователя",
+	Long: `При вызове отображает все папки пользователя деревом.
+	Записи помещаются в папки флагом --folder команд сохранения, списки фильтруются флагом --folder команд вывода.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		keepService, err := setupService(false)
+		if err != nil {
+			fmt.Printf("Ошибка при конфигурации сервиса %s", err.Error())
+			return
+		}
+		userModel, err := getUserID()
+		if err != nil {
+			fmt.Printf("Ошибка при получении данных %s", err.Error())
+			return
+		}
+		paths, err := keepService.Folders(userModel.UserID)
+		if err != nil {
+			fmt.Printf("Ошибка при получении данных: %s", err.Error())
+			return
+		}
+		if len(paths) == 0 {
+			fmt.Printf("Нет папок")
+			return
+		}
+		fmt.Print(render.Folders(paths))
+	},
+}
+
+// folderCreateCmd represents the folder_create command
+var folderCreateCmd = &cobra.Command{
+	Use:   "folder_create <path>",
+	Short: "Создает папку",
+	Long: `Создает папку по указанному пути, например Работа/Банки.
+	Недостающие родительские папки создаются автоматически.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		keepService, err := setupService(false)
+		if err != nil {
+			fmt.Printf("Ошибка при конфигурации сервиса %s", err.Error())
+			return
+		}
+		userModel, err := getUserID()
+		if err != nil {
+			fmt.Printf("Ошибка при получении данных %s", err.Error())
+			return
+		}
+		err = keepService.CreateFolder(args[0], userModel.UserID)
+		if err != nil {
+			printFolderError(err)
+			return
+		}
+		fmt.Printf("Папка создана\n")
+	},
+}
+
+// folderRenameCmd represents the folder_rename command
+var folderRenameCmd = &cobra.Command{
+	Use:   "folder_rename <path> <new-name>",
+	Short: "Переименовывает папку",
+	Long: `Меняет имя папки, не перемещая ее. Вложенные папки и записи остаются в переименованной папке.
+	Пример использование: gophkeeper folder_rename Работа/Банки Финансы`,
+	Args: cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		keepService, err := setupService(false)
+		if err != nil {
+			fmt.Printf("Ошибка при конфигурации сервиса %s", err.Error())
+			return
+		}
+		userModel, err := getUserID()
+		if err != nil {
+			fmt.Printf("Ошибка при получении данных %s", err.Error())
+			return
+		}
+		err = keepService.RenameFolder(args[0], args[1], userModel.UserID)
+		if err != nil {
+			printFolderError(err)
+			return
+		}
+		fmt.Printf("Папка переименована\n")
+	},
+}
+
+// folderMoveCmd represents the folder_move command
+var folderMoveCmd = &cobra.Command{
+	Use:   "folder_move <path> <new-parent>",
+	Short: "Перемещает папку в другую папку",
+	Long: `Перемещает папку вместе с вложенными папками и записями в другую родительскую папку.
+	Для переноса в корень укажите "/".
+	Пример использование: gophkeeper folder_move Работа/Банки Личное`,
+	Args: cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		keepService, err := setupService(false)
+		if err != nil {
+			fmt.Printf("Ошибка при конфигурации сервиса %s", err.Error())
+			return
+		}
+		userModel, err := getUserID()
+		if err != nil {
+			fmt.Printf("Ошибка при получении данных %s", err.Error())
+			return
+		}
+		err = keepService.MoveFolder(args[0], args[1], userModel.UserID)
+		if err != nil {
+			printFolderError(err)
+			return
+		}
+		fmt.Printf("Папка перемещена\n")
+	},
+}
+
+// printFolderError выводит понятное сообщение об ошибке операции с папкой.
+func printFolderError(err error) {
+	switch {
+	case errors.Is(err, storage.ErrFolderExist):
+		fmt.Printf("Папка с таким путем уже существует.")
+	case errors.Is(err, storage.ErrFolderNotExist):
+		fmt.Printf("Папка не найдена.")
+	case errors.Is(err, folder.ErrCycle):
+		fmt.Printf("Нельзя переместить папку в саму себя или во вложенную папку.")
+	case errors.Is(err, folder.ErrInvalid):
+		fmt.Printf("Некорректный путь папки: %s", err.Error())
+	default:
+		fmt.Printf("Ошибка при изменении папки: %s", err.Error())
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(foldersCmd)
+	rootCmd.AddCommand(folderCreateCmd)
+	rootCmd.AddCommand(folderRenameCmd)
+	rootCmd.AddCommand(folderMoveCmd)
+}
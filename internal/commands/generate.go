@@ -0,0 +1,85 @@
+/*
+Copyright © 2024 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/Dorrrke/GophKeeper-client/internal/generator"
+	"github.com/spf13/cobra"
+)
+
+// generateCmd represents the generate command
+var generateCmd = &cobra.Command{
+	Use:   "generate",
+	Short: "Генерирует пароль",
+	Long: `Генерирует случайный пароль заданной длины из выбранных наборов символов.
+	С флагом --words генерирует парольную фразу diceware из словаря EFF.
+	Пример использования: gophkeeper generate --length 24 --no-symbols --exclude-ambiguous`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		password, err := generateFromFlags(cmd)
+		if err != nil {
+			fmt.Printf("Ошибка генерации пароля: %s\n", err.Error())
+			return
+		}
+		fmt.Println(password)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(generateCmd)
+	addGeneratorFlags(generateCmd)
+}
+
+// addGeneratorFlags добавляет команде флаги параметров генерации пароля.
+func addGeneratorFlags(cmd *cobra.Command) {
+	cmd.Flags().Int("length", generator.DefaultOptions.Length, "Длина пароля")
+	cmd.Flags().Bool("no-lower", false, "Не использовать строчные буквы")
+	cmd.Flags().Bool("no-upper", false, "Не использовать заглавные буквы")
+	cmd.Flags().Bool("no-digits", false, "Не использовать цифры")
+	cmd.Flags().Bool("no-symbols", false, "Не использовать специальные символы")
+	cmd.Flags().Bool("exclude-ambiguous", false, "Исключить похожие символы (I, l, 1, |, O, 0, o)")
+	cmd.Flags().Int("words", 0, "Сгенерировать парольную фразу из указанного числа слов")
+	cmd.Flags().String("separator", "-", "Разделитель слов парольной фразы")
+}
+
+// generateFromFlags генерирует пароль или парольную фразу по флагам команды.
+func generateFromFlags(cmd *cobra.Command) (string, error) {
+	flags := cmd.Flags()
+	words, err := flags.GetInt("words")
+	if err != nil {
+		return "", err
+	}
+	if words > 0 {
+		separator, err := flags.GetString("separator")
+		if err != nil {
+			return "", err
+		}
+		return generator.Passphrase(words, separator)
+	}
+	opts := generator.DefaultOptions
+	if opts.Length, err = flags.GetInt("length"); err != nil {
+		return "", err
+	}
+	for _, opt := range []struct {
+		flag  string
+		value *bool
+	}{
+		{"no-lower", &opts.Lower},
+		{"no-upper", &opts.Upper},
+		{"no-digits", &opts.Digits},
+		{"no-symbols", &opts.Symbols},
+	} {
+		disabled, err := flags.GetBool(opt.flag)
+		if err != nil {
+			return "", err
+		}
+		*opt.value = !disabled
+	}
+	if opts.ExcludeAmbiguous, err = flags.GetBool("exclude-ambiguous"); err != nil {
+		return "", err
+	}
+	return generator.Password(opts)
+}
@@ -5,8 +5,12 @@ package cmd
 
 import (
 	"fmt"
+	"slices"
 	"time"
 
+	"github.com/Dorrrke/GophKeeper-client/internal/domain/models"
+	"github.com/Dorrrke/GophKeeper-client/internal/render"
+	"github.com/Dorrrke/GophKeeper-client/internal/services"
 	"github.com/spf13/cobra"
 )
 
@@ -31,22 +35,31 @@ var getcardsCmd = &cobra.Command{
 		if err != nil {
 			fmt.Printf("Ошибка при получении данных: %s", err.Error())
 		}
+		filter, err := filterFromFlags(cmd)
+		if err != nil {
+			fmt.Printf("Ошибка в параметрах фильтра: %s\n", err.Error())
+			return
+		}
+		res = slices.DeleteFunc(res, func(r models.CardModel) bool {
+			return !filter.Match(r.Folder, r.Tags)
+		})
 		if len(res) == 0 {
 			fmt.Printf("Нет сохраненных данных")
 			return
 		}
-		cards := ""
+		names := make([]string, 0, len(res))
 		for _, card := range res {
-			cardStr := fmt.Sprintf("\nCard name: %s \n\tNumber: %s \n\tDate: %s\n\tCVV: %v\n",
-				card.Name, card.Number, card.Date, card.CVVCode)
-			cards += cardStr
+			names = append(names, card.Name)
 		}
-		fmt.Printf("Cards: %s", cards)
+		reveal := revealRequested(cmd, keepService, userModel.UserID, services.AccessKindCard, names...)
+		fmt.Print(render.Cards(res, reveal, time.Now()))
 	},
 }
 
 func init() {
 	rootCmd.AddCommand(getcardsCmd)
+	addFilterFlags(getcardsCmd)
+	addRevealFlag(getcardsCmd)
 
 	// Here you will define your flags and configuration settings.
 
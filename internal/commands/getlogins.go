@@ -5,7 +5,11 @@ package cmd
 
 import (
 	"fmt"
+	"slices"
 
+	"github.com/Dorrrke/GophKeeper-client/internal/domain/models"
+	"github.com/Dorrrke/GophKeeper-client/internal/render"
+	"github.com/Dorrrke/GophKeeper-client/internal/services"
 	"github.com/spf13/cobra"
 )
 
@@ -30,22 +34,31 @@ var getloginsCmd = &cobra.Command{
 		if err != nil {
 			fmt.Printf("Ошибка при получении данных: %s", err.Error())
 		}
+		filter, err := filterFromFlags(cmd)
+		if err != nil {
+			fmt.Printf("Ошибка в параметрах фильтра: %s\n", err.Error())
+			return
+		}
+		res = slices.DeleteFunc(res, func(r models.LoginModel) bool {
+			return !filter.Match(r.Folder, r.Tags)
+		})
 		if len(res) == 0 {
 			fmt.Printf("Нет сохраненных данных")
 			return
 		}
-		logins := ""
+		names := make([]string, 0, len(res))
 		for _, login := range res {
-			cardStr := fmt.Sprintf("\nLogin name: %s \n\tLogin: %s \n\tPassword: %s\n",
-				login.Name, login.Login, login.Password)
-			logins += cardStr
+			names = append(names, login.Name)
 		}
-		fmt.Printf("Logins: %s", logins)
+		reveal := revealRequested(cmd, keepService, userModel.UserID, services.AccessKindLogin, names...)
+		fmt.Print(render.Logins(res, reveal))
 	},
 }
 
 func init() {
 	rootCmd.AddCommand(getloginsCmd)
+	addFilterFlags(getloginsCmd)
+	addRevealFlag(getloginsCmd)
 
 	// Here you will define your flags and configuration settings.
 
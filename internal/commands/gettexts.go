@@ -5,7 +5,10 @@ package cmd
 
 import (
 	"fmt"
+	"slices"
 
+	"github.com/Dorrrke/GophKeeper-client/internal/domain/models"
+	"github.com/Dorrrke/GophKeeper-client/internal/render"
 	"github.com/spf13/cobra"
 )
 
@@ -31,22 +34,25 @@ var gettextsCmd = &cobra.Command{
 			fmt.Printf("Ошибка при получении данных: %s", err.Error())
 			return
 		}
+		filter, err := filterFromFlags(cmd)
+		if err != nil {
+			fmt.Printf("Ошибка в параметрах фильтра: %s\n", err.Error())
+			return
+		}
+		res = slices.DeleteFunc(res, func(r models.TextDataModel) bool {
+			return !filter.Match(r.Folder, r.Tags)
+		})
 		if len(res) == 0 {
 			fmt.Printf("Нет сохраненных данных")
 			return
 		}
-		texts := ""
-		for _, text := range res {
-			cardStr := fmt.Sprintf("\nText name: %s \n\tData: %s\n",
-				text.Name, text.Data)
-			texts += cardStr
-		}
-		fmt.Printf("Texts: %s", texts)
+		fmt.Print(render.Texts(res))
 	},
 }
 
 func init() {
 	rootCmd.AddCommand(gettextsCmd)
+	addFilterFlags(gettextsCmd)
 
 	// Here you will define your flags and configuration settings.
 
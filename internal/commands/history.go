@@ -0,0 +1,109 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/Dorrrke/GophKeeper-client/internal/render"
+	"github.com/Dorrrke/GophKeeper-client/internal/services"
+	"github.com/Dorrrke/GophKeeper-client/internal/storage"
+	"github.com/spf13/cobra"
+)
+
+// historyCmd represents the history command
+var historyCmd = &cobra.Command{
+	Use:   "history <card|auth|text|bin> <name>",
+	Short: "Отображает предыдущие версии записи",
+	Long: `При вызове отображает список сохраненных предыдущих версий записи.
+	Версия сохраняется при каждом обновлении записи с флагом --update, количество хранимых версий задается флагом -history-retention.
+	Вернуть запись к нужной версии можно командой restore.`,
+	Args: cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		keepService, err := setupService(false)
+		if err != nil {
+			fmt.Printf("Ошибка при конфигурации сервиса %s", err.Error())
+			return
+		}
+		userModel, err := getUserID()
+		if err != nil {
+			fmt.Printf("Ошибка при получении данных %s", err.Error())
+			return
+		}
+		versions, err := keepService.History(args[0], args[1], userModel.UserID)
+		if err != nil {
+			printRecordError(err)
+			return
+		}
+		if len(versions) == 0 {
+			fmt.Printf("Нет сохраненных версий")
+			return
+		}
+		fmt.Print(render.History(args[0], args[1], versions))
+	},
+}
+
+// restoreCmd represents the restore command
+var restoreCmd = &cobra.Command{
+	Use:   "restore <card|auth|text|bin> <name> [--version N]",
+	Short: "Восстанавливает запись из корзины или предыдущую версию записи",
+	Long: `Без флага --version возвращает удаленную запись из корзины, восстановление дойдет до сервера при следующей синхронизации.
+	С флагом --version возвращает записи содержимое указанной версии из истории.
+	Текущее содержимое сохраняется в историю как новая версия, поэтому восстановление можно отменить.
+	Пример использование: gophkeeper restore auth github --version 2`,
+	Args: cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		keepService, err := setupService(false)
+		if err != nil {
+			fmt.Printf("Ошибка при конфигурации сервиса %s", err.Error())
+			return
+		}
+		userModel, err := getUserID()
+		if err != nil {
+			fmt.Printf("Ошибка при получении данных %s", err.Error())
+			return
+		}
+		if !cmd.Flags().Changed("version") {
+			err = keepService.RestoreDeleted(args[0], args[1], userModel.UserID)
+			if err != nil {
+				printRecordError(err)
+				return
+			}
+			fmt.Printf("Запись восстановлена из корзины\n")
+			return
+		}
+		version, err := cmd.Flags().GetInt("version")
+		if err != nil {
+			fmt.Printf("Ошибка при получении флага: %s", err.Error())
+			return
+		}
+		err = keepService.RestoreVersion(args[0], args[1], version, userModel.UserID)
+		if err != nil {
+			printRecordError(err)
+			return
+		}
+		fmt.Printf("Версия %d восстановлена\n", version)
+	},
+}
+
+// printRecordError выводит понятное сообщение об ошибке операции с записью по ее виду и имени.
+func printRecordError(err error) {
+	switch {
+	case errors.Is(err, services.ErrUnknownKind):
+		fmt.Printf("Неизвестный тип записи, укажите card, auth, text или bin.")
+	case errors.Is(err, storage.ErrVersionNotExist):
+		fmt.Printf("Версии с таким номером не существует.")
+	case errors.Is(err, storage.ErrCardNotExist), errors.Is(err, storage.ErrLoginNotExist),
+		errors.Is(err, storage.ErrTextNotExist), errors.Is(err, storage.ErrBinDataNotExist), errors.Is(err, storage.ErrOTPNotExist):
+		fmt.Printf("Записи с таким именем не существует.")
+	case errors.Is(err, storage.ErrVaultLocked):
+		fmt.Printf("Хранилище заблокировано, выполните unlock.")
+	default:
+		fmt.Printf("Ошибка при работе с записью: %s", err.Error())
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(historyCmd)
+	rootCmd.AddCommand(restoreCmd)
+	restoreCmd.Flags().Int("version", 0, "Номер восстанавливаемой версии из команды history")
+}
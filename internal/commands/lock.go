@@ -0,0 +1,31 @@
+/*
+Copyright © 2024 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+// lockCmd represents the lock command
+var lockCmd = &cobra.Command{
+	Use:   "lock",
+	Short: "Блокировка хранилища.",
+	Long: `Удаляет ключ хранилища текущей сессии. Данные сессии остаются зашифрованными,
+	для продолжения работы нужно выполнить unlock, ввести мастер-пароль и экспортировать новый секрет сессии
+	в переменную окружения GOPHKEEPER_SESSION.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := wipeFile(vaultKeyFile); err != nil {
+			fmt.Printf("Ошибка блокировки хранилища: %s\n", err.Error())
+			return
+		}
+		fmt.Println("Хранилище заблокировано")
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(lockCmd)
+}
@@ -7,6 +7,8 @@ import (
 	"errors"
 	"fmt"
 
+	"github.com/Dorrrke/GophKeeper-client/internal/render"
+	"github.com/Dorrrke/GophKeeper-client/internal/services"
 	"github.com/Dorrrke/GophKeeper-client/internal/storage"
 	"github.com/spf13/cobra"
 )
@@ -50,14 +52,14 @@ var loginCmd = &cobra.Command{
 			fmt.Printf("Ошибка при получении данных %s", err.Error())
 			return
 		}
-		fmt.Printf("\nLogin name: %s \n\tLogin: %s \n\tPassword: %s\n",
-			login.Name, login.Login, login.Password)
+		fmt.Print(render.Login(login, revealRequested(cmd, keepService, userModel.UserID, services.AccessKindLogin, login.Name)))
 	},
 }
 
 func init() {
 	rootCmd.AddCommand(loginCmd)
 	loginCmd.Flags().Bool("delete", false, "Удаление данных")
+	addRevealFlag(loginCmd)
 
 	// Here you will define your flags and configuration settings.
 
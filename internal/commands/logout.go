@@ -0,0 +1,70 @@
+/*
+Copyright © 2024 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+// logoutCmd represents the logout command
+var logoutCmd = &cobra.Command{
+	Use:   "logout",
+	Short: "Выход из системы.",
+	Long:  `Затирает и удаляет файлы сессии: ключ хранилища, auth_conf с токенами сервера и логин текущего пользователя.`,
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		for _, file := range []string{vaultKeyFile, authConfFile, currentUserFile} {
+			if err := wipeFile(file); err != nil {
+				fmt.Printf("Ошибка удаления файла %s: %s\n", file, err.Error())
+				return
+			}
+		}
+		fmt.Println("Выход из системы выполнен")
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(logoutCmd)
+}
+
+// wipeFile перезаписывает содержимое файла случайными данными и нулями, после чего удаляет его.
+// Отсутствие файла ошибкой не считается.
+func wipeFile(path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY, 0)
+	if err != nil {
+		return err
+	}
+	size := info.Size()
+	noise := make([]byte, size)
+	if _, err := rand.Read(noise); err != nil {
+		f.Close()
+		return err
+	}
+	for _, data := range [][]byte{noise, make([]byte, size)} {
+		if _, err := f.WriteAt(data, 0); err != nil {
+			f.Close()
+			return err
+		}
+		if err := f.Sync(); err != nil {
+			f.Close()
+			return err
+		}
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Remove(path)
+}
@@ -0,0 +1,104 @@
+package cmd
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	errText "github.com/Dorrrke/GophKeeper-client/internal/domain/errors"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+var (
+	errSecretMismatch = errors.New(errText.SecretMismatchError)
+	errEmptySecret    = errors.New(errText.EmptySecretError)
+)
+
+// stdinReader - общий буфер stdin, чтобы несколько секретов читались из последовательных строк.
+var stdinReader = bufio.NewReader(os.Stdin)
+
+// addStdinFlag добавляет команде флаг --stdin для чтения секретов из стандартного ввода.
+func addStdinFlag(cmd *cobra.Command) {
+	cmd.Flags().Bool("stdin", false, "Читать секреты из stdin, по одному в строке")
+}
+
+// secretArg возвращает секрет из аргумента с индексом i, а если аргумент не передан -
+// из stdin при флаге --stdin или из скрытого ввода в терминале.
+func secretArg(cmd *cobra.Command, args []string, i int, prompt string) (string, error) {
+	if i < len(args) {
+		return args[i], nil
+	}
+	if fromStdin(cmd) {
+		return readLine()
+	}
+	return readSecret(prompt)
+}
+
+// confirmedSecretArg работает как secretArg, но при вводе в терминале запрашивает значение дважды.
+func confirmedSecretArg(cmd *cobra.Command, args []string, i int, prompt string) (string, error) {
+	if i < len(args) || fromStdin(cmd) {
+		return secretArg(cmd, args, i, prompt)
+	}
+	secret, err := readSecret(prompt)
+	if err != nil {
+		return "", err
+	}
+	confirm, err := readSecret("Повторите ввод: ")
+	if err != nil {
+		return "", err
+	}
+	if secret != confirm {
+		return "", errSecretMismatch
+	}
+	return secret, nil
+}
+
+func fromStdin(cmd *cobra.Command) bool {
+	stdin, err := cmd.Flags().GetBool("stdin")
+	return err == nil && stdin
+}
+
+// readSecret запрашивает непустой секрет без отображения ввода.
+func readSecret(prompt string) (string, error) {
+	secret, err := readPassword(prompt)
+	if err != nil {
+		return "", err
+	}
+	if secret == "" {
+		return "", errEmptySecret
+	}
+	return secret, nil
+}
+
+// readPassword запрашивает пароль, не отображая ввод в терминале.
+// Если stdin не является терминалом, пароль читается из очередной строки ввода.
+func readPassword(prompt string) (string, error) {
+	fmt.Fprint(os.Stderr, prompt)
+	fd := int(os.Stdin.Fd())
+	if term.IsTerminal(fd) {
+		pass, err := term.ReadPassword(fd)
+		fmt.Fprintln(os.Stderr)
+		if err != nil {
+			return "", err
+		}
+		return string(pass), nil
+	}
+	return readLine()
+}
+
+// readLine читает очередную строку stdin без перевода строки.
+func readLine() (string, error) {
+	line, err := stdinReader.ReadString('\n')
+	if err != nil && !(errors.Is(err, io.EOF) && line != "") {
+		return "", err
+	}
+	line = strings.TrimRight(line, "\r\n")
+	if line == "" {
+		return "", errEmptySecret
+	}
+	return line, nil
+}
@@ -0,0 +1,81 @@
+/*
+Copyright © 2024 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/Dorrrke/GophKeeper-client/internal/audit"
+	"github.com/spf13/cobra"
+)
+
+// pwnedCmd represents the pwned command
+var pwnedCmd = &cobra.Command{
+	Use:   "pwned <path>",
+	Short: "Проверка паролей по списку утечек",
+	Long: `Проверяет сохраненные пароли по локально загруженному списку SHA-1 хешей Have I Been Pwned.
+	Сеть при проверке не используется. В качестве пути можно указать каталог с файлами диапазонов
+	(имя файла - первые 5 символов хеша, строки SUFFIX:COUNT) или отсортированный по хешу файл
+	pwned-passwords-sha1-ordered-by-hash.txt.
+	Пример использования: gophkeeper pwned ./pwnedpasswords`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		keepService, err := setupService(false)
+		if err != nil {
+			fmt.Printf("Ошибка при конфигурации сервиса %s", err.Error())
+			return
+		}
+		userModel, err := getUserID()
+		if err != nil {
+			fmt.Printf("Ошибка при получении данных %s", err.Error())
+			return
+		}
+		jsonFlag, err := cmd.Flags().GetBool("json")
+		if err != nil {
+			fmt.Printf("Ошибка при получении флага: %s", err.Error())
+			return
+		}
+		src, err := audit.OpenPwned(args[0])
+		if err != nil {
+			fmt.Printf("Ошибка открытия списка утечек: %s\n", err.Error())
+			return
+		}
+		defer src.Close()
+		res, err := keepService.CheckPwnedLogins(userModel.UserID, src)
+		if err != nil {
+			fmt.Printf("Ошибка проверки паролей: %s\n", err.Error())
+			return
+		}
+		if jsonFlag {
+			if res == nil {
+				res = []audit.PwnedEntry{}
+			}
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			if err := enc.Encode(res); err != nil {
+				fmt.Printf("Ошибка при формировании отчета: %s", err.Error())
+			}
+			return
+		}
+		if len(res) == 0 {
+			fmt.Println("Сохраненные пароли в списке утечек не найдены")
+			return
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "NAME\tLOGIN\tSEEN")
+		for _, entry := range res {
+			fmt.Fprintf(w, "%s\t%s\t%d\n", entry.Name, entry.Login, entry.Count)
+		}
+		w.Flush()
+		fmt.Printf("\nНайдено в утечках: %d\n", len(res))
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(pwnedCmd)
+	pwnedCmd.Flags().Bool("json", false, "Вывести результат в формате JSON")
+}
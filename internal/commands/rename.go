@@ -0,0 +1,51 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/Dorrrke/GophKeeper-client/internal/services"
+	"github.com/Dorrrke/GophKeeper-client/internal/storage"
+	"github.com/spf13/cobra"
+)
+
+// renameCmd represents the rename command
+var renameCmd = &cobra.Command{
+	Use:   "rename <card|auth|text|bin|otp> <old> <new>",
+	Short: "Переименовывает запись",
+	Long: `Меняет имя записи. Метаданные, папка, теги и история версий остаются при записи.
+	При следующей синхронизации сервер и другие устройства получат переименование, а не удаление и новую запись.
+	Пример использование: gophkeeper rename card visa salary`,
+	Args: cobra.ExactArgs(3),
+	Run: func(cmd *cobra.Command, args []string) {
+		keepService, err := setupService(false)
+		if err != nil {
+			fmt.Printf("Ошибка при конфигурации сервиса %s", err.Error())
+			return
+		}
+		userModel, err := getUserID()
+		if err != nil {
+			fmt.Printf("Ошибка при получении данных %s", err.Error())
+			return
+		}
+		err = keepService.Rename(args[0], args[1], args[2], userModel.UserID)
+		if err != nil {
+			switch {
+			case errors.Is(err, services.ErrEmptyName):
+				fmt.Printf("Новое имя не может быть пустым.")
+			case errors.Is(err, storage.ErrCardAlredyExist), errors.Is(err, storage.ErrLoginAlredyExist),
+				errors.Is(err, storage.ErrTextAlredyExist), errors.Is(err, storage.ErrBinAlredyExist),
+				errors.Is(err, storage.ErrOTPAlredyExist):
+				fmt.Printf("Запись с таким именем уже существует.")
+			default:
+				printRecordError(err)
+			}
+			return
+		}
+		fmt.Printf("Запись переименована\n")
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(renameCmd)
+}
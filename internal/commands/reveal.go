@@ -0,0 +1,31 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/Dorrrke/GophKeeper-client/internal/services"
+	"github.com/spf13/cobra"
+)
+
+// addRevealFlag добавляет команде флаг --reveal для показа секретов без маски.
+func addRevealFlag(cmd *cobra.Command) {
+	cmd.Flags().Bool("reveal", false, "Показать номера карт, CVV и пароли без маски (показ записывается в журнал доступа)")
+}
+
+// revealRequested проверяет флаг --reveal и записывает показ в журнал доступа.
+// Если запись в журнал не удалась, секреты остаются скрытыми.
+func revealRequested(cmd *cobra.Command, keepService *services.KeepService, uID int64, kind string, names ...string) bool {
+	reveal, err := cmd.Flags().GetBool("reveal")
+	if err != nil {
+		fmt.Printf("Ошибка при получении флага: %s", err.Error())
+		return false
+	}
+	if !reveal {
+		return false
+	}
+	if err := keepService.LogReveal(uID, kind, names...); err != nil {
+		fmt.Printf("Не удалось записать показ в журнал доступа, данные будут скрыты: %s\n", err.Error())
+		return false
+	}
+	return true
+}
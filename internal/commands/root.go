@@ -5,8 +5,10 @@ Copyright © 2024 NAME HERE <EMAIL ADDRESS>
 package cmd
 
 import (
+	"flag"
 	"os"
 
+	"github.com/Dorrrke/GophKeeper-client/internal/config"
 	"github.com/spf13/cobra"
 )
 
@@ -15,13 +17,17 @@ import (
 // rootCmd represents the base command when called without any subcommands
 var rootCmd = &cobra.Command{
 	Use:   "GophKeeper",
-	Short: "A brief description of your application",
-	Long: `A longer description that spans multiple lines and likely contains
-examples and usage of using your application. For example:
+	Short: "Клиент менеджера паролей GophKeeper",
+	Long: `GophKeeper хранит логины, карты, тексты, файлы и одноразовые пароли в зашифрованной локальной базе
+и синхронизирует их с сервером командой update.
 
-Cobra is a CLI library for Go that empowers applications.
-This application is a tool to generate the needed files
-to quickly create a Cobra application.`,
+Работа начинается с sign_up или sign_in. После входа и после unlock выводится секрет сессии,
+который нужно экспортировать в переменную окружения GOPHKEEPER_SESSION:
+
+	export GOPHKEEPER_SESSION=<секрет>
+
+Без этой переменной остальные команды не могут расшифровать ключ хранилища. Секрет в файлах не хранится,
+поэтому его нужно экспортировать заново в каждом новом терминале и после каждого unlock.`,
 	// Uncomment the following line if your bare application
 	// has an action associated with it:
 	// Run: func(cmd *cobra.Command, args []string) { },
@@ -42,6 +48,9 @@ func init() {
 	// will be global for your application.
 
 	// rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $HOME/.GophKeeper.yaml)")
+	configFlags := flag.NewFlagSet("config", flag.ContinueOnError)
+	appConfig = config.RegisterFlags(configFlags)
+	rootCmd.PersistentFlags().AddGoFlagSet(configFlags)
 
 	// Cobra also supports local flags, which will only run
 	// when this action is called directly.
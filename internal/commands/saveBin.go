@@ -37,9 +37,17 @@ var saveBinCmd = &cobra.Command{
 			fmt.Printf("Ошибка при конфигурации сервиса %s", err.Error())
 			return
 		}
+		attrs, err := attrsFromFlags(cmd)
+		if err != nil {
+			fmt.Printf("Ошибка при разборе флагов записи: %s\n", err.Error())
+			return
+		}
 		bModel := models.BinaryDataModel{
-			Name: args[0],
-			Data: bData,
+			Name:   args[0],
+			Data:   bData,
+			Meta:   attrs.meta,
+			Folder: attrs.folder,
+			Tags:   attrs.tags,
 		}
 		updateFlag, err := cmd.Flags().GetBool("update")
 		if err != nil {
@@ -71,6 +79,7 @@ var saveBinCmd = &cobra.Command{
 func init() {
 	rootCmd.AddCommand(saveBinCmd)
 	saveBinCmd.Flags().Bool("update", false, "Обновить существующие данные")
+	addAttrFlags(saveBinCmd)
 	// Here you will define your flags and configuration settings.
 
 	// Cobra supports Persistent Flags which will work for this command
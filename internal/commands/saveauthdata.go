@@ -14,13 +14,16 @@ import (
 
 // saveauthdataCmd represents the saveauthdata command
 var saveauthdataCmd = &cobra.Command{
-	Use:   "save_auth_data",
+	Use:   "save_auth_data <name> <login> [password]",
 	Short: "Сохраняет пару логин пароль введенные пользователем",
 	Long: `Сохраняет пару логин пароль введенные пользователем.
 	При подключении наличии подключения к сети данные отправляются на хранение на сервере, 
 	в ином случае харнятся на личном ПК пользователя.
-	Пример использование: gophkeeper saveauthdata login_name login password`,
-	Args: cobra.ExactArgs(3),
+	Пример использование: gophkeeper saveauthdata login_name login password
+	Если пароль не передан аргументом, он запрашивается без отображения ввода
+	(при --update - дважды) или читается из stdin при указании флага --stdin.
+	С флагом --generate пароль генерируется, параметры задаются теми же флагами, что у команды generate.`,
+	Args: cobra.RangeArgs(2, 3),
 	Run: func(cmd *cobra.Command, args []string) {
 		fmt.Println("saveauthdata called")
 
@@ -28,11 +31,6 @@ var saveauthdataCmd = &cobra.Command{
 		if err != nil {
 			fmt.Printf("Ошибка при конфигурации сервиса %s", err.Error())
 		}
-		authData := models.LoginModel{
-			Name:     args[0],
-			Login:    args[1],
-			Password: args[2],
-		}
 		userModel, err := getUserID()
 		if err != nil {
 			fmt.Printf("Ошибка при получении данных %s", err.Error())
@@ -42,6 +40,45 @@ var saveauthdataCmd = &cobra.Command{
 		if err != nil {
 			fmt.Printf("Ошибка при получении флага: %s", err.Error())
 		}
+		generateFlag, err := cmd.Flags().GetBool("generate")
+		if err != nil {
+			fmt.Printf("Ошибка при получении флага: %s", err.Error())
+		}
+		var password string
+		if generateFlag {
+			if len(args) > 2 {
+				fmt.Println("Пароль не передается аргументом вместе с флагом --generate")
+				return
+			}
+			password, err = generateFromFlags(cmd)
+			if err != nil {
+				fmt.Printf("Ошибка генерации пароля: %s\n", err.Error())
+				return
+			}
+		} else {
+			readPass := secretArg
+			if updateFlag {
+				readPass = confirmedSecretArg
+			}
+			password, err = readPass(cmd, args, 2, "Пароль: ")
+			if err != nil {
+				fmt.Printf("Ошибка чтения пароля: %s\n", err.Error())
+				return
+			}
+		}
+		attrs, err := attrsFromFlags(cmd)
+		if err != nil {
+			fmt.Printf("Ошибка при разборе флагов записи: %s\n", err.Error())
+			return
+		}
+		authData := models.LoginModel{
+			Name:     args[0],
+			Login:    args[1],
+			Password: password,
+			Meta:     attrs.meta,
+			Folder:   attrs.folder,
+			Tags:     attrs.tags,
+		}
 		if updateFlag {
 			err := keepService.UpdateLogin(authData, userModel.UserID)
 			if err != nil {
@@ -49,6 +86,7 @@ var saveauthdataCmd = &cobra.Command{
 				return
 			}
 			fmt.Printf("Данные успешно обновлены")
+			printGeneratedHint(generateFlag, authData.Name)
 			return
 		}
 		_, err = keepService.SaveLogin(authData, userModel.UserID)
@@ -61,12 +99,17 @@ var saveauthdataCmd = &cobra.Command{
 			return
 		}
 		fmt.Println("Успешно сохранено!")
+		printGeneratedHint(generateFlag, authData.Name)
 	},
 }
 
 func init() {
 	rootCmd.AddCommand(saveauthdataCmd)
 	saveauthdataCmd.Flags().Bool("update", false, "Обновить существующие данные")
+	addStdinFlag(saveauthdataCmd)
+	addAttrFlags(saveauthdataCmd)
+	saveauthdataCmd.Flags().Bool("generate", false, "Сгенерировать новый пароль")
+	addGeneratorFlags(saveauthdataCmd)
 
 	// Here you will define your flags and configuration settings.
 
@@ -78,3 +121,10 @@ func init() {
 	// is called directly, e.g.:
 	// saveauthdataCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
 }
+
+// printGeneratedHint подсказывает, как посмотреть сгенерированный пароль.
+func printGeneratedHint(generated bool, name string) {
+	if generated {
+		fmt.Printf("\nПароль сгенерирован. Посмотреть его можно командой: gophkeeper login %s\n", name)
+	}
+}
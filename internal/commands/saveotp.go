@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/Dorrrke/GophKeeper-client/internal/otp"
+	"github.com/Dorrrke/GophKeeper-client/internal/storage"
+	"github.com/spf13/cobra"
+)
+
+// saveotpCmd represents the save_otp command
+var saveotpCmd = &cobra.Command{
+	Use:   "save_otp <name> [otpauth-uri]",
+	Short: "Сохраняет секрет одноразовых паролей TOTP/HOTP.",
+	Long: `Сохраняет секрет одноразовых паролей из ссылки вида otpauth://totp/... или otpauth://hotp/...
+	Такую ссылку содержит QR-код, который сервис показывает при подключении двухфакторной аутентификации.
+	Пример использование: gophkeeper save_otp github "otpauth://totp/GitHub:user?secret=JBSWY3DPEHPK3PXP&issuer=GitHub"
+	Если ссылка не передана аргументом, она запрашивается без отображения ввода
+	или читается из stdin при указании флага --stdin.`,
+	Args: cobra.RangeArgs(1, 2),
+	Run: func(cmd *cobra.Command, args []string) {
+		fmt.Println("save_otp called")
+
+		keepService, err := setupService(false)
+		if err != nil {
+			fmt.Printf("Ошибка при конфигурации сервиса %s", err.Error())
+			return
+		}
+		userModel, err := getUserID()
+		if err != nil {
+			fmt.Printf("Ошибка при получении данных %s", err.Error())
+			return
+		}
+		updateFlag, err := cmd.Flags().GetBool("update")
+		if err != nil {
+			fmt.Printf("Ошибка при получении флага: %s", err.Error())
+		}
+		uri, err := secretArg(cmd, args, 1, "otpauth URI: ")
+		if err != nil {
+			fmt.Printf("Ошибка чтения ссылки: %s\n", err.Error())
+			return
+		}
+		otpData, err := otp.ParseURI(uri)
+		if err != nil {
+			fmt.Printf("Ошибка разбора ссылки: %s\n", err.Error())
+			return
+		}
+		attrs, err := attrsFromFlags(cmd)
+		if err != nil {
+			fmt.Printf("Ошибка при разборе флагов записи: %s\n", err.Error())
+			return
+		}
+		otpData.Meta, otpData.Folder, otpData.Tags = attrs.meta, attrs.folder, attrs.tags
+		otpData.Name = args[0]
+		if updateFlag {
+			err := keepService.UpdateOTP(otpData, userModel.UserID)
+			if err != nil {
+				fmt.Printf("Ошибка при обновлении данных: %s", err.Error())
+				return
+			}
+			fmt.Printf("Данные успешно обновлены")
+			return
+		}
+		_, err = keepService.SaveOTP(otpData, userModel.UserID)
+		if err != nil {
+			if errors.Is(err, storage.ErrOTPAlredyExist) {
+				fmt.Printf("Секрет с таким именем уже сохранен.")
+				return
+			}
+			fmt.Printf("Ошибка при сохранении данных: %s", err.Error())
+			return
+		}
+		fmt.Println("Успешно сохранено!")
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(saveotpCmd)
+	saveotpCmd.Flags().Bool("update", false, "Обновить существующие данные")
+	addStdinFlag(saveotpCmd)
+	addAttrFlags(saveotpCmd)
+}
@@ -46,6 +46,11 @@ var savetextdataCmd = &cobra.Command{
 			fmt.Printf("Ошибка при получении данных %s", err.Error())
 			return
 		}
+		attrs, err := attrsFromFlags(cmd)
+		if err != nil {
+			fmt.Printf("Ошибка при разборе флагов записи: %s\n", err.Error())
+			return
+		}
 		if filePath {
 			data, err := parseFromeFile(args[1])
 			if err != nil {
@@ -53,8 +58,11 @@ var savetextdataCmd = &cobra.Command{
 				return
 			}
 			textData := models.TextDataModel{
-				Name: args[0],
-				Data: data,
+				Name:   args[0],
+				Data:   data,
+				Meta:   attrs.meta,
+				Folder: attrs.folder,
+				Tags:   attrs.tags,
 			}
 			if uFlag {
 				err := keepService.UpdateText(textData, userModel.UserID)
@@ -77,8 +85,11 @@ var savetextdataCmd = &cobra.Command{
 			fmt.Println("Успешно сохранено!")
 		} else {
 			textData := models.TextDataModel{
-				Name: args[0],
-				Data: args[1],
+				Name:   args[0],
+				Data:   args[1],
+				Meta:   attrs.meta,
+				Folder: attrs.folder,
+				Tags:   attrs.tags,
 			}
 			if uFlag {
 				err := keepService.UpdateText(textData, userModel.UserID)
@@ -107,6 +118,7 @@ func init() {
 	rootCmd.AddCommand(savetextdataCmd)
 	savetextdataCmd.Flags().Bool("file", false, "Файл с текстовыми данными для сохранения")
 	savetextdataCmd.Flags().Bool("update", false, "Обновить существующие данные")
+	addAttrFlags(savetextdataCmd)
 
 	// Here you will define your flags and configuration settings.
 
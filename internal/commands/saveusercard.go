@@ -6,8 +6,8 @@ package cmd
 import (
 	"errors"
 	"fmt"
-	"strconv"
 
+	"github.com/Dorrrke/GophKeeper-client/internal/cardcheck"
 	"github.com/Dorrrke/GophKeeper-client/internal/domain/models"
 	"github.com/Dorrrke/GophKeeper-client/internal/storage"
 	"github.com/spf13/cobra"
@@ -15,12 +15,16 @@ import (
 
 // saveusercardCmd represents the saveusercard command
 var saveusercardCmd = &cobra.Command{
-	Use:   "save_card",
+	Use:   "save_card <name> [number] <date> [cvv]",
 	Short: "Сохраняет данные банковской карты",
 	Long: `Сохраняет данные банковской карты пользователя.
 	При подключении наличии подключения к сети данные отправляются на хранение на сервере, 
-	в ином случае харнятся на личном ПК пользователя.`,
-	Args: cobra.ExactArgs(4),
+	в ином случае харнятся на личном ПК пользователя.
+	Номер карты и CVV можно не передавать аргументами: save_card name date запросит оба значения,
+	save_card name number date - только CVV. Ввод не отображается, с флагом --stdin значения читаются из stdin.
+	Номер проверяется по алгоритму Луна, срок действия указывается в формате MM/YY,
+	CVV состоит из 3 цифр (4 для American Express).`,
+	Args: cobra.RangeArgs(2, 4),
 	Run: func(cmd *cobra.Command, args []string) {
 		fmt.Println("saveusercard called")
 
@@ -28,21 +32,44 @@ var saveusercardCmd = &cobra.Command{
 		if err != nil {
 			fmt.Printf("Ошибка при конфигурации сервиса %s", err.Error())
 		}
-		cvvCode, err := strconv.Atoi(args[3])
+		userModel, err := getUserID()
 		if err != nil {
-			fmt.Printf("Внутренняя ошибка: %s", err.Error())
+			fmt.Printf("Ошибка при получении данных %s", err.Error())
+			return
 		}
-		card := models.CardModel{
-			Name:    args[0],
-			Number:  args[1],
-			Date:    args[2],
-			CVVCode: cvvCode,
+		name, date := args[0], args[len(args)-1]
+		var secrets []string
+		if len(args) > 2 {
+			date = args[2]
+			secrets = append(secrets, args[1])
 		}
-		userModel, err := getUserID()
+		if len(args) > 3 {
+			secrets = append(secrets, args[3])
+		}
+		number, err := secretArg(cmd, secrets, 0, "Номер карты: ")
 		if err != nil {
-			fmt.Printf("Ошибка при получении данных %s", err.Error())
+			fmt.Printf("Ошибка чтения номера карты: %s\n", err.Error())
+			return
+		}
+		cvv, err := secretArg(cmd, secrets, 1, "CVV: ")
+		if err != nil {
+			fmt.Printf("Ошибка чтения CVV: %s\n", err.Error())
 			return
 		}
+		attrs, err := attrsFromFlags(cmd)
+		if err != nil {
+			fmt.Printf("Ошибка при разборе флагов записи: %s\n", err.Error())
+			return
+		}
+		card := models.CardModel{
+			Name:    name,
+			Number:  number,
+			Date:    date,
+			CVVCode: cvv,
+			Meta:    attrs.meta,
+			Folder:  attrs.folder,
+			Tags:    attrs.tags,
+		}
 		updateFlag, err := cmd.Flags().GetBool("update")
 		if err != nil {
 			fmt.Printf("Ошибка при получении флага: %s", err.Error())
@@ -50,6 +77,9 @@ var saveusercardCmd = &cobra.Command{
 		if updateFlag {
 			err := keepService.UpdateCard(card, userModel.UserID)
 			if err != nil {
+				if printCardError(err) {
+					return
+				}
 				fmt.Printf("Ошибка при обновлении данных: %s", err.Error())
 				return
 			}
@@ -62,6 +92,9 @@ var saveusercardCmd = &cobra.Command{
 				fmt.Printf("Карта с таким именем уже сохранена.")
 				return
 			}
+			if printCardError(err) {
+				return
+			}
 			fmt.Printf("Ошибка при сохранении карты: %s", err.Error())
 			return
 		}
@@ -70,9 +103,27 @@ var saveusercardCmd = &cobra.Command{
 	},
 }
 
+// printCardError выводит понятное сообщение об ошибке проверки реквизитов карты.
+// Возвращает false, если ошибка не связана с проверкой.
+func printCardError(err error) bool {
+	switch {
+	case errors.Is(err, cardcheck.ErrNumber):
+		fmt.Printf("Некорректный номер карты: %s\n", err.Error())
+	case errors.Is(err, cardcheck.ErrDate):
+		fmt.Println("Некорректный срок действия карты, ожидается формат MM/YY.")
+	case errors.Is(err, cardcheck.ErrCVV):
+		fmt.Printf("Некорректный CVV: %s\n", err.Error())
+	default:
+		return false
+	}
+	return true
+}
+
 func init() {
 	rootCmd.AddCommand(saveusercardCmd)
 	saveusercardCmd.Flags().Bool("update", false, "Обновить существующие данные")
+	addStdinFlag(saveusercardCmd)
+	addAttrFlags(saveusercardCmd)
 
 	// Here you will define your flags and configuration settings.
 
@@ -0,0 +1,176 @@
+package cmd
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/Dorrrke/GophKeeper-client/internal/coder"
+	errText "github.com/Dorrrke/GophKeeper-client/internal/domain/errors"
+	"github.com/Dorrrke/GophKeeper-client/internal/domain/models"
+)
+
+const (
+	// authConfFile - файл с данными текущего пользователя, зашифрованный ключом хранилища.
+	authConfFile = "auth_conf"
+	// vaultKeyFile - ключ хранилища текущей сессии, зашифрованный секретом сессии.
+	vaultKeyFile = "vault_key"
+	// sessionEnv - переменная окружения с секретом сессии. Секрет выдается при входе и в файлах не хранится.
+	sessionEnv = "GOPHKEEPER_SESSION"
+	// currentUserFile - логин пользователя текущей сессии, нужен для разблокировки.
+	currentUserFile = "current_user"
+	// sessionsDir - каталог с сессиями пользователей, с которых переключились командой switch.
+	sessionsDir = "sessions"
+)
+
+var (
+	errVaultLocked     = errors.New(errText.VaultLockedError)
+	errSessionExpired  = errors.New(errText.SessionExpiredError)
+	errNoServerToken   = errors.New(errText.NoServerTokenError)
+	errSessionLocked   = errors.New(errText.SessionLockedError)
+	errNoCurrentUser   = errors.New(errText.NoCurrentUserError)
+	errNoSessionSecret = errors.New(errText.NoSessionSecretError)
+	errSessionSecret   = errors.New(errText.SessionSecretError)
+)
+
+// getUserID возвращает сессию текущего пользователя и отмечает ее активность.
+// Сессия без активности дольше IdleTimeout блокируется.
+func getUserID() (models.SessionModel, error) {
+	vaultKey, err := readVaultKey()
+	if err != nil {
+		return models.SessionModel{}, err
+	}
+	session, err := readSession(vaultKey)
+	if err != nil {
+		return models.SessionModel{}, err
+	}
+	now := time.Now()
+	if session.ExpiresAt.IsZero() || now.After(session.ExpiresAt) {
+		return models.SessionModel{}, errSessionExpired
+	}
+	cfg, err := readConfig()
+	if err != nil {
+		return models.SessionModel{}, err
+	}
+	if cfg.IdleTimeout > 0 && now.After(session.LastActivity.Add(cfg.IdleTimeout)) {
+		if err := wipeFile(vaultKeyFile); err != nil {
+			return models.SessionModel{}, err
+		}
+		return models.SessionModel{}, errSessionLocked
+	}
+	session.LastActivity = now
+	if err := saveSession(session, vaultKey); err != nil {
+		return models.SessionModel{}, err
+	}
+	return session, nil
+}
+
+// readSession расшифровывает сессию из auth_conf ключом хранилища.
+func readSession(vaultKey []byte) (models.SessionModel, error) {
+	return readSessionFile(authConfFile, vaultKey)
+}
+
+// readSessionFile расшифровывает сессию из указанного файла ключом хранилища.
+func readSessionFile(path string, vaultKey []byte) (models.SessionModel, error) {
+	f, err := os.ReadFile(path)
+	if err != nil {
+		return models.SessionModel{}, err
+	}
+	data, err := coder.Decoder(vaultKey, f)
+	if err != nil {
+		return models.SessionModel{}, err
+	}
+	var session models.SessionModel
+	err = json.Unmarshal(data, &session)
+	if err != nil {
+		return models.SessionModel{}, err
+	}
+	return session, nil
+}
+
+// readVaultKey читает ключ хранилища, сохраненный при входе в систему, и расшифровывает его секретом сессии.
+func readVaultKey() ([]byte, error) {
+	f, err := os.ReadFile(vaultKeyFile)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, errVaultLocked
+		}
+		return nil, err
+	}
+	encoded := os.Getenv(sessionEnv)
+	if encoded == "" {
+		return nil, errNoSessionSecret
+	}
+	secret, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, errSessionSecret
+	}
+	vaultKey, err := coder.Open(secret, string(f), []byte(vaultKeyFile))
+	if err != nil {
+		return nil, errSessionSecret
+	}
+	return vaultKey, nil
+}
+
+// saveVaultKey шифрует ключ хранилища новым секретом сессии и возвращает секрет.
+// Секрет нужно передать следующим командам через переменную окружения sessionEnv.
+func saveVaultKey(vaultKey []byte) (string, error) {
+	secret, err := coder.NewKey()
+	if err != nil {
+		return "", err
+	}
+	sealed, err := coder.Seal(secret, vaultKey, []byte(vaultKeyFile))
+	if err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(vaultKeyFile, []byte(sealed), 0600); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(secret), nil
+}
+
+// printSessionSecret выводит команду, которой секрет сессии передается следующим командам.
+func printSessionSecret(secret string) {
+	fmt.Printf("Для работы с хранилищем в этом терминале выполните:\nexport %s=%s\n", sessionEnv, secret)
+}
+
+// createConfigFile сохраняет сессию и ключ хранилища и возвращает секрет сессии, которым зашифрован ключ.
+func createConfigFile(session models.SessionModel, vaultKey []byte) (string, error) {
+	if err := saveSession(session, vaultKey); err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(currentUserFile, []byte(session.Login), 0600); err != nil {
+		return "", err
+	}
+	// Отложенная командой switch сессия пользователя заменяется новой.
+	if err := os.Remove(parkedSessionFile(session.Login)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return "", err
+	}
+	return saveVaultKey(vaultKey)
+}
+
+// saveSession шифрует сессию ключом хранилища и записывает ее в auth_conf.
+func saveSession(session models.SessionModel, vaultKey []byte) error {
+	authData, err := json.Marshal(session)
+	if err != nil {
+		return err
+	}
+	encData, err := coder.Encoder(vaultKey, authData)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(authConfFile, encData, 0600)
+}
+
+// isLegacyConfigFile проверяет, зашифрован ли auth_conf старым встроенным ключом.
+func isLegacyConfigFile() bool {
+	f, err := os.ReadFile(authConfFile)
+	if err != nil {
+		return false
+	}
+	_, err = coder.LegacyDecoder(f)
+	return err == nil
+}
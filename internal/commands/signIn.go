@@ -4,53 +4,72 @@ Copyright © 2024 NAME HERE <EMAIL ADDRESS>
 package cmd
 
 import (
-	"encoding/json"
 	"errors"
 	"fmt"
-	"os"
+	"time"
 
 	"github.com/Dorrrke/GophKeeper-client/internal/coder"
-	"github.com/Dorrrke/GophKeeper-client/internal/domain/models"
 	"github.com/Dorrrke/GophKeeper-client/internal/services"
-	"github.com/Dorrrke/GophKeeper-client/internal/storage"
 	"github.com/spf13/cobra"
 )
 
 // signInCmd represents the signIn command
 var signInCmd = &cobra.Command{
-	Use:   "sign_in",
+	Use:   "sign_in <login> [password]",
 	Short: "Вход в систему.",
-	Args:  cobra.ExactArgs(2),
+	Long: `Вход в систему под указанным логином.
+	Если мастер-пароль не передан аргументом, он запрашивается без отображения ввода
+	или читается из stdin при указании флага --stdin.
+	После входа выводится секрет сессии: его нужно передать следующим командам через переменную окружения GOPHKEEPER_SESSION.`,
+	Args: cobra.RangeArgs(1, 2),
 	Run: func(cmd *cobra.Command, args []string) {
 		fmt.Println("signIn called")
-		keepService, err := setupService(false)
+		pass, err := secretArg(cmd, args, 1, "Мастер-пароль: ")
+		if err != nil {
+			fmt.Printf("Ошибка чтения пароля: %s\n", err.Error())
+			return
+		}
+		keepService, err := setupService(true)
 		if err != nil {
 			fmt.Printf("Ошибка при конфигурации сервиса %s", err.Error())
+			return
 		}
-		userModel, err := keepService.LoginUser(args[0], args[1])
+		userModel, err := keepService.LoginUser(args[0], pass)
 		if err != nil {
-			if errors.Is(err, storage.ErrUserNotExist) {
-				fmt.Printf("Неверный логин или пароль. Такого пользователя не существует.")
-				return
-			}
-			if errors.Is(err, services.ErrInvalidPassword) {
-				fmt.Printf("Неверный пароль.")
-				return
-			}
-			fmt.Printf("Ошибка получения данных: %s\n", err.Error())
+			printLoginError(err)
 			return
 		}
-		err = createConfigFile(userModel)
+		vaultKey, err := keepService.UnlockVault(userModel.UserID, pass)
+		if err != nil {
+			fmt.Printf("Ошибка получения ключа хранилища: %s\n", err.Error())
+			return
+		}
+		serverKeys, err := coder.DeriveServerKeys(pass, args[0])
+		if err != nil {
+			fmt.Printf("Ошибка получения ключей сервера: %s\n", err.Error())
+			return
+		}
+		tokens, err := authOnServer(keepService, args[0], serverKeys)
+		if err != nil {
+			fmt.Printf("Не удалось войти на сервер, синхронизация будет недоступна до следующего входа: %s\n", err.Error())
+		}
+		session := keepService.NewSession(userModel, serverKeys.Sync, tokens)
+		if isLegacyConfigFile() {
+			fmt.Println("Файл auth_conf будет перешифрован ключом хранилища")
+		}
+		secret, err := createConfigFile(session, vaultKey)
 		if err != nil {
 			fmt.Printf("Ошибка входа в систему: %s\n", err.Error())
 			return
 		}
 		fmt.Println("Успешный вход в систему")
+		printSessionSecret(secret)
 	},
 }
 
 func init() {
 	rootCmd.AddCommand(signInCmd)
+	addStdinFlag(signInCmd)
 
 	// Here you will define your flags and configuration settings.
 
@@ -63,23 +82,34 @@ func init() {
 	// signInCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
 }
 
-func createConfigFile(auth models.UserModel) error {
-	f, err := os.Create("auth_conf")
-	if err != nil {
-		return err
+// printLoginError выводит понятное сообщение о неудачной проверке логина и мастер-пароля.
+func printLoginError(err error) {
+	var delayErr *services.LoginDelayError
+	if errors.As(err, &delayErr) {
+		printLoginDelay(delayErr)
+		return
 	}
-	defer f.Close()
-	authData, err := json.Marshal(auth)
-	if err != nil {
-		return err
+	if errors.Is(err, services.ErrInvalidPassword) {
+		fmt.Printf("Неверный логин или пароль.")
+		return
 	}
-	encData, err := coder.Encoder(authData)
-	if err != nil {
-		return err
+	fmt.Printf("Ошибка получения данных: %s\n", err.Error())
+}
+
+// printLoginDelay сообщает, через сколько можно повторить вход.
+func printLoginDelay(delayErr *services.LoginDelayError) {
+	wait := delayErr.Delay.Round(time.Second)
+	if wait < time.Second {
+		wait = time.Second
 	}
-	_, err = f.WriteString(string(encData))
-	if err != nil {
-		return err
+	switch {
+	case delayErr.Locked && errors.Is(delayErr, services.ErrInvalidPassword):
+		fmt.Printf("Неверный логин или пароль. Слишком много неудачных попыток, вход заблокирован на %s.\n", wait)
+	case delayErr.Locked:
+		fmt.Printf("Вход заблокирован после неудачных попыток. Повторите через %s.\n", wait)
+	case errors.Is(delayErr, services.ErrInvalidPassword):
+		fmt.Printf("Неверный логин или пароль. Следующая попытка возможна через %s.\n", wait)
+	default:
+		fmt.Printf("Слишком частые попытки входа. Повторите через %s.\n", wait)
 	}
-	return nil
 }
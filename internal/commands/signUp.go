@@ -7,6 +7,7 @@ import (
 	"errors"
 	"fmt"
 
+	"github.com/Dorrrke/GophKeeper-client/internal/coder"
 	"github.com/Dorrrke/GophKeeper-client/internal/domain/models"
 	"github.com/Dorrrke/GophKeeper-client/internal/storage"
 	"github.com/spf13/cobra"
@@ -14,16 +15,26 @@ import (
 
 // signUpCmd represents the signUp command
 var signUpCmd = &cobra.Command{
-	Use:   "sign_up",
+	Use:   "sign_up <login> [password]",
 	Short: "Регистарция пользователя",
-	Args:  cobra.ExactArgs(2),
+	Long: `Регистрация пользователя с указанным логином.
+	Если мастер-пароль не передан аргументом, он запрашивается дважды без отображения ввода
+	или читается из stdin при указании флага --stdin.
+	После входа выводится секрет сессии: его нужно передать следующим командам через переменную окружения GOPHKEEPER_SESSION.`,
+	Args: cobra.RangeArgs(1, 2),
 	Run: func(cmd *cobra.Command, args []string) {
 		fmt.Println("signUp called")
-		keepService, err := setupService(false)
+		pass, err := confirmedSecretArg(cmd, args, 1, "Мастер-пароль: ")
+		if err != nil {
+			fmt.Printf("Ошибка чтения пароля: %s\n", err.Error())
+			return
+		}
+		keepService, err := setupService(true)
 		if err != nil {
 			fmt.Printf("Ошибка при конфигурации сервиса %s", err.Error())
+			return
 		}
-		uId, err := keepService.RegisterUser(args[0], args[1])
+		uId, err := keepService.RegisterUser(args[0], pass)
 		if err != nil {
 			if errors.Is(err, storage.ErrUserAlredyExist) {
 				fmt.Printf("Такой пользователь уже существует.")
@@ -32,21 +43,38 @@ var signUpCmd = &cobra.Command{
 			fmt.Printf("Ошибка получения данных: %s\n", err.Error())
 			return
 		}
-		authData := models.UserModel{
+		vaultKey, err := keepService.UnlockVault(uId, pass)
+		if err != nil {
+			fmt.Printf("Ошибка получения ключа хранилища: %s\n", err.Error())
+			return
+		}
+		serverKeys, err := coder.DeriveServerKeys(pass, args[0])
+		if err != nil {
+			fmt.Printf("Ошибка получения ключей сервера: %s\n", err.Error())
+			return
+		}
+		tokens, err := authOnServer(keepService, args[0], serverKeys)
+		if err != nil {
+			fmt.Printf("Не удалось зарегистрироваться на сервере, синхронизация будет недоступна до следующего входа: %s\n", err.Error())
+		}
+		userModel := models.UserModel{
 			UserID: uId,
 			Login:  args[0],
-			Hash:   args[1],
 		}
-		if err := createConfigFile(authData); err != nil {
+		session := keepService.NewSession(userModel, serverKeys.Sync, tokens)
+		secret, err := createConfigFile(session, vaultKey)
+		if err != nil {
 			fmt.Printf("Ошибка входа в систему: %s\n", err.Error())
 			return
 		}
 		fmt.Println("Успешная регистрация и вход в систему")
+		printSessionSecret(secret)
 	},
 }
 
 func init() {
 	rootCmd.AddCommand(signUpCmd)
+	addStdinFlag(signUpCmd)
 
 	// Here you will define your flags and configuration settings.
 
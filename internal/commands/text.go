@@ -7,6 +7,7 @@ import (
 	"errors"
 	"fmt"
 
+	"github.com/Dorrrke/GophKeeper-client/internal/render"
 	"github.com/Dorrrke/GophKeeper-client/internal/storage"
 	"github.com/spf13/cobra"
 )
@@ -52,8 +53,7 @@ var textCmd = &cobra.Command{
 			fmt.Printf("Ошибка при получении данных %s", err.Error())
 			return
 		}
-		fmt.Printf("\nText name: %s \n\tData: %s\n",
-			tData.Name, tData.Data)
+		fmt.Print(render.Text(tData))
 	},
 }
 
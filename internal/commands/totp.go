@@ -0,0 +1,110 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/Dorrrke/GophKeeper-client/internal/domain/models"
+	"github.com/Dorrrke/GophKeeper-client/internal/render"
+	"github.com/Dorrrke/GophKeeper-client/internal/storage"
+	"github.com/spf13/cobra"
+)
+
+// totpCmd represents the totp command
+var totpCmd = &cobra.Command{
+	Use:   "totp <name>",
+	Short: "Отображает текущий одноразовый пароль.",
+	Long: `При вызове отображает одноразовый пароль для секрета, сохраненного под указанным именем,
+	и количество секунд до его смены (RFC 6238).
+	Для секретов HOTP (RFC 4226) выдается код для текущего значения счетчика, после чего счетчик увеличивается.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		fmt.Println("totp called")
+
+		keepService, err := setupService(false)
+		if err != nil {
+			fmt.Printf("Ошибка при конфигурации сервиса %s", err.Error())
+			return
+		}
+		userModel, err := getUserID()
+		if err != nil {
+			fmt.Printf("Ошибка при получении данных %s", err.Error())
+			return
+		}
+		delFlag, err := cmd.Flags().GetBool("delete")
+		if err != nil {
+			fmt.Printf("Ошибка при получении флага: %s", err.Error())
+		}
+		if delFlag {
+			err = keepService.DeleteOTPByName(args[0], userModel.UserID)
+			if err != nil {
+				fmt.Printf("Ошибка при удалении данных %s", err.Error())
+				return
+			}
+			fmt.Printf("Успешное удаление\n")
+			return
+		}
+		code, remaining, err := keepService.GenerateOTP(args[0], userModel.UserID, time.Now())
+		if err != nil {
+			if errors.Is(err, storage.ErrOTPNotExist) {
+				fmt.Printf("Секрета с таким именем не существует.")
+				return
+			}
+			fmt.Printf("Ошибка при получении кода %s", err.Error())
+			return
+		}
+		if remaining == 0 {
+			fmt.Printf("\nCode: %s\n\tСчетчик HOTP увеличен\n", code)
+			return
+		}
+		fmt.Printf("\nCode: %s\n\tДействует еще %d с\n", code, remaining)
+	},
+}
+
+// getotpsCmd represents the getotp command
+var getotpsCmd = &cobra.Command{
+	Use:   "getotp",
+	Short: "Отображает сохраненные секреты одноразовых паролей",
+	Long: `При вызове отображает список всех сохраненных секретов TOTP/HOTP без самих секретов.
+	Код по секрету выдает команда totp.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		fmt.Println("getotp called")
+		keepService, err := setupService(false)
+		if err != nil {
+			fmt.Printf("Ошибка при конфигурации сервиса %s", err.Error())
+			return
+		}
+		userModel, err := getUserID()
+		if err != nil {
+			fmt.Printf("Ошибка при получении данных %s", err.Error())
+			return
+		}
+		res, err := keepService.GetOTPs(userModel.UserID)
+		if err != nil {
+			fmt.Printf("Ошибка при получении данных: %s", err.Error())
+			return
+		}
+		filter, err := filterFromFlags(cmd)
+		if err != nil {
+			fmt.Printf("Ошибка в параметрах фильтра: %s\n", err.Error())
+			return
+		}
+		res = slices.DeleteFunc(res, func(r models.OTPModel) bool {
+			return !filter.Match(r.Folder, r.Tags)
+		})
+		if len(res) == 0 {
+			fmt.Printf("Нет сохраненных данных")
+			return
+		}
+		fmt.Print(render.OTPs(res))
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(totpCmd)
+	rootCmd.AddCommand(getotpsCmd)
+	addFilterFlags(getotpsCmd)
+	totpCmd.Flags().Bool("delete", false, "Удалить сохраненный секрет")
+}
@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/Dorrrke/GophKeeper-client/internal/render"
+	"github.com/spf13/cobra"
+)
+
+// trashCmd represents the trash command
+var trashCmd = &cobra.Command{
+	Use:   "trash",
+	Short: "Отображает удаленные записи",
+	Long: `При вызове отображает удаленные карты, пары логин пароль, текстовые и бинарные данные.
+	Запись из корзины возвращает команда restore <type> <name>.
+	После синхронизации записи, удаленные дольше --trash-retention назад, удаляются окончательно.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		keepService, err := setupService(false)
+		if err != nil {
+			fmt.Printf("Ошибка при конфигурации сервиса %s", err.Error())
+			return
+		}
+		userModel, err := getUserID()
+		if err != nil {
+			fmt.Printf("Ошибка при получении данных %s", err.Error())
+			return
+		}
+		items, err := keepService.Trash(userModel.UserID)
+		if err != nil {
+			fmt.Printf("Ошибка при получении данных: %s", err.Error())
+			return
+		}
+		if len(items) == 0 {
+			fmt.Printf("Корзина пуста")
+			return
+		}
+		fmt.Print(render.Trash(items))
+	},
+}
+
+// trashEmptyCmd represents the trash empty command
+var trashEmptyCmd = &cobra.Command{
+	Use:   "empty",
+	Short: "Окончательно удаляет записи из корзины",
+	Long: `Окончательно удаляет записи, пролежавшие в корзине дольше --older-than, по умолчанию - все.
+	Записи, удаление которых еще не отправлено на сервер, остаются в корзине до синхронизации командой update.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		keepService, err := setupService(false)
+		if err != nil {
+			fmt.Printf("Ошибка при конфигурации сервиса %s", err.Error())
+			return
+		}
+		userModel, err := getUserID()
+		if err != nil {
+			fmt.Printf("Ошибка при получении данных %s", err.Error())
+			return
+		}
+		olderThan, err := cmd.Flags().GetDuration("older-than")
+		if err != nil {
+			fmt.Printf("Ошибка при получении флага: %s", err.Error())
+			return
+		}
+		if err := keepService.EmptyTrash(userModel.UserID, olderThan); err != nil {
+			fmt.Printf("Ошибка при очистке корзины: %s", err.Error())
+			return
+		}
+		fmt.Printf("Корзина очищена\n")
+		if olderThan == 0 {
+			if items, err := keepService.Trash(userModel.UserID); err == nil && len(items) != 0 {
+				fmt.Printf("Записей, удаление которых еще не отправлено на сервер: %d, их можно удалить после синхронизации командой update\n", len(items))
+			}
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(trashCmd)
+	trashCmd.AddCommand(trashEmptyCmd)
+	trashEmptyCmd.Flags().Duration("older-than", 0, "Удалить только записи, удаленные раньше указанного времени назад, например 720h")
+}
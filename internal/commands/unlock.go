@@ -0,0 +1,80 @@
+/*
+Copyright © 2024 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/Dorrrke/GophKeeper-client/internal/services"
+	"github.com/spf13/cobra"
+)
+
+// unlockCmd represents the unlock command
+var unlockCmd = &cobra.Command{
+	Use:   "unlock",
+	Short: "Разблокировка хранилища.",
+	Long: `Запрашивает мастер-пароль пользователя текущей сессии и восстанавливает ключ хранилища.
+	Выводит новый секрет сессии для переменной окружения GOPHKEEPER_SESSION.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		login, err := os.ReadFile(currentUserFile)
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				err = errNoCurrentUser
+			}
+			fmt.Printf("Ошибка разблокировки: %s\n", err.Error())
+			return
+		}
+		keepService, err := setupService(false)
+		if err != nil {
+			fmt.Printf("Ошибка при конфигурации сервиса %s", err.Error())
+			return
+		}
+		pass, err := readPassword(fmt.Sprintf("Мастер-пароль для %s: ", strings.TrimSpace(string(login))))
+		if err != nil {
+			fmt.Printf("Ошибка чтения пароля: %s\n", err.Error())
+			return
+		}
+		userModel, err := keepService.LoginUser(strings.TrimSpace(string(login)), pass)
+		if err != nil {
+			var delayErr *services.LoginDelayError
+			if errors.As(err, &delayErr) {
+				printLoginDelay(delayErr)
+				return
+			}
+			fmt.Printf("Ошибка разблокировки: %s\n", err.Error())
+			return
+		}
+		vaultKey, err := keepService.UnlockVault(userModel.UserID, pass)
+		if err != nil {
+			fmt.Printf("Ошибка получения ключа хранилища: %s\n", err.Error())
+			return
+		}
+		session, err := readSession(vaultKey)
+		if err != nil {
+			fmt.Printf("Сессия не найдена, выполните sign_in: %s\n", err.Error())
+			return
+		}
+		if session.UserID != userModel.UserID || time.Now().After(session.ExpiresAt) {
+			fmt.Println(errSessionExpired.Error())
+			return
+		}
+		session.LastActivity = time.Now()
+		secret, err := createConfigFile(session, vaultKey)
+		if err != nil {
+			fmt.Printf("Ошибка разблокировки: %s\n", err.Error())
+			return
+		}
+		fmt.Println("Хранилище разблокировано")
+		printSessionSecret(secret)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(unlockCmd)
+}
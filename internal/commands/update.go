@@ -6,6 +6,7 @@ package cmd
 import (
 	"fmt"
 
+	"github.com/Dorrrke/GophKeeper-client/internal/coder"
 	errText "github.com/Dorrrke/GophKeeper-client/internal/domain/errors"
 	"github.com/Dorrrke/GophKeeper-client/internal/domain/models"
 	"github.com/Dorrrke/GophKeeper-client/internal/services"
@@ -17,31 +18,53 @@ import (
 var updateCmd = &cobra.Command{
 	Use:   "update",
 	Short: "Обновление базы данных на удаленном сервере.",
-	Long: `При выполнении команды, все сохраненные данные пользователя отправляются на удаленный сервер и происходит синхронизация.
-	Если на сервере оказались более новые данные, они вернутся и будут занесены в локальную базу данных.`,
+	Long: `При выполнении команды на удаленный сервер отправляются данные, измененные после последней синхронизации.
+	С сервера загружаются все записи пользователя, изменения с других устройств заносятся в локальную базу данных.
+	При первой синхронизации отправляются все сохраненные данные пользователя.
+	Записи, измененные и на устройстве, и на сервере, разрешаются по флагу --conflict-policy, см. команду conflicts.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		fmt.Println("update called")
 		keepService, err := setupService(true)
 		if err != nil {
 			fmt.Printf("Ошибка при конфигурации сервиса %s", err.Error())
 		}
-		userModel, err := getUserID()
+		session, err := getUserID()
 		if err != nil {
 			fmt.Printf("Ошибка при получении данных %s", err.Error())
 			return
 		}
-		authOnServer(keepService, userModel)
+		if session.Tokens.AccessToken == "" {
+			fmt.Printf("Ошибка при входе в сервер сервиса %s\n", errNoServerToken.Error())
+			return
+		}
+		cfg, err := readConfig()
 		if err != nil {
-			fmt.Printf("Ошибка при входе в сервер сервиса %s", err.Error())
-			fmt.Println(err.Error())
+			fmt.Printf("Ошибка при конфигурации сервиса %s", err.Error())
+			return
+		}
+		resolver, err := conflictResolver(cfg.ConflictPolicy)
+		if err != nil {
+			fmt.Printf("Ошибка при конфигурации сервиса %s", err.Error())
 			return
 		}
-		err = keepService.SyncBD(userModel.UserID)
+		keepService.SetConflictResolver(resolver)
+		err = keepService.SyncBD(session.UserID)
 		if err != nil {
 			fmt.Printf("Ошибка при получении синхронизации базы данных данных %s", err.Error())
 			return
 		}
+		session.Tokens = keepService.ServerTokens()
+		vaultKey, err := readVaultKey()
+		if err == nil {
+			err = saveSession(session, vaultKey)
+		}
+		if err != nil {
+			fmt.Printf("Ошибка при сохранении сессии %s\n", err.Error())
+		}
 		fmt.Println("Данные синхронизированны!")
+		if conflicts, err := keepService.Conflicts(session.UserID); err == nil && len(conflicts) != 0 {
+			fmt.Printf("Неразрешенных конфликтов: %d, список выводит команда conflicts\n", len(conflicts))
+		}
 	},
 }
 
@@ -59,21 +82,19 @@ func init() {
 	// updateCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
 }
 
-func authOnServer(keepService *services.KeepService, userModel models.UserModel) error {
-	err := keepService.ServerLogin(userModel)
+// authOnServer выполняет вход на сервер и регистрирует пользователя, если сервер его не знает.
+// На сервер передается пароль входа, полученный из мастер-пароля, сам мастер-пароль устройство не покидает.
+func authOnServer(keepService *services.KeepService, login string, keys coder.ServerKeys) (models.TokensModel, error) {
+	tokens, err := keepService.ServerLogin(login, keys)
 	if err != nil {
 		rpcStatus, ok := status.FromError(err)
 		if !ok {
-			return err
+			return models.TokensModel{}, err
 		}
 		if rpcStatus.Message() != errText.NoUserOnServerError {
-			return err
-		}
-		err := keepService.ServerRegister(userModel)
-		if err != nil {
-			return err
+			return models.TokensModel{}, err
 		}
-		return nil
+		return keepService.ServerRegister(login, keys)
 	}
-	return nil
+	return tokens, nil
 }
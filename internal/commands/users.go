@@ -0,0 +1,159 @@
+package cmd
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Dorrrke/GophKeeper-client/internal/coder"
+	"github.com/spf13/cobra"
+)
+
+// usersCmd represents the users command
+var usersCmd = &cobra.Command{
+	Use:   "users",
+	Short: "Работа с пользователями локальной базы",
+	Long: `В одной локальной базе могут храниться данные нескольких пользователей, записи каждого видны только ему.
+	Список пользователей выводит команда users list, переключиться на другого пользователя можно командой switch.`,
+}
+
+// usersListCmd represents the users list command
+var usersListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "Отображает пользователей локальной базы",
+	Long:  `При вызове отображает логины всех пользователей локальной базы, пользователь текущей сессии отмечен звездочкой.`,
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		keepService, err := setupService(false)
+		if err != nil {
+			fmt.Printf("Ошибка при конфигурации сервиса %s", err.Error())
+			return
+		}
+		users, err := keepService.Users()
+		if err != nil {
+			fmt.Printf("Ошибка при получении данных: %s", err.Error())
+			return
+		}
+		if len(users) == 0 {
+			fmt.Printf("Нет зарегистрированных пользователей")
+			return
+		}
+		current := currentLogin()
+		for _, user := range users {
+			mark := " "
+			if user.Login == current {
+				mark = "*"
+			}
+			fmt.Printf("%s %s\n", mark, user.Login)
+		}
+	},
+}
+
+// switchCmd represents the switch command
+var switchCmd = &cobra.Command{
+	Use:   "switch <login> [password]",
+	Short: "Переключение на другого пользователя локальной базы",
+	Long: `Делает текущим другого пользователя локальной базы после проверки его мастер-пароля.
+	Сессия прежнего пользователя откладывается и восстанавливается при обратном переключении, пока не истекла.
+	Если отложенной сессии нет, выполняется вход на сервер как при sign_in.
+	Если мастер-пароль не передан аргументом, он запрашивается без отображения ввода
+	или читается из stdin при указании флага --stdin.
+	После переключения выводится секрет сессии нового пользователя для переменной окружения GOPHKEEPER_SESSION.`,
+	Args: cobra.RangeArgs(1, 2),
+	Run: func(cmd *cobra.Command, args []string) {
+		login := args[0]
+		current := currentLogin()
+		if login == current {
+			fmt.Printf("Пользователь %s уже выбран\n", login)
+			return
+		}
+		pass, err := secretArg(cmd, args, 1, "Мастер-пароль: ")
+		if err != nil {
+			fmt.Printf("Ошибка чтения пароля: %s\n", err.Error())
+			return
+		}
+		keepService, err := setupService(true)
+		if err != nil {
+			fmt.Printf("Ошибка при конфигурации сервиса %s", err.Error())
+			return
+		}
+		userModel, err := keepService.LoginUser(login, pass)
+		if err != nil {
+			printLoginError(err)
+			return
+		}
+		vaultKey, err := keepService.UnlockVault(userModel.UserID, pass)
+		if err != nil {
+			fmt.Printf("Ошибка получения ключа хранилища: %s\n", err.Error())
+			return
+		}
+		session, err := readSessionFile(parkedSessionFile(login), vaultKey)
+		if err != nil || session.UserID != userModel.UserID || time.Now().After(session.ExpiresAt) {
+			serverKeys, err := coder.DeriveServerKeys(pass, login)
+			if err != nil {
+				fmt.Printf("Ошибка получения ключей сервера: %s\n", err.Error())
+				return
+			}
+			tokens, err := authOnServer(keepService, login, serverKeys)
+			if err != nil {
+				fmt.Printf("Не удалось войти на сервер, синхронизация будет недоступна до следующего входа: %s\n", err.Error())
+			}
+			session = keepService.NewSession(userModel, serverKeys.Sync, tokens)
+		}
+		session.LastActivity = time.Now()
+		if err := parkSession(current); err != nil {
+			fmt.Printf("Ошибка сохранения сессии %s: %s\n", current, err.Error())
+			return
+		}
+		secret, err := createConfigFile(session, vaultKey)
+		if err != nil {
+			fmt.Printf("Ошибка переключения пользователя: %s\n", err.Error())
+			return
+		}
+		fmt.Printf("Текущий пользователь: %s\n", login)
+		printSessionSecret(secret)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(usersCmd)
+	usersCmd.AddCommand(usersListCmd)
+	rootCmd.AddCommand(switchCmd)
+	addStdinFlag(switchCmd)
+}
+
+// currentLogin возвращает логин пользователя текущей сессии или пустую строку, если входа не было.
+func currentLogin() string {
+	login, err := os.ReadFile(currentUserFile)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(login))
+}
+
+// parkedSessionFile возвращает путь к отложенной сессии пользователя.
+// Логин кодируется, чтобы не зависеть от допустимых в именах файлов символов.
+func parkedSessionFile(login string) string {
+	return filepath.Join(sessionsDir, hex.EncodeToString([]byte(login)))
+}
+
+// parkSession откладывает сессию текущего пользователя. Сессия остается зашифрованной его ключом хранилища.
+func parkSession(login string) error {
+	if login == "" {
+		return nil
+	}
+	if _, err := os.Stat(authConfFile); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	if err := os.MkdirAll(sessionsDir, 0700); err != nil {
+		return err
+	}
+	return os.Rename(authConfFile, parkedSessionFile(login))
+}
@@ -1,27 +1,185 @@
 package config
 
 import (
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
 	"flag"
+	"fmt"
 	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	errText "github.com/Dorrrke/GophKeeper-client/internal/domain/errors"
 )
 
+var ErrInvalidPin = errors.New(errText.InvalidPinError)
+
 type Config struct {
 	ServerAddr string
 	DBPath     string
+	TLS        TLSConfig
+	Hash       HashConfig
+	Login      LoginConfig
+	// IdleTimeout - время бездействия, после которого сессия блокируется. 0 - без блокировки.
+	IdleTimeout time.Duration
+	// HistoryRetention - число хранимых предыдущих версий каждой записи. 0 - без ограничения.
+	HistoryRetention int
+	// TrashRetention - время хранения удаленных записей в корзине после синхронизации.
+	TrashRetention time.Duration
+	// ConflictPolicy - разрешение конфликтов синхронизации: ask, local, remote, both или skip.
+	ConflictPolicy string
 }
 
-func ReadConfig() *Config {
-	var cfg Config
-	flag.StringVar(&cfg.ServerAddr, "a", "localhost:8080", "server address")
-	flag.StringVar(&cfg.DBPath, "d", "gophkeeper.db", "path to sqlite db")
+// TLSConfig - настройки защищенного соединения с сервером.
+type TLSConfig struct {
+	// CAFile - сертификаты удостоверяющих центров, которым доверяет клиент. По умолчанию используются системные.
+	CAFile string
+	// CertFile и KeyFile - сертификат и ключ клиента для взаимной аутентификации (mTLS).
+	CertFile string
+	KeyFile  string
+	// ServerName - имя сервера для проверки сертификата, если оно отличается от адреса подключения.
+	ServerName string
+	// Insecure - подключение без TLS. Используется только при явном указании.
+	Insecure bool
+	// Pins - SHA-256 хеши SubjectPublicKeyInfo сертификата сервера в base64.
+	Pins []string
+}
+
+// HashConfig - настройки хеширования пароля пользователя.
+type HashConfig struct {
+	// Algorithm - argon2id или bcrypt.
+	Algorithm string
+	// BcryptCost - стоимость bcrypt.
+	BcryptCost int
+	// Argon2Params - параметры Argon2id в виде m=65536,t=3,p=4. По умолчанию используются параметры ключа хранилища.
+	Argon2Params string
+}
+
+// LoginConfig - настройки блокировки входа после неудачных попыток.
+type LoginConfig struct {
+	// LockoutAfter - число неудачных попыток подряд до блокировки. 0 - без блокировки.
+	LockoutAfter int
+	// LockoutCooldown - время блокировки входа.
+	LockoutCooldown time.Duration
+}
+
+// ReadConfig читает конфигурацию из флагов командной строки и переменных окружения.
+func ReadConfig() (*Config, error) {
+	cfg := RegisterFlags(flag.CommandLine)
 	flag.Parse()
+	if err := cfg.ReadEnv(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
 
+// RegisterFlags регистрирует флаги конфигурации в fs и возвращает конфигурацию, которую они заполняют.
+// Переменные окружения применяются вызовом ReadEnv после разбора флагов.
+func RegisterFlags(fs *flag.FlagSet) *Config {
+	var cfg Config
+	fs.StringVar(&cfg.ServerAddr, "a", "localhost:8080", "server address")
+	fs.StringVar(&cfg.DBPath, "d", "gophkeeper.db", "path to sqlite db")
+	fs.StringVar(&cfg.TLS.CAFile, "ca", "", "path to CA bundle for server certificate verification")
+	fs.StringVar(&cfg.TLS.CertFile, "cert", "", "path to client certificate for mTLS")
+	fs.StringVar(&cfg.TLS.KeyFile, "key", "", "path to client private key for mTLS")
+	fs.StringVar(&cfg.TLS.ServerName, "server-name", "", "override server name for certificate verification")
+	fs.BoolVar(&cfg.TLS.Insecure, "insecure", false, "connect to server without TLS")
+	fs.Var((*pinList)(&cfg.TLS.Pins), "pin", "comma separated base64 SHA-256 SPKI hashes of server certificate")
+	fs.StringVar(&cfg.Hash.Algorithm, "hash", "argon2id", "password hash algorithm: argon2id or bcrypt")
+	fs.IntVar(&cfg.Hash.BcryptCost, "bcrypt-cost", 12, "bcrypt cost")
+	fs.StringVar(&cfg.Hash.Argon2Params, "argon2-params", "", "argon2id params in form m=65536,t=3,p=4")
+	fs.IntVar(&cfg.Login.LockoutAfter, "lockout-after", 0, "lock sign in after this many failed attempts, 0 disables lockout")
+	fs.DurationVar(&cfg.Login.LockoutCooldown, "lockout-cooldown", time.Hour, "sign in lockout duration")
+	fs.DurationVar(&cfg.IdleTimeout, "idle-timeout", 15*time.Minute, "lock session after this period of inactivity, 0 disables auto-lock")
+	fs.IntVar(&cfg.HistoryRetention, "history-retention", 20, "number of previous versions kept for each record, 0 keeps all")
+	fs.DurationVar(&cfg.TrashRetention, "trash-retention", 30*24*time.Hour, "keep deleted records in trash for this period after sync")
+	fs.StringVar(&cfg.ConflictPolicy, "conflict-policy", "ask", "sync conflict resolution: ask, local, remote, both or skip")
+	return &cfg
+}
+
+// ReadEnv переопределяет значения конфигурации заданными переменными окружения.
+// Возвращает ошибку, если закрепленные хеши сертификата заданы неверно.
+func (cfg *Config) ReadEnv() error {
 	if sAddr := os.Getenv("SERVER_ADDR"); sAddr != "" {
 		cfg.ServerAddr = sAddr
 	}
 	if dbPath := os.Getenv("DATA_BASE_PATH"); dbPath != "" {
 		cfg.DBPath = dbPath
 	}
+	if caFile := os.Getenv("SERVER_CA_FILE"); caFile != "" {
+		cfg.TLS.CAFile = caFile
+	}
+	if certFile := os.Getenv("CLIENT_CERT_FILE"); certFile != "" {
+		cfg.TLS.CertFile = certFile
+	}
+	if keyFile := os.Getenv("CLIENT_KEY_FILE"); keyFile != "" {
+		cfg.TLS.KeyFile = keyFile
+	}
+	if serverName := os.Getenv("SERVER_NAME"); serverName != "" {
+		cfg.TLS.ServerName = serverName
+	}
+	if insecure, err := strconv.ParseBool(os.Getenv("SERVER_INSECURE")); err == nil {
+		cfg.TLS.Insecure = insecure
+	}
+	if pins := os.Getenv("SERVER_PIN_SHA256"); pins != "" {
+		if err := (*pinList)(&cfg.TLS.Pins).Set(pins); err != nil {
+			return err
+		}
+	}
+	if algorithm := os.Getenv("PASSWORD_HASH"); algorithm != "" {
+		cfg.Hash.Algorithm = algorithm
+	}
+	if cost, err := strconv.Atoi(os.Getenv("BCRYPT_COST")); err == nil {
+		cfg.Hash.BcryptCost = cost
+	}
+	if argon2Params := os.Getenv("ARGON2_PARAMS"); argon2Params != "" {
+		cfg.Hash.Argon2Params = argon2Params
+	}
+	if lockoutAfter, err := strconv.Atoi(os.Getenv("LOGIN_LOCKOUT_AFTER")); err == nil {
+		cfg.Login.LockoutAfter = lockoutAfter
+	}
+	if cooldown, err := time.ParseDuration(os.Getenv("LOGIN_LOCKOUT_COOLDOWN")); err == nil {
+		cfg.Login.LockoutCooldown = cooldown
+	}
+	if idleTimeout, err := time.ParseDuration(os.Getenv("SESSION_IDLE_TIMEOUT")); err == nil {
+		cfg.IdleTimeout = idleTimeout
+	}
+	if retention, err := strconv.Atoi(os.Getenv("HISTORY_RETENTION")); err == nil {
+		cfg.HistoryRetention = retention
+	}
+	if retention, err := time.ParseDuration(os.Getenv("TRASH_RETENTION")); err == nil {
+		cfg.TrashRetention = retention
+	}
+	if policy := os.Getenv("CONFLICT_POLICY"); policy != "" {
+		cfg.ConflictPolicy = policy
+	}
+	return nil
+}
 
-	return &cfg
+// pinList - значение флага со списком хешей через запятую.
+// Пустые элементы пропускаются, поэтому пустое значение отключает закрепление.
+type pinList []string
+
+func (p *pinList) String() string {
+	return strings.Join(*p, ",")
+}
+
+// Set разбирает список хешей. Каждый хеш - SHA-256 в base64, допускается префикс sha256/.
+func (p *pinList) Set(value string) error {
+	var pins []string
+	for _, pin := range strings.Split(value, ",") {
+		pin = strings.TrimSpace(pin)
+		if pin == "" {
+			continue
+		}
+		hash, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(pin, "sha256/"))
+		if err != nil || len(hash) != sha256.Size {
+			return fmt.Errorf("%w: %s", ErrInvalidPin, pin)
+		}
+		pins = append(pins, pin)
+	}
+	*p = pins
+	return nil
 }
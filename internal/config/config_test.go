@@ -4,8 +4,16 @@ import (
 	"flag"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Хеши SHA-256 в base64 для проверки закрепления сертификата.
+const (
+	pinA = "47DEQpj8HBSa+/TImW+5JCeuQeRkm5NMpJWZG3hSuFU="
+	pinB = "LPJNul+wow4m6DsqxbninhsWHlwfp0JecwQzYpOLmCQ="
 )
 
 func TestReadConfit(t *testing.T) {
@@ -28,6 +36,17 @@ func TestReadConfit(t *testing.T) {
 				cfg: Config{
 					ServerAddr: "localhost:9191",
 					DBPath:     "storage/test.db",
+					Hash: HashConfig{
+						Algorithm:  "argon2id",
+						BcryptCost: 12,
+					},
+					Login: LoginConfig{
+						LockoutCooldown: time.Hour,
+					},
+					IdleTimeout:      15 * time.Minute,
+					HistoryRetention: 20,
+					TrashRetention:   30 * 24 * time.Hour,
+					ConflictPolicy:   "ask",
 				},
 			},
 		},
@@ -42,16 +61,243 @@ func TestReadConfit(t *testing.T) {
 				cfg: Config{
 					ServerAddr: "12.12.12.12:4545",
 					DBPath:     "db_test.db",
+					Hash: HashConfig{
+						Algorithm:  "argon2id",
+						BcryptCost: 12,
+					},
+					Login: LoginConfig{
+						LockoutCooldown: time.Hour,
+					},
+					IdleTimeout:      15 * time.Minute,
+					HistoryRetention: 20,
+					TrashRetention:   30 * 24 * time.Hour,
+					ConflictPolicy:   "ask",
+				},
+			},
+		},
+		{
+			name: "Test ReadConfig function #3; Call with tls flags",
+			flags: []string{"test", "-ca", "ca.pem", "-cert", "client.pem", "-key", "client.key",
+				"-server-name", "keeper.local", "-pin", pinA + ", sha256/" + pinB},
+			want: want{
+				cfg: Config{
+					ServerAddr: "localhost:8080",
+					DBPath:     "gophkeeper.db",
+					TLS: TLSConfig{
+						CAFile:     "ca.pem",
+						CertFile:   "client.pem",
+						KeyFile:    "client.key",
+						ServerName: "keeper.local",
+						Pins:       []string{pinA, "sha256/" + pinB},
+					},
+					Hash: HashConfig{
+						Algorithm:  "argon2id",
+						BcryptCost: 12,
+					},
+					Login: LoginConfig{
+						LockoutCooldown: time.Hour,
+					},
+					IdleTimeout:      15 * time.Minute,
+					HistoryRetention: 20,
+					TrashRetention:   30 * 24 * time.Hour,
+					ConflictPolicy:   "ask",
+				},
+			},
+		},
+		{
+			name:  "Test ReadConfig function #4; Call with insecure env",
+			flags: []string{"test", "-ca", "ca.pem"},
+			envSetup: func() {
+				t.Setenv("SERVER_INSECURE", "true")
+				t.Setenv("SERVER_CA_FILE", "env_ca.pem")
+			},
+			want: want{
+				cfg: Config{
+					ServerAddr: "localhost:8080",
+					DBPath:     "gophkeeper.db",
+					TLS: TLSConfig{
+						CAFile:   "env_ca.pem",
+						Insecure: true,
+					},
+					Hash: HashConfig{
+						Algorithm:  "argon2id",
+						BcryptCost: 12,
+					},
+					Login: LoginConfig{
+						LockoutCooldown: time.Hour,
+					},
+					IdleTimeout:      15 * time.Minute,
+					HistoryRetention: 20,
+					TrashRetention:   30 * 24 * time.Hour,
+					ConflictPolicy:   "ask",
+				},
+			},
+		},
+		{
+			name:  "Test ReadConfig function #5; Call with hash settings",
+			flags: []string{"test", "-hash", "bcrypt", "-bcrypt-cost", "10"},
+			envSetup: func() {
+				t.Setenv("BCRYPT_COST", "14")
+				t.Setenv("ARGON2_PARAMS", "m=1024,t=1,p=1")
+			},
+			want: want{
+				cfg: Config{
+					ServerAddr: "localhost:8080",
+					DBPath:     "gophkeeper.db",
+					Hash: HashConfig{
+						Algorithm:    "bcrypt",
+						BcryptCost:   14,
+						Argon2Params: "m=1024,t=1,p=1",
+					},
+					Login: LoginConfig{
+						LockoutCooldown: time.Hour,
+					},
+					IdleTimeout:      15 * time.Minute,
+					HistoryRetention: 20,
+					TrashRetention:   30 * 24 * time.Hour,
+					ConflictPolicy:   "ask",
+				},
+			},
+		},
+		{
+			name:  "Test ReadConfig function #6; Call with lockout settings",
+			flags: []string{"test", "-lockout-after", "5", "-lockout-cooldown", "30m"},
+			envSetup: func() {
+				t.Setenv("LOGIN_LOCKOUT_COOLDOWN", "2h")
+			},
+			want: want{
+				cfg: Config{
+					ServerAddr: "localhost:8080",
+					DBPath:     "gophkeeper.db",
+					Hash: HashConfig{
+						Algorithm:  "argon2id",
+						BcryptCost: 12,
+					},
+					Login: LoginConfig{
+						LockoutAfter:    5,
+						LockoutCooldown: 2 * time.Hour,
+					},
+					IdleTimeout:      15 * time.Minute,
+					HistoryRetention: 20,
+					TrashRetention:   30 * 24 * time.Hour,
+					ConflictPolicy:   "ask",
 				},
 			},
 		},
 		{
-			name:  "Test ReadConfig function #3; Call without flags and env",
+			name:  "Test ReadConfig function #7; Call without flags and env",
 			flags: []string{""},
 			want: want{
 				cfg: Config{
 					ServerAddr: "localhost:8080",
 					DBPath:     "gophkeeper.db",
+					Hash: HashConfig{
+						Algorithm:  "argon2id",
+						BcryptCost: 12,
+					},
+					Login: LoginConfig{
+						LockoutCooldown: time.Hour,
+					},
+					IdleTimeout:      15 * time.Minute,
+					HistoryRetention: 20,
+					TrashRetention:   30 * 24 * time.Hour,
+					ConflictPolicy:   "ask",
+				},
+			},
+		},
+		{
+			name:  "Test ReadConfig function #8; Call with idle timeout",
+			flags: []string{"test", "-idle-timeout", "5m"},
+			envSetup: func() {
+				t.Setenv("SESSION_IDLE_TIMEOUT", "0s")
+			},
+			want: want{
+				cfg: Config{
+					ServerAddr: "localhost:8080",
+					DBPath:     "gophkeeper.db",
+					Hash: HashConfig{
+						Algorithm:  "argon2id",
+						BcryptCost: 12,
+					},
+					Login: LoginConfig{
+						LockoutCooldown: time.Hour,
+					},
+					IdleTimeout:      0,
+					HistoryRetention: 20,
+					TrashRetention:   30 * 24 * time.Hour,
+					ConflictPolicy:   "ask",
+				},
+			},
+		},
+		{
+			name:  "Test ReadConfig function #9; Call with history retention",
+			flags: []string{"test", "-history-retention", "5"},
+			envSetup: func() {
+				t.Setenv("HISTORY_RETENTION", "3")
+			},
+			want: want{
+				cfg: Config{
+					ServerAddr: "localhost:8080",
+					DBPath:     "gophkeeper.db",
+					Hash: HashConfig{
+						Algorithm:  "argon2id",
+						BcryptCost: 12,
+					},
+					Login: LoginConfig{
+						LockoutCooldown: time.Hour,
+					},
+					IdleTimeout:      15 * time.Minute,
+					HistoryRetention: 3,
+					TrashRetention:   30 * 24 * time.Hour,
+					ConflictPolicy:   "ask",
+				},
+			},
+		},
+		{
+			name:  "Test ReadConfig function #10; Call with trash retention",
+			flags: []string{"test", "-trash-retention", "48h"},
+			envSetup: func() {
+				t.Setenv("TRASH_RETENTION", "0s")
+			},
+			want: want{
+				cfg: Config{
+					ServerAddr: "localhost:8080",
+					DBPath:     "gophkeeper.db",
+					Hash: HashConfig{
+						Algorithm:  "argon2id",
+						BcryptCost: 12,
+					},
+					Login: LoginConfig{
+						LockoutCooldown: time.Hour,
+					},
+					IdleTimeout:      15 * time.Minute,
+					HistoryRetention: 20,
+					TrashRetention:   0,
+					ConflictPolicy:   "ask",
+				},
+			},
+		},
+		{
+			name:  "Test ReadConfig function #11; Call with conflict policy",
+			flags: []string{"test", "-conflict-policy", "local"},
+			envSetup: func() {
+				t.Setenv("CONFLICT_POLICY", "skip")
+			},
+			want: want{
+				cfg: Config{
+					ServerAddr: "localhost:8080",
+					DBPath:     "gophkeeper.db",
+					Hash: HashConfig{
+						Algorithm:  "argon2id",
+						BcryptCost: 12,
+					},
+					Login: LoginConfig{
+						LockoutCooldown: time.Hour,
+					},
+					IdleTimeout:      15 * time.Minute,
+					HistoryRetention: 20,
+					TrashRetention:   30 * 24 * time.Hour,
+					ConflictPolicy:   "skip",
 				},
 			},
 		},
@@ -64,9 +310,49 @@ func TestReadConfit(t *testing.T) {
 				tc.envSetup()
 				defer os.Unsetenv("SERVER_ADDR")
 				defer os.Unsetenv("DATA_BASE_PATH")
+				defer os.Unsetenv("SERVER_INSECURE")
+				defer os.Unsetenv("SERVER_CA_FILE")
+				defer os.Unsetenv("BCRYPT_COST")
+				defer os.Unsetenv("ARGON2_PARAMS")
+				defer os.Unsetenv("LOGIN_LOCKOUT_COOLDOWN")
+				defer os.Unsetenv("SESSION_IDLE_TIMEOUT")
+				defer os.Unsetenv("HISTORY_RETENTION")
+				defer os.Unsetenv("TRASH_RETENTION")
+				defer os.Unsetenv("CONFLICT_POLICY")
 			}
-			testCfg := ReadConfig()
+			testCfg, err := ReadConfig()
+			require.NoError(t, err)
 			assert.Equal(t, tc.want.cfg, *testCfg)
 		})
 	}
 }
+
+func TestRegisterFlags(t *testing.T) {
+	fs := flag.NewFlagSet("config", flag.ContinueOnError)
+	cfg := RegisterFlags(fs)
+	assert.NoError(t, fs.Parse([]string{"-insecure", "-pin", pinA + "," + pinB, "-a", ":9191"}))
+	t.Setenv("SERVER_PIN_SHA256", pinB)
+	require.NoError(t, cfg.ReadEnv())
+	assert.True(t, cfg.TLS.Insecure)
+	assert.Equal(t, []string{pinB}, cfg.TLS.Pins)
+	assert.Equal(t, ":9191", cfg.ServerAddr)
+	assert.Equal(t, "ask", cfg.ConflictPolicy)
+}
+
+func TestPins(t *testing.T) {
+	fs := flag.NewFlagSet("config", flag.ContinueOnError)
+	cfg := RegisterFlags(fs)
+	require.NoError(t, fs.Parse([]string{"-pin", " , " + pinA + ",,"}))
+	assert.Equal(t, []string{pinA}, cfg.TLS.Pins)
+
+	// Пустое значение отключает закрепление.
+	t.Setenv("SERVER_PIN_SHA256", ",")
+	require.NoError(t, cfg.ReadEnv())
+	assert.Empty(t, cfg.TLS.Pins)
+
+	for _, pin := range []string{"AAAA", "not base64!", "sha256/" + pinA[:20]} {
+		t.Setenv("SERVER_PIN_SHA256", pin)
+		assert.ErrorIs(t, cfg.ReadEnv(), ErrInvalidPin, pin)
+		assert.ErrorIs(t, (*pinList)(&cfg.TLS.Pins).Set(pin), ErrInvalidPin, pin)
+	}
+}
@@ -15,4 +15,46 @@ const (
 	BinDataExistsError    = "bin data is alredy exists"
 	BinDataNotExistsError = "bin data not found"
 	NoUserOnServerError   = "invalid login/password pair; this user does not exist"
+	InvalidVaultKeyError  = "invalid vault key"
+	InvalidKDFParamsError = "invalid kdf params"
+	VaultLockedError      = "vault is locked; sign in again"
+	NoSyncKeyError        = "sync key is not set; sign in again"
+	PinMismatchError      = "server certificate does not match any pinned key"
+	InvalidCABundleError  = "no certificates found in CA bundle"
+	InvalidPinError       = "invalid pin; expected base64 SHA-256 hash of server public key"
+	ClientCertPairError   = "both client certificate and key are required for mTLS"
+	SessionExpiredError   = "session expired; sign in again"
+	NoServerTokenError    = "no server token in session; sign in while server is available"
+	HashAlgorithmError    = "unknown password hash algorithm"
+	InvalidHashError      = "invalid password hash format"
+	BcryptCostError       = "bcrypt cost is out of range"
+	LoginDelayedError     = "too many failed sign in attempts"
+	SessionLockedError    = "session is locked; run unlock"
+	NoCurrentUserError    = "no signed in user; sign in first"
+	SecretMismatchError   = "entered values do not match"
+	EmptySecretError      = "empty value entered"
+	NoCharClassesError    = "at least one character class is required"
+	PasswordLengthError   = "password length is shorter than the number of character classes"
+	WordCountError        = "passphrase must contain at least one word"
+	PwnedLineError        = "invalid line in breached password list"
+	OTPExistsError        = "otp is alredy exists"
+	OTPNotExistsError     = "otp not found"
+	InvalidOTPURIError    = "invalid otpauth uri"
+	OTPAlgorithmError     = "unsupported otp algorithm"
+	CardNumberError       = "invalid card number"
+	CardDateError         = "invalid card date; expected MM/YY"
+	CardCVVError          = "invalid cvv"
+	InvalidMetaError      = "metadata must be in key=value form"
+	FolderExistsError     = "folder is alredy exists"
+	FolderNotExistsError  = "folder not found"
+	InvalidFolderError    = "invalid folder path"
+	FolderCycleError      = "folder cannot be moved into itself"
+	VersionNotExistsError = "version not found"
+	UnknownKindError      = "unknown record type"
+	EmptyNameError        = "record name must not be empty"
+	ConflictNotExistError = "conflict not found"
+	ConflictPolicyError   = "unknown conflict resolution; expected local, remote, both or skip"
+	TimestampError        = "invalid record timestamp"
+	NoSessionSecretError  = "GOPHKEEPER_SESSION is not set; export the value printed by sign_in or unlock"
+	SessionSecretError    = "GOPHKEEPER_SESSION does not match the current session; run unlock and export the new value"
 )
@@ -1,27 +1,87 @@
 package models
 
-import gophkeeperv1 "github.com/Dorrrke/goph-keeper-proto/gen/go/gophkeeper"
+import (
+	"time"
+
+	"github.com/Dorrrke/GophKeeper-client/internal/hlc"
+	gophkeeperv1 "github.com/Dorrrke/goph-keeper-proto/gen/go/gophkeeper"
+)
 
 type CardModel struct {
 	Name    string
 	Number  string
 	Date    string
-	CVVCode int
+	CVVCode string
+	// Meta - произвольные метаданные записи (банк, адрес сайта, заметки).
+	Meta map[string]string
+	// Folder - путь папки записи вида "Работа/Банки", пустой для корня.
+	Folder string
+	Tags   []string
 }
 
 type LoginModel struct {
 	Name     string
 	Login    string
 	Password string
+	// Updated - время последнего изменения записи.
+	Updated time.Time
+	Meta    map[string]string
+	Folder  string
+	Tags    []string
 }
 
 type TextDataModel struct {
-	Name string
-	Data string
+	Name   string
+	Data   string
+	Meta   map[string]string
+	Folder string
+	Tags   []string
 }
 
 type BinaryDataModel struct {
-	Name string
+	Name   string
+	Data   []byte
+	Meta   map[string]string
+	Folder string
+	Tags   []string
+}
+
+// OTPModel - секрет одноразовых паролей TOTP/HOTP.
+type OTPModel struct {
+	Name      string
+	Type      string
+	Secret    string
+	Issuer    string
+	Account   string
+	Algorithm string
+	Digits    int
+	Period    int
+	Counter   uint64
+	Meta      map[string]string
+	Folder    string
+	Tags      []string
+}
+
+// AccessLogModel - запись журнала доступа к секретным данным.
+type AccessLogModel struct {
+	Kind   string
+	Name   string
+	Action string
+	At     time.Time
+}
+
+// TrashModel - удаленная запись, которую еще можно восстановить.
+type TrashModel struct {
+	Kind    string
+	Name    string
+	Deleted time.Time
+}
+
+// HistoryModel - сохраненная предыдущая версия записи.
+type HistoryModel struct {
+	Version int
+	Created time.Time
+	// Data - запись в JSON, заполняется только при получении конкретной версии.
 	Data []byte
 }
 
@@ -31,46 +91,136 @@ type UserModel struct {
 	Hash   string `json:"hash"`
 }
 
+// TokensModel - токены доступа к серверу.
+type TokensModel struct {
+	AccessToken  string    `json:"access_token,omitempty"`
+	RefreshToken string    `json:"refresh_token,omitempty"`
+	ExpiresAt    time.Time `json:"expires_at"`
+}
+
+// SessionModel - сессия пользователя, сохраняемая в auth_conf.
+type SessionModel struct {
+	UserID    int64       `json:"u_id"`
+	Login     string      `json:"login"`
+	Tokens    TokensModel `json:"tokens"`
+	SyncKey   []byte      `json:"sync_key,omitempty"`
+	ExpiresAt time.Time   `json:"expires_at"`
+	// LastActivity - время последней команды, используется для блокировки по бездействию.
+	LastActivity time.Time `json:"last_activity"`
+}
+
+type KDFModel struct {
+	Salt   []byte
+	Params string
+}
+
+// RootFolder - обозначение корневой папки в командах и при обновлении записи.
+const RootFolder = "/"
+
+// SyncFolderModel - папка пользователя для синхронизации.
+type SyncFolderModel struct {
+	UserID  int64
+	Path    string
+	Deleted bool
+	Updated hlc.Timestamp
+}
+
+// SyncModel - записи пользователя для синхронизации. Updated - метка гибридных часов последнего изменения записи,
+// у записей с устройства Base - метка версии с сервера, на основе которой сделано локальное изменение.
 type SyncModel struct {
 	Cards []SyncCardModel
 	Texts []SyncTextDataModel
 	Bins  []SyncBinaryDataModel
 	Auth  []SyncLoginModel
+	OTPs  []SyncOTPModel
+	// Folders - папки пользователя, в том числе пустые.
+	Folders []SyncFolderModel
 }
 
 type SyncCardModel struct {
 	UserID  int64
 	Name    string
+	UUID    string
 	Number  string
 	Date    string
-	CVVCode int
+	CVVCode string
 	Deleted bool
-	Updated string
+	Updated hlc.Timestamp
+	Base    hlc.Timestamp
+	Meta    map[string]string
+	Folder  string
+	Tags    []string
 }
 
 type SyncLoginModel struct {
 	UserID   int64
 	Name     string
+	UUID     string
 	Login    string
 	Password string
 	Deleted  bool
-	Updated  string
+	Updated  hlc.Timestamp
+	Base     hlc.Timestamp
+	Meta     map[string]string
+	Folder   string
+	Tags     []string
 }
 
 type SyncTextDataModel struct {
 	UserID  int64
 	Name    string
+	UUID    string
 	Data    string
 	Deleted bool
-	Updated string
+	Updated hlc.Timestamp
+	Base    hlc.Timestamp
+	Meta    map[string]string
+	Folder  string
+	Tags    []string
 }
 
 type SyncBinaryDataModel struct {
 	UserID  int64
 	Name    string
+	UUID    string
 	Data    []byte
 	Deleted bool
-	Updated string
+	Updated hlc.Timestamp
+	Base    hlc.Timestamp
+	Meta    map[string]string
+	Folder  string
+	Tags    []string
+}
+
+type SyncOTPModel struct {
+	UserID    int64
+	Name      string
+	UUID      string
+	Type      string
+	Secret    string
+	Issuer    string
+	Account   string
+	Algorithm string
+	Digits    int
+	Period    int
+	Counter   uint64
+	Deleted   bool
+	Updated   hlc.Timestamp
+	Base      hlc.Timestamp
+	Meta      map[string]string
+	Folder    string
+	Tags      []string
+}
+
+// ConflictModel - запись, измененная и на устройстве, и на сервере после последней синхронизации.
+// Local и Remote содержат по одной версии записи вида Kind.
+type ConflictModel struct {
+	Kind     string
+	Name     string
+	UUID     string
+	Local    SyncModel
+	Remote   SyncModel
+	Detected time.Time
 }
 
 type ProtoSyncModel struct {
@@ -79,3 +229,10 @@ type ProtoSyncModel struct {
 	Bins  []*gophkeeperv1.SyncBinData
 	Auth  []*gophkeeperv1.SyncAuth
 }
+
+// LoginAttemptsModel - неудачные попытки входа под логином.
+type LoginAttemptsModel struct {
+	Failures    int
+	LastFailure time.Time
+	LockedUntil time.Time
+}
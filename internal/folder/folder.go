@@ -0,0 +1,82 @@
+// Package folder работает с путями папок вида "Работа/Банки".
+// Корневая папка обозначается пустым путем.
+package folder
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	errText "github.com/Dorrrke/GophKeeper-client/internal/domain/errors"
+)
+
+var (
+	ErrInvalid = errors.New(errText.InvalidFolderError)
+	ErrCycle   = errors.New(errText.FolderCycleError)
+)
+
+// Separator разделяет уровни вложенности папок.
+const Separator = "/"
+
+// Normalize приводит путь к каноническому виду: без пробелов по краям сегментов
+// и без разделителей в начале и в конце. "/" и пустая строка обозначают корень.
+func Normalize(path string) (string, error) {
+	path = strings.Trim(strings.TrimSpace(path), Separator)
+	if path == "" {
+		return "", nil
+	}
+	segments := strings.Split(path, Separator)
+	for i, seg := range segments {
+		seg = strings.TrimSpace(seg)
+		if seg == "" || seg == "." || seg == ".." {
+			return "", fmt.Errorf("%w: %q", ErrInvalid, path)
+		}
+		segments[i] = seg
+	}
+	return strings.Join(segments, Separator), nil
+}
+
+// Join добавляет к пути родительской папки имя вложенной.
+func Join(parent string, name string) string {
+	if parent == "" {
+		return name
+	}
+	return parent + Separator + name
+}
+
+// Parent возвращает путь родительской папки, для папки верхнего уровня - корень.
+func Parent(path string) string {
+	i := strings.LastIndex(path, Separator)
+	if i < 0 {
+		return ""
+	}
+	return path[:i]
+}
+
+// Base возвращает имя папки без пути родителя.
+func Base(path string) string {
+	return path[strings.LastIndex(path, Separator)+1:]
+}
+
+// Ancestors возвращает путь и все его родительские папки, начиная с верхнего уровня.
+func Ancestors(path string) []string {
+	if path == "" {
+		return nil
+	}
+	segments := strings.Split(path, Separator)
+	paths := make([]string, len(segments))
+	for i := range segments {
+		paths[i] = strings.Join(segments[:i+1], Separator)
+	}
+	return paths
+}
+
+// Contains сообщает, совпадает ли path с parent или вложен в нее. Корень содержит любую папку.
+func Contains(parent string, path string) bool {
+	return parent == "" || path == parent || strings.HasPrefix(path, parent+Separator)
+}
+
+// Move возвращает новый путь папки path при переносе папки from в to.
+func Move(path string, from string, to string) string {
+	return to + strings.TrimPrefix(path, from)
+}
@@ -0,0 +1,47 @@
+package folder
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := map[string]string{
+		"":                 "",
+		"/":                "",
+		"Work":             "Work",
+		"/Work/Banks/":     "Work/Banks",
+		" Work / Banks ":   "Work/Banks",
+		"Личное/Документы": "Личное/Документы",
+	}
+	for in, want := range tests {
+		got, err := Normalize(in)
+		assert.NoError(t, err, in)
+		assert.Equal(t, want, got, in)
+	}
+	for _, bad := range []string{"Work//Banks", "Work/../x", "./Work", "Work/ /x"} {
+		_, err := Normalize(bad)
+		assert.ErrorIs(t, err, ErrInvalid, bad)
+	}
+}
+
+func TestPaths(t *testing.T) {
+	assert.Equal(t, "Work/Banks", Join("Work", "Banks"))
+	assert.Equal(t, "Work", Join("", "Work"))
+	assert.Equal(t, "Work", Parent("Work/Banks"))
+	assert.Equal(t, "", Parent("Work"))
+	assert.Equal(t, "Banks", Base("Work/Banks"))
+	assert.Equal(t, "Work", Base("Work"))
+	assert.Equal(t, []string{"a", "a/b", "a/b/c"}, Ancestors("a/b/c"))
+	assert.Nil(t, Ancestors(""))
+
+	assert.True(t, Contains("", "a/b"))
+	assert.True(t, Contains("a", "a"))
+	assert.True(t, Contains("a", "a/b"))
+	assert.False(t, Contains("a", "ab"))
+	assert.False(t, Contains("a/b", "a"))
+
+	assert.Equal(t, "x/y/b", Move("a/b", "a", "x/y"))
+	assert.Equal(t, "x", Move("a", "a", "x"))
+}
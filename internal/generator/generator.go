@@ -0,0 +1,167 @@
+// Package generator генерирует пароли и парольные фразы с помощью crypto/rand.
+package generator
+
+import (
+	"bufio"
+	"crypto/rand"
+	_ "embed"
+	"errors"
+	"math/big"
+	"strings"
+
+	errText "github.com/Dorrrke/GophKeeper-client/internal/domain/errors"
+)
+
+var (
+	ErrNoCharClasses = errors.New(errText.NoCharClassesError)
+	ErrLength        = errors.New(errText.PasswordLengthError)
+	ErrWordCount     = errors.New(errText.WordCountError)
+)
+
+// Наборы символов для паролей.
+const (
+	lowerChars  = "abcdefghijklmnopqrstuvwxyz"
+	upperChars  = "ABCDEFGHIJKLMNOPQRSTUVWXYZ"
+	digitChars  = "0123456789"
+	symbolChars = "!@#$%^&*()-_=+[]{};:,.<>?/~"
+	// ambiguousChars - символы, которые легко спутать при чтении.
+	ambiguousChars = "Il1|O0o"
+)
+
+// effLargeWordlist - словарь EFF для diceware: https://www.eff.org/dice.
+//
+//go:embed eff_large_wordlist.txt
+var effLargeWordlist string
+
+var wordlist = parseWordlist(effLargeWordlist)
+
+// Options - параметры генерации пароля.
+type Options struct {
+	Length           int
+	Lower            bool
+	Upper            bool
+	Digits           bool
+	Symbols          bool
+	ExcludeAmbiguous bool
+}
+
+// DefaultOptions - параметры пароля по умолчанию.
+var DefaultOptions = Options{
+	Length:  20,
+	Lower:   true,
+	Upper:   true,
+	Digits:  true,
+	Symbols: true,
+}
+
+// Password генерирует пароль, в котором есть хотя бы один символ каждого выбранного набора.
+func Password(opts Options) (string, error) {
+	var classes []string
+	for _, class := range []struct {
+		enabled bool
+		chars   string
+	}{
+		{opts.Lower, lowerChars},
+		{opts.Upper, upperChars},
+		{opts.Digits, digitChars},
+		{opts.Symbols, symbolChars},
+	} {
+		if !class.enabled {
+			continue
+		}
+		chars := class.chars
+		if opts.ExcludeAmbiguous {
+			chars = strings.Map(func(r rune) rune {
+				if strings.ContainsRune(ambiguousChars, r) {
+					return -1
+				}
+				return r
+			}, chars)
+		}
+		classes = append(classes, chars)
+	}
+	if len(classes) == 0 {
+		return "", ErrNoCharClasses
+	}
+	if opts.Length < len(classes) {
+		return "", ErrLength
+	}
+
+	password := make([]byte, 0, opts.Length)
+	for _, chars := range classes {
+		c, err := randomChar(chars)
+		if err != nil {
+			return "", err
+		}
+		password = append(password, c)
+	}
+	all := strings.Join(classes, "")
+	for len(password) < opts.Length {
+		c, err := randomChar(all)
+		if err != nil {
+			return "", err
+		}
+		password = append(password, c)
+	}
+	if err := shuffle(password); err != nil {
+		return "", err
+	}
+	return string(password), nil
+}
+
+// Passphrase генерирует парольную фразу из слов словаря EFF.
+func Passphrase(words int, separator string) (string, error) {
+	if words < 1 {
+		return "", ErrWordCount
+	}
+	phrase := make([]string, words)
+	for i := range phrase {
+		n, err := randomInt(len(wordlist))
+		if err != nil {
+			return "", err
+		}
+		phrase[i] = wordlist[n]
+	}
+	return strings.Join(phrase, separator), nil
+}
+
+func randomChar(chars string) (byte, error) {
+	n, err := randomInt(len(chars))
+	if err != nil {
+		return 0, err
+	}
+	return chars[n], nil
+}
+
+// shuffle перемешивает символы алгоритмом Фишера-Йетса.
+func shuffle(data []byte) error {
+	for i := len(data) - 1; i > 0; i-- {
+		j, err := randomInt(i + 1)
+		if err != nil {
+			return err
+		}
+		data[i], data[j] = data[j], data[i]
+	}
+	return nil
+}
+
+// randomInt возвращает равномерно распределенное число из [0, n).
+func randomInt(n int) (int, error) {
+	v, err := rand.Int(rand.Reader, big.NewInt(int64(n)))
+	if err != nil {
+		return 0, err
+	}
+	return int(v.Int64()), nil
+}
+
+// parseWordlist разбирает словарь в формате EFF: номер броска кубиков и слово через табуляцию.
+func parseWordlist(data string) []string {
+	var words []string
+	scanner := bufio.NewScanner(strings.NewReader(data))
+	for scanner.Scan() {
+		if _, word, ok := strings.Cut(scanner.Text(), "\t"); ok && word != "" {
+			words = append(words, word)
+		}
+	}
+	return words
+}
@@ -0,0 +1,83 @@
+package generator
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPassword(t *testing.T) {
+	type want struct {
+		classes []string
+		absent  string
+		err     error
+	}
+	type test struct {
+		name string
+		opts Options
+		want want
+	}
+	tests := []test{
+		{
+			name: "Test Password function #1; Default options",
+			opts: DefaultOptions,
+			want: want{
+				classes: []string{lowerChars, upperChars, digitChars, symbolChars},
+			},
+		},
+		{
+			name: "Test Password function #2; Digits without ambiguous characters",
+			opts: Options{Length: 64, Digits: true, ExcludeAmbiguous: true},
+			want: want{
+				classes: []string{digitChars},
+				absent:  "01",
+			},
+		},
+		{
+			name: "Test Password function #3; No character classes",
+			opts: Options{Length: 10},
+			want: want{
+				err: ErrNoCharClasses,
+			},
+		},
+		{
+			name: "Test Password function #4; Length shorter than classes",
+			opts: Options{Length: 3, Lower: true, Upper: true, Digits: true, Symbols: true},
+			want: want{
+				err: ErrLength,
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			password, err := Password(tc.opts)
+			assert.ErrorIs(t, err, tc.want.err)
+			if tc.want.err != nil {
+				return
+			}
+			assert.Len(t, password, tc.opts.Length)
+			for _, class := range tc.want.classes {
+				assert.True(t, strings.ContainsAny(password, class), "no characters from %q", class)
+			}
+			assert.False(t, strings.ContainsAny(password, tc.want.absent))
+			assert.Empty(t, strings.Trim(password, strings.Join(tc.want.classes, "")))
+		})
+	}
+}
+
+func TestPassphrase(t *testing.T) {
+	assert.Len(t, wordlist, 7776)
+
+	phrase, err := Passphrase(6, " ")
+	assert.NoError(t, err)
+	words := strings.Split(phrase, " ")
+	assert.Len(t, words, 6)
+	for _, word := range words {
+		assert.Contains(t, wordlist, word)
+	}
+
+	_, err = Passphrase(0, "-")
+	assert.ErrorIs(t, err, ErrWordCount)
+}
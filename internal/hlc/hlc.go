@@ -0,0 +1,218 @@
+// Package hlc реализует гибридные логические часы для меток изменения записей.
+// Метка состоит из физического времени в миллисекундах, счетчика событий внутри одной миллисекунды
+// и идентификатора устройства, поэтому метки одного устройства строго растут даже при переводе системных часов,
+// а метки разных устройств не совпадают. Строковое представление сортируется так же, как сами метки.
+package hlc
+
+import (
+	"crypto/rand"
+	"database/sql/driver"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	errText "github.com/Dorrrke/GophKeeper-client/internal/domain/errors"
+)
+
+var ErrTimestamp = errors.New(errText.TimestampError)
+
+// wallLayout - формат физического времени метки. Время всегда в UTC с миллисекундами, поэтому длина постоянна.
+const wallLayout = "2006-01-02T15:04:05.000Z"
+
+// deviceLen - длина идентификатора устройства в шестнадцатеричном виде.
+const deviceLen = 16
+
+// LegacyDevice - устройство меток, полученных из времени в формате RFC3339.
+// Все устройства переводят такое время в одинаковую метку.
+const LegacyDevice = "0000000000000000"
+
+// Timestamp - метка гибридных часов. Нулевое значение означает отсутствие метки.
+type Timestamp struct {
+	// Wall - физическое время в миллисекундах Unix.
+	Wall int64
+	// Counter - номер события внутри одной миллисекунды Wall.
+	Counter uint16
+	// Device - идентификатор устройства, выдавшего метку.
+	Device string
+}
+
+// NewDevice создает случайный идентификатор устройства.
+func NewDevice() (string, error) {
+	b := make([]byte, deviceLen/2)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// FromTime переводит время без счетчика в метку устройства LegacyDevice.
+func FromTime(t time.Time) Timestamp {
+	return Timestamp{Wall: t.UnixMilli(), Device: LegacyDevice}
+}
+
+// Parse разбирает строковое представление метки. Пустая строка дает нулевую метку,
+// время в формате RFC3339 из записей прежних версий клиента переводится через FromTime.
+func Parse(value string) (Timestamp, error) {
+	if value == "" {
+		return Timestamp{}, nil
+	}
+	wall, rest, ok := strings.Cut(value, "/")
+	if !ok {
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return Timestamp{}, fmt.Errorf("%w: %q", ErrTimestamp, value)
+		}
+		return FromTime(t), nil
+	}
+	counter, device, ok := strings.Cut(rest, "/")
+	if !ok || len(wall) != len(wallLayout) || len(counter) != 4 || len(device) != deviceLen {
+		return Timestamp{}, fmt.Errorf("%w: %q", ErrTimestamp, value)
+	}
+	t, err := time.Parse(wallLayout, wall)
+	if err != nil {
+		return Timestamp{}, fmt.Errorf("%w: %q", ErrTimestamp, value)
+	}
+	c, err := strconv.ParseUint(counter, 16, 16)
+	if err != nil {
+		return Timestamp{}, fmt.Errorf("%w: %q", ErrTimestamp, value)
+	}
+	return Timestamp{Wall: t.UnixMilli(), Counter: uint16(c), Device: device}, nil
+}
+
+// MustParse работает как Parse, но паникует при ошибке. Используется для меток, заданных в коде.
+func MustParse(value string) Timestamp {
+	ts, err := Parse(value)
+	if err != nil {
+		panic(err)
+	}
+	return ts
+}
+
+// String возвращает сортируемое представление метки вида 2024-01-02T15:04:05.000Z/0001/9f86d081884c7d65.
+// Для нулевой метки возвращается пустая строка.
+func (t Timestamp) String() string {
+	if t.IsZero() {
+		return ""
+	}
+	return fmt.Sprintf("%s/%04x/%s", t.Time().UTC().Format(wallLayout), t.Counter, t.Device)
+}
+
+func (t Timestamp) IsZero() bool {
+	return t == Timestamp{}
+}
+
+// Time возвращает физическое время метки.
+func (t Timestamp) Time() time.Time {
+	if t.IsZero() {
+		return time.Time{}
+	}
+	return time.UnixMilli(t.Wall)
+}
+
+// Compare сравнивает метки: -1, если t раньше u, 0, если метки равны, и +1, если t позже u.
+func (t Timestamp) Compare(u Timestamp) int {
+	switch {
+	case t.Wall != u.Wall:
+		return cmp(t.Wall < u.Wall)
+	case t.Counter != u.Counter:
+		return cmp(t.Counter < u.Counter)
+	case t.Device != u.Device:
+		return cmp(t.Device < u.Device)
+	}
+	return 0
+}
+
+func cmp(less bool) int {
+	if less {
+		return -1
+	}
+	return 1
+}
+
+// After сообщает, позже ли метка t метки u.
+func (t Timestamp) After(u Timestamp) bool {
+	return t.Compare(u) > 0
+}
+
+func (t Timestamp) MarshalText() ([]byte, error) {
+	return []byte(t.String()), nil
+}
+
+func (t *Timestamp) UnmarshalText(text []byte) error {
+	ts, err := Parse(string(text))
+	if err != nil {
+		return err
+	}
+	*t = ts
+	return nil
+}
+
+// Value сохраняет метку в базе строкой, нулевую метку - как NULL.
+func (t Timestamp) Value() (driver.Value, error) {
+	if t.IsZero() {
+		return nil, nil
+	}
+	return t.String(), nil
+}
+
+// Scan читает метку из строки базы. NULL и пустая строка дают нулевую метку.
+func (t *Timestamp) Scan(src any) error {
+	switch v := src.(type) {
+	case nil:
+		*t = Timestamp{}
+		return nil
+	case string:
+		return t.UnmarshalText([]byte(v))
+	case []byte:
+		return t.UnmarshalText(v)
+	}
+	return fmt.Errorf("%w: unsupported type %T", ErrTimestamp, src)
+}
+
+// Clock - гибридные часы устройства. Безопасны для одновременного использования.
+type Clock struct {
+	mu     sync.Mutex
+	device string
+	last   Timestamp
+	now    func() time.Time
+}
+
+// NewClock создает часы устройства device, продолжающие отсчет после метки last.
+func NewClock(device string, last Timestamp) *Clock {
+	return &Clock{device: device, last: last, now: time.Now}
+}
+
+// Now выдает метку нового события. Она позже всех выданных и полученных часами меток.
+func (c *Clock) Now() Timestamp {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.last = c.next(c.last)
+	return c.last
+}
+
+// Update учитывает метку, полученную с другого устройства, чтобы следующие метки были позже нее.
+// Возвращает последнюю метку часов после учета, она всегда принадлежит этому устройству.
+func (c *Clock) Update(remote Timestamp) Timestamp {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if remote.After(c.last) {
+		c.last = Timestamp{Wall: remote.Wall, Counter: remote.Counter, Device: c.device}
+	}
+	return c.last
+}
+
+// next возвращает метку устройства, следующую за after.
+func (c *Clock) next(after Timestamp) Timestamp {
+	wall := c.now().UnixMilli()
+	if wall > after.Wall {
+		return Timestamp{Wall: wall, Device: c.device}
+	}
+	if after.Counter == 1<<16-1 {
+		return Timestamp{Wall: after.Wall + 1, Device: c.device}
+	}
+	return Timestamp{Wall: after.Wall, Counter: after.Counter + 1, Device: c.device}
+}
@@ -0,0 +1,105 @@
+package hlc
+
+import (
+	"encoding/json"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	ts := Timestamp{Wall: time.Date(2024, time.January, 2, 7, 0, 0, 123e6, time.UTC).UnixMilli(), Counter: 26, Device: "9f86d081884c7d65"}
+	assert.Equal(t, "2024-01-02T07:00:00.123Z/001a/9f86d081884c7d65", ts.String())
+	parsed, err := Parse(ts.String())
+	require.NoError(t, err)
+	assert.Equal(t, ts, parsed)
+
+	// Время прежних версий переводится одинаково независимо от часового пояса.
+	legacy, err := Parse("2024-01-02T10:00:00+03:00")
+	require.NoError(t, err)
+	assert.Equal(t, "2024-01-02T07:00:00.000Z/0000/"+LegacyDevice, legacy.String())
+
+	zero, err := Parse("")
+	require.NoError(t, err)
+	assert.True(t, zero.IsZero())
+	assert.Equal(t, "", zero.String())
+
+	for _, bad := range []string{"yesterday", "2024-01-02T07:00:00.123Z/1/9f86d081884c7d65", "2024-01-02T07:00:00.123Z/001a/ab",
+		"2024-01-02T07:00:00.123Z/zzzz/9f86d081884c7d65"} {
+		_, err := Parse(bad)
+		assert.ErrorIs(t, err, ErrTimestamp, bad)
+	}
+}
+
+func TestClock(t *testing.T) {
+	wall := time.Date(2024, time.January, 2, 7, 0, 0, 0, time.UTC)
+	clock := NewClock("aaaaaaaaaaaaaaaa", Timestamp{})
+	clock.now = func() time.Time { return wall }
+
+	first := clock.Now()
+	second := clock.Now()
+	assert.Equal(t, wall.UnixMilli(), first.Wall)
+	assert.True(t, second.After(first))
+	assert.Equal(t, uint16(1), second.Counter)
+
+	// Системные часы отстали: метки продолжают расти.
+	wall = wall.Add(-time.Hour)
+	third := clock.Now()
+	assert.True(t, third.After(second))
+
+	// Метка с устройства, часы которого спешат, сдвигает часы вперед.
+	remote := Timestamp{Wall: wall.Add(2 * time.Hour).UnixMilli(), Counter: 5, Device: "bbbbbbbbbbbbbbbb"}
+	merged := clock.Update(remote)
+	assert.Equal(t, remote.Wall, merged.Wall)
+	assert.Equal(t, "aaaaaaaaaaaaaaaa", merged.Device)
+	next := clock.Now()
+	assert.True(t, next.After(remote))
+	assert.Equal(t, "aaaaaaaaaaaaaaaa", next.Device)
+
+	assert.Equal(t, next, clock.Update(first))
+	assert.True(t, clock.Now().After(next))
+}
+
+func TestOrder(t *testing.T) {
+	stamps := []Timestamp{
+		{Wall: 1704178800001, Device: "bbbbbbbbbbbbbbbb"},
+		{Wall: 1704178800000, Counter: 16, Device: "aaaaaaaaaaaaaaaa"},
+		{Wall: 1704178800000, Counter: 2, Device: "bbbbbbbbbbbbbbbb"},
+		{Wall: 1704178800000, Counter: 2, Device: "aaaaaaaaaaaaaaaa"},
+	}
+	strs := make([]string, len(stamps))
+	for i, ts := range stamps {
+		strs[i] = ts.String()
+	}
+	sort.Slice(stamps, func(i, j int) bool { return stamps[i].Compare(stamps[j]) < 0 })
+	sort.Strings(strs)
+	for i, ts := range stamps {
+		assert.Equal(t, ts.String(), strs[i])
+	}
+}
+
+func TestEncoding(t *testing.T) {
+	type record struct {
+		Updated Timestamp
+		Base    Timestamp
+	}
+	in := record{Updated: Timestamp{Wall: 1704178800000, Counter: 1, Device: "aaaaaaaaaaaaaaaa"}}
+	data, err := json.Marshal(in)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"Updated":"2024-01-02T07:00:00.000Z/0001/aaaaaaaaaaaaaaaa","Base":""}`, string(data))
+	var out record
+	require.NoError(t, json.Unmarshal(data, &out))
+	assert.Equal(t, in, out)
+
+	var scanned Timestamp
+	require.NoError(t, scanned.Scan(nil))
+	assert.True(t, scanned.IsZero())
+	require.NoError(t, scanned.Scan([]byte(in.Updated.String())))
+	assert.Equal(t, in.Updated, scanned)
+	value, err := Timestamp{}.Value()
+	require.NoError(t, err)
+	assert.Nil(t, value)
+}
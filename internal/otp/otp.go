@@ -0,0 +1,182 @@
+// Package otp разбирает ссылки otpauth:// и вычисляет одноразовые пароли HOTP (RFC 4226) и TOTP (RFC 6238).
+package otp
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base32"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	errText "github.com/Dorrrke/GophKeeper-client/internal/domain/errors"
+	"github.com/Dorrrke/GophKeeper-client/internal/domain/models"
+)
+
+var (
+	ErrInvalidURI = errors.New(errText.InvalidOTPURIError)
+	ErrAlgorithm  = errors.New(errText.OTPAlgorithmError)
+)
+
+// Типы одноразовых паролей.
+const (
+	TypeTOTP = "totp"
+	TypeHOTP = "hotp"
+)
+
+// Значения по умолчанию из формата Key Uri.
+const (
+	DefaultAlgorithm = "SHA1"
+	DefaultDigits    = 6
+	DefaultPeriod    = 30
+)
+
+var algorithms = map[string]func() hash.Hash{
+	"SHA1":   sha1.New,
+	"SHA256": sha256.New,
+	"SHA512": sha512.New,
+}
+
+// ParseURI разбирает ссылку вида otpauth://totp/Issuer:account?secret=...&issuer=...
+func ParseURI(uri string) (models.OTPModel, error) {
+	u, err := url.Parse(strings.TrimSpace(uri))
+	if err != nil || u.Scheme != "otpauth" {
+		return models.OTPModel{}, fmt.Errorf("%w: %s", ErrInvalidURI, "scheme must be otpauth")
+	}
+	m := models.OTPModel{
+		Type:      strings.ToLower(u.Host),
+		Algorithm: DefaultAlgorithm,
+		Digits:    DefaultDigits,
+	}
+	if m.Type != TypeTOTP && m.Type != TypeHOTP {
+		return models.OTPModel{}, fmt.Errorf("%w: unknown type %q", ErrInvalidURI, u.Host)
+	}
+	label := strings.TrimPrefix(u.Path, "/")
+	if issuer, account, ok := strings.Cut(label, ":"); ok {
+		m.Issuer, m.Account = strings.TrimSpace(issuer), strings.TrimSpace(account)
+	} else {
+		m.Account = label
+	}
+	q := u.Query()
+	if issuer := q.Get("issuer"); issuer != "" {
+		m.Issuer = issuer
+	}
+	m.Secret, err = normalizeSecret(q.Get("secret"))
+	if err != nil {
+		return models.OTPModel{}, err
+	}
+	if alg := q.Get("algorithm"); alg != "" {
+		m.Algorithm = strings.ToUpper(alg)
+	}
+	if _, ok := algorithms[m.Algorithm]; !ok {
+		return models.OTPModel{}, fmt.Errorf("%w: %s", ErrAlgorithm, m.Algorithm)
+	}
+	if digits := q.Get("digits"); digits != "" {
+		m.Digits, err = strconv.Atoi(digits)
+		if err != nil || m.Digits < 6 || m.Digits > 10 {
+			return models.OTPModel{}, fmt.Errorf("%w: digits %q", ErrInvalidURI, digits)
+		}
+	}
+	if m.Type == TypeTOTP {
+		m.Period = DefaultPeriod
+		if period := q.Get("period"); period != "" {
+			m.Period, err = strconv.Atoi(period)
+			if err != nil || m.Period <= 0 {
+				return models.OTPModel{}, fmt.Errorf("%w: period %q", ErrInvalidURI, period)
+			}
+		}
+	}
+	if m.Type == TypeHOTP {
+		counter := q.Get("counter")
+		if counter == "" {
+			return models.OTPModel{}, fmt.Errorf("%w: hotp requires counter", ErrInvalidURI)
+		}
+		m.Counter, err = strconv.ParseUint(counter, 10, 64)
+		if err != nil {
+			return models.OTPModel{}, fmt.Errorf("%w: counter %q", ErrInvalidURI, counter)
+		}
+	}
+	return m, nil
+}
+
+// URI собирает ссылку otpauth:// для экспорта секрета в другое приложение.
+func URI(m models.OTPModel) string {
+	label := m.Account
+	if m.Issuer != "" {
+		label = m.Issuer + ":" + m.Account
+	}
+	q := url.Values{}
+	q.Set("secret", m.Secret)
+	if m.Issuer != "" {
+		q.Set("issuer", m.Issuer)
+	}
+	q.Set("algorithm", m.Algorithm)
+	q.Set("digits", strconv.Itoa(m.Digits))
+	if m.Type == TypeTOTP {
+		q.Set("period", strconv.Itoa(m.Period))
+	} else {
+		q.Set("counter", strconv.FormatUint(m.Counter, 10))
+	}
+	u := url.URL{Scheme: "otpauth", Host: m.Type, Path: "/" + label, RawQuery: q.Encode()}
+	return u.String()
+}
+
+// Code возвращает одноразовый пароль: для TOTP - на момент t, для HOTP - для текущего счетчика.
+func Code(m models.OTPModel, t time.Time) (string, error) {
+	counter := m.Counter
+	if m.Type == TypeTOTP {
+		counter = uint64(t.Unix()) / uint64(m.Period)
+	}
+	return HOTP(m.Secret, counter, m.Digits, m.Algorithm)
+}
+
+// Remaining возвращает, сколько секунд код TOTP останется действительным.
+func Remaining(m models.OTPModel, t time.Time) int {
+	if m.Type != TypeTOTP {
+		return 0
+	}
+	return m.Period - int(t.Unix()%int64(m.Period))
+}
+
+// HOTP вычисляет одноразовый пароль по RFC 4226.
+func HOTP(secret string, counter uint64, digits int, algorithm string) (string, error) {
+	newHash, ok := algorithms[algorithm]
+	if !ok {
+		return "", fmt.Errorf("%w: %s", ErrAlgorithm, algorithm)
+	}
+	key, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(secret)
+	if err != nil {
+		return "", fmt.Errorf("%w: %s", ErrInvalidURI, "secret is not base32")
+	}
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], counter)
+	mac := hmac.New(newHash, key)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+	offset := sum[len(sum)-1] & 0x0f
+	code := uint64(binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff)
+	mod := uint64(1)
+	for i := 0; i < digits; i++ {
+		mod *= 10
+	}
+	return fmt.Sprintf("%0*d", digits, code%mod), nil
+}
+
+// normalizeSecret приводит секрет base32 к верхнему регистру без пробелов и выравнивания.
+func normalizeSecret(secret string) (string, error) {
+	secret = strings.ToUpper(strings.NewReplacer(" ", "", "-", "", "=", "").Replace(secret))
+	if secret == "" {
+		return "", fmt.Errorf("%w: %s", ErrInvalidURI, "secret is required")
+	}
+	if _, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(secret); err != nil {
+		return "", fmt.Errorf("%w: %s", ErrInvalidURI, "secret is not base32")
+	}
+	return secret, nil
+}
@@ -0,0 +1,142 @@
+package otp
+
+import (
+	"encoding/base32"
+	"testing"
+	"time"
+
+	"github.com/Dorrrke/GophKeeper-client/internal/domain/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Тестовые векторы RFC 6238, приложение B.
+func TestTOTPVectors(t *testing.T) {
+	secrets := map[string]string{
+		"SHA1":   "12345678901234567890",
+		"SHA256": "12345678901234567890123456789012",
+		"SHA512": "1234567890123456789012345678901234567890123456789012345678901234",
+	}
+	type test struct {
+		unix int64
+		alg  string
+		code string
+	}
+	tests := []test{
+		{59, "SHA1", "94287082"},
+		{59, "SHA256", "46119246"},
+		{59, "SHA512", "90693936"},
+		{1111111109, "SHA1", "07081804"},
+		{1111111111, "SHA256", "67062674"},
+		{1234567890, "SHA512", "93441116"},
+		{2000000000, "SHA1", "69279037"},
+		{20000000000, "SHA256", "77737706"},
+	}
+	for _, tc := range tests {
+		m := models.OTPModel{
+			Type:      TypeTOTP,
+			Secret:    base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte(secrets[tc.alg])),
+			Algorithm: tc.alg,
+			Digits:    8,
+			Period:    30,
+		}
+		code, err := Code(m, time.Unix(tc.unix, 0))
+		assert.NoError(t, err)
+		assert.Equal(t, tc.code, code, "%s at %d", tc.alg, tc.unix)
+	}
+}
+
+// Тестовые векторы RFC 4226, приложение D.
+func TestHOTPVectors(t *testing.T) {
+	secret := base32.StdEncoding.EncodeToString([]byte("12345678901234567890"))
+	want := []string{"755224", "287082", "359152", "969429", "338314", "254676", "287922", "162583", "399871", "520489"}
+	m, err := ParseURI("otpauth://hotp/test?counter=0&secret=" + secret)
+	require.NoError(t, err)
+	for i, code := range want {
+		m.Counter = uint64(i)
+		got, err := Code(m, time.Now())
+		assert.NoError(t, err)
+		assert.Equal(t, code, got)
+	}
+}
+
+func TestParseURI(t *testing.T) {
+	type want struct {
+		model models.OTPModel
+		err   error
+	}
+	type test struct {
+		name string
+		uri  string
+		want want
+	}
+	tests := []test{
+		{
+			name: "Test ParseURI function #1; Full totp uri",
+			uri:  "otpauth://totp/ACME%20Co:john.doe@email.com?secret=hxdmvjecjjwsrb3hwizr4ifugftmxboz&issuer=ACME%20Co&algorithm=SHA256&digits=8&period=60",
+			want: want{
+				model: models.OTPModel{
+					Type:      TypeTOTP,
+					Secret:    "HXDMVJECJJWSRB3HWIZR4IFUGFTMXBOZ",
+					Issuer:    "ACME Co",
+					Account:   "john.doe@email.com",
+					Algorithm: "SHA256",
+					Digits:    8,
+					Period:    60,
+				},
+			},
+		},
+		{
+			name: "Test ParseURI function #2; Defaults",
+			uri:  "otpauth://totp/alice?secret=JBSWY3DPEHPK3PXP",
+			want: want{
+				model: models.OTPModel{
+					Type:      TypeTOTP,
+					Secret:    "JBSWY3DPEHPK3PXP",
+					Account:   "alice",
+					Algorithm: DefaultAlgorithm,
+					Digits:    DefaultDigits,
+					Period:    DefaultPeriod,
+				},
+			},
+		},
+		{
+			name: "Test ParseURI function #3; Hotp without counter",
+			uri:  "otpauth://hotp/alice?secret=JBSWY3DPEHPK3PXP",
+			want: want{err: ErrInvalidURI},
+		},
+		{
+			name: "Test ParseURI function #4; Invalid secret",
+			uri:  "otpauth://totp/alice?secret=not-base32!",
+			want: want{err: ErrInvalidURI},
+		},
+		{
+			name: "Test ParseURI function #5; Unsupported algorithm",
+			uri:  "otpauth://totp/alice?secret=JBSWY3DPEHPK3PXP&algorithm=MD5",
+			want: want{err: ErrAlgorithm},
+		},
+		{
+			name: "Test ParseURI function #6; Wrong scheme",
+			uri:  "https://example.com/totp?secret=JBSWY3DPEHPK3PXP",
+			want: want{err: ErrInvalidURI},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			m, err := ParseURI(tc.uri)
+			assert.ErrorIs(t, err, tc.want.err)
+			assert.Equal(t, tc.want.model, m)
+			if tc.want.err == nil {
+				again, err := ParseURI(URI(m))
+				assert.NoError(t, err)
+				assert.Equal(t, m, again)
+			}
+		})
+	}
+}
+
+func TestRemaining(t *testing.T) {
+	m := models.OTPModel{Type: TypeTOTP, Period: 30}
+	assert.Equal(t, 30, Remaining(m, time.Unix(60, 0)))
+	assert.Equal(t, 1, Remaining(m, time.Unix(89, 0)))
+}
@@ -0,0 +1,311 @@
+// Package render форматирует сохраненные записи для вывода в терминал.
+// Номера карт, CVV и пароли по умолчанию маскируются и показываются только по явному запросу.
+package render
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Dorrrke/GophKeeper-client/internal/cardcheck"
+	"github.com/Dorrrke/GophKeeper-client/internal/domain/models"
+	"github.com/Dorrrke/GophKeeper-client/internal/folder"
+	"github.com/Dorrrke/GophKeeper-client/internal/hlc"
+)
+
+// Hidden заменяет скрытое значение. Длина маски не зависит от длины секрета.
+const Hidden = "•••••"
+
+// MaskPAN оставляет видимыми только последние четыре цифры номера карты.
+func MaskPAN(number string) string {
+	if len(number) < 8 {
+		return Hidden
+	}
+	return "**** **** **** " + number[len(number)-4:]
+}
+
+// Mask скрывает секрет целиком.
+func Mask(secret string) string {
+	if secret == "" {
+		return ""
+	}
+	return Hidden
+}
+
+// Card форматирует карту. Платежная система определяется по номеру, истекший срок действия помечается.
+func Card(card models.CardModel, reveal bool, now time.Time) string {
+	number, cvv := MaskPAN(card.Number), Mask(card.CVVCode)
+	if reveal {
+		number, cvv = card.Number, card.CVVCode
+	}
+	date := card.Date
+	if cardcheck.Expired(card.Date, now) {
+		date += " (срок действия истек)"
+	}
+	return fmt.Sprintf("\nCard name: %s \n\tBrand: %s \n\tNumber: %s \n\tDate: %s\n\tCVV: %s\n",
+		card.Name, cardcheck.Brand(card.Number), number, date, cvv) + Attrs(card.Folder, card.Tags, card.Meta)
+}
+
+// Cards форматирует список карт.
+func Cards(cards []models.CardModel, reveal bool, now time.Time) string {
+	var b strings.Builder
+	b.WriteString("Cards: ")
+	for _, card := range cards {
+		b.WriteString(Card(card, reveal, now))
+	}
+	return b.String()
+}
+
+// Login форматирует пару логин/пароль.
+func Login(login models.LoginModel, reveal bool) string {
+	password := Mask(login.Password)
+	if reveal {
+		password = login.Password
+	}
+	return fmt.Sprintf("\nLogin name: %s \n\tLogin: %s \n\tPassword: %s\n",
+		login.Name, login.Login, password) + Attrs(login.Folder, login.Tags, login.Meta)
+}
+
+// Logins форматирует список пар логин/пароль.
+func Logins(logins []models.LoginModel, reveal bool) string {
+	var b strings.Builder
+	b.WriteString("Logins: ")
+	for _, login := range logins {
+		b.WriteString(Login(login, reveal))
+	}
+	return b.String()
+}
+
+// Text форматирует текстовые данные.
+func Text(text models.TextDataModel) string {
+	return fmt.Sprintf("\nText name: %s \n\tData: %s\n", text.Name, text.Data) + Attrs(text.Folder, text.Tags, text.Meta)
+}
+
+// Texts форматирует список текстовых данных.
+func Texts(texts []models.TextDataModel) string {
+	var b strings.Builder
+	b.WriteString("Texts: ")
+	for _, text := range texts {
+		b.WriteString(Text(text))
+	}
+	return b.String()
+}
+
+// Bins форматирует список имен бинарных данных.
+func Bins(bins []models.BinaryDataModel) string {
+	var b strings.Builder
+	b.WriteString("Bin list: ")
+	for _, bin := range bins {
+		fmt.Fprintf(&b, "\n\tBin name: %s\n", bin.Name)
+		b.WriteString(Attrs(bin.Folder, bin.Tags, bin.Meta))
+	}
+	return b.String()
+}
+
+// OTPs форматирует список секретов одноразовых паролей. Сами секреты не выводятся.
+func OTPs(otps []models.OTPModel) string {
+	var b strings.Builder
+	b.WriteString("OTP: ")
+	for _, o := range otps {
+		fmt.Fprintf(&b, "\nOTP name: %s \n\tType: %s \n\tIssuer: %s \n\tAccount: %s\n",
+			o.Name, o.Type, o.Issuer, o.Account)
+		b.WriteString(Attrs(o.Folder, o.Tags, o.Meta))
+	}
+	return b.String()
+}
+
+// Attrs форматирует папку, теги и метаданные записи. Незаданные атрибуты не выводятся.
+func Attrs(folder string, tags []string, meta map[string]string) string {
+	var b strings.Builder
+	if folder != "" {
+		fmt.Fprintf(&b, "\tFolder: %s\n", folder)
+	}
+	if len(tags) != 0 {
+		fmt.Fprintf(&b, "\tTags: %s\n", strings.Join(tags, ", "))
+	}
+	b.WriteString(Meta(meta))
+	return b.String()
+}
+
+// Folders форматирует папки деревом. Пути должны быть отсортированы.
+func Folders(paths []string) string {
+	var b strings.Builder
+	b.WriteString("Folders: \n")
+	for _, path := range paths {
+		depth := strings.Count(path, folder.Separator)
+		fmt.Fprintf(&b, "\t%s%s\n", strings.Repeat("  ", depth), folder.Base(path))
+	}
+	return b.String()
+}
+
+// Meta форматирует метаданные записи в порядке ключей. Для записи без метаданных возвращает пустую строку.
+func Meta(meta map[string]string) string {
+	if len(meta) == 0 {
+		return ""
+	}
+	keys := make([]string, 0, len(meta))
+	for key := range meta {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	var b strings.Builder
+	b.WriteString("\tMeta:\n")
+	for _, key := range keys {
+		fmt.Fprintf(&b, "\t\t%s: %s\n", key, meta[key])
+	}
+	return b.String()
+}
+
+// AccessLog форматирует записи журнала доступа.
+func AccessLog(entries []models.AccessLogModel) string {
+	var b strings.Builder
+	b.WriteString("Access log: ")
+	for _, e := range entries {
+		fmt.Fprintf(&b, "\n\t%s  %s  %s %s", e.At.Local().Format(time.DateTime), e.Action, e.Kind, e.Name)
+	}
+	b.WriteString("\n")
+	return b.String()
+}
+
+// Trash отображает удаленные записи.
+func Trash(items []models.TrashModel) string {
+	var b strings.Builder
+	b.WriteString("Trash: ")
+	for _, item := range items {
+		fmt.Fprintf(&b, "\n\t%s  %s %s", item.Deleted.Local().Format(time.DateTime), item.Kind, item.Name)
+	}
+	b.WriteString("\n")
+	return b.String()
+}
+
+// History отображает список версий записи.
+func History(kind string, name string, versions []models.HistoryModel) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "History of %s %s: ", kind, name)
+	for _, v := range versions {
+		fmt.Fprintf(&b, "\n\tv%d  %s", v.Version, v.Created.Local().Format(time.DateTime))
+	}
+	b.WriteString("\n")
+	return b.String()
+}
+
+// Conflicts отображает неразрешенные конфликты синхронизации.
+func Conflicts(conflicts []models.ConflictModel) string {
+	var b strings.Builder
+	b.WriteString("Conflicts: ")
+	for _, c := range conflicts {
+		fmt.Fprintf(&b, "\n\t%s  %s %s", c.Detected.Local().Format(time.DateTime), c.Kind, c.Name)
+	}
+	b.WriteString("\n")
+	return b.String()
+}
+
+// ConflictDiff отображает различающиеся поля версий записи на устройстве и на сервере.
+// Значения секретов не выводятся, отмечается только их изменение.
+func ConflictDiff(conflict models.ConflictModel) string {
+	local, remote := newVersionFields(conflict.Local), newVersionFields(conflict.Remote)
+	var b strings.Builder
+	fmt.Fprintf(&b, "Conflict %s %s: local → remote\n", conflict.Kind, conflict.Name)
+	if local.deleted || remote.deleted {
+		fmt.Fprintf(&b, "\tDeleted: %t → %t\n", local.deleted, remote.deleted)
+		return b.String()
+	}
+	seen := make(map[string]bool)
+	for _, f := range append(local.order, remote.order...) {
+		if seen[f.name] {
+			continue
+		}
+		seen[f.name] = true
+		l, r := local.values[f.name], remote.values[f.name]
+		switch {
+		case l == r:
+		case f.secret:
+			fmt.Fprintf(&b, "\t%s: changed\n", f.name)
+		default:
+			fmt.Fprintf(&b, "\t%s: %s → %s\n", f.name, orDash(l), orDash(r))
+		}
+	}
+	return b.String()
+}
+
+// diffField - поле записи в сравнении версий.
+type diffField struct {
+	name   string
+	secret bool
+}
+
+// versionFields - поля версии записи в порядке вывода.
+type versionFields struct {
+	deleted bool
+	order   []diffField
+	values  map[string]string
+}
+
+func (v *versionFields) add(name string, value string, secret bool) {
+	v.order = append(v.order, diffField{name: name, secret: secret})
+	v.values[name] = value
+}
+
+// newVersionFields собирает поля единственной записи модели синхронизации.
+func newVersionFields(m models.SyncModel) versionFields {
+	v := versionFields{values: make(map[string]string)}
+	var updated hlc.Timestamp
+	var folder string
+	var tags []string
+	var meta map[string]string
+	switch {
+	case len(m.Cards) == 1:
+		c := m.Cards[0]
+		v.deleted, updated, folder, tags, meta = c.Deleted, c.Updated, c.Folder, c.Tags, c.Meta
+		v.add("Number", c.Number, true)
+		v.add("Date", c.Date, false)
+		v.add("CVV", c.CVVCode, true)
+	case len(m.Auth) == 1:
+		l := m.Auth[0]
+		v.deleted, updated, folder, tags, meta = l.Deleted, l.Updated, l.Folder, l.Tags, l.Meta
+		v.add("Login", l.Login, false)
+		v.add("Password", l.Password, true)
+	case len(m.Texts) == 1:
+		t := m.Texts[0]
+		v.deleted, updated, folder, tags, meta = t.Deleted, t.Updated, t.Folder, t.Tags, t.Meta
+		v.add("Data", t.Data, true)
+	case len(m.Bins) == 1:
+		bin := m.Bins[0]
+		v.deleted, updated, folder, tags, meta = bin.Deleted, bin.Updated, bin.Folder, bin.Tags, bin.Meta
+		v.add("Data", string(bin.Data), true)
+	case len(m.OTPs) == 1:
+		o := m.OTPs[0]
+		v.deleted, updated, folder, tags, meta = o.Deleted, o.Updated, o.Folder, o.Tags, o.Meta
+		v.add("Type", o.Type, false)
+		v.add("Secret", o.Secret, true)
+		v.add("Issuer", o.Issuer, false)
+		v.add("Account", o.Account, false)
+		v.add("Algorithm", o.Algorithm, false)
+		v.add("Digits", strconv.Itoa(o.Digits), false)
+		v.add("Period", strconv.Itoa(o.Period), false)
+		v.add("Counter", strconv.FormatUint(o.Counter, 10), false)
+	}
+	if !updated.IsZero() {
+		v.add("Updated", updated.Time().Local().Format(time.DateTime), false)
+	}
+	v.add("Folder", folder, false)
+	v.add("Tags", strings.Join(tags, ", "), false)
+	keys := make([]string, 0, len(meta))
+	for key := range meta {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		v.add("Meta."+key, meta[key], false)
+	}
+	return v
+}
+
+func orDash(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
+}
@@ -0,0 +1,96 @@
+package render
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Dorrrke/GophKeeper-client/internal/domain/models"
+	"github.com/Dorrrke/GophKeeper-client/internal/hlc"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMask(t *testing.T) {
+	assert.Equal(t, "**** **** **** 1111", MaskPAN("4111111111111111"))
+	assert.Equal(t, "**** **** **** 0005", MaskPAN("378282246310005"))
+	assert.Equal(t, Hidden, MaskPAN("123"))
+	assert.Equal(t, Hidden, Mask("a"))
+	assert.Equal(t, Hidden, Mask("a much longer password"))
+	assert.Equal(t, "", Mask(""))
+}
+
+func TestCard(t *testing.T) {
+	now := time.Date(2030, time.June, 1, 0, 0, 0, 0, time.UTC)
+	card := models.CardModel{Name: "visa", Number: "4111111111111111", Date: "09/33", CVVCode: "012"}
+
+	masked := Card(card, false, now)
+	assert.Contains(t, masked, "Brand: Visa")
+	assert.Contains(t, masked, "**** **** **** 1111")
+	assert.NotContains(t, masked, "4111111111111111")
+	assert.NotContains(t, masked, "012")
+
+	revealed := Card(card, true, now)
+	assert.Contains(t, revealed, "4111111111111111")
+	assert.Contains(t, revealed, "CVV: 012")
+
+	card.Date = "01/30"
+	assert.Contains(t, Card(card, false, now), "срок действия истек")
+}
+
+func TestLogins(t *testing.T) {
+	logins := []models.LoginModel{
+		{Name: "mail", Login: "bob", Password: "hunter2"},
+		{Name: "bank", Login: "alice", Password: "correct horse"},
+	}
+	masked := Logins(logins, false)
+	assert.Contains(t, masked, "Login: bob")
+	assert.NotContains(t, masked, "hunter2")
+	assert.NotContains(t, masked, "correct horse")
+
+	revealed := Logins(logins, true)
+	assert.Contains(t, revealed, "Password: hunter2")
+	assert.Contains(t, revealed, "Password: correct horse")
+}
+
+func TestMeta(t *testing.T) {
+	assert.Equal(t, "", Meta(nil))
+	assert.Equal(t, "\tMeta:\n\t\tbank: Sber\n\t\turl: https://sber.ru\n",
+		Meta(map[string]string{"url": "https://sber.ru", "bank": "Sber"}))
+
+	text := Text(models.TextDataModel{Name: "note", Data: "data", Meta: map[string]string{"tag": "home"}})
+	assert.Contains(t, text, "tag: home")
+}
+
+func TestAttrs(t *testing.T) {
+	assert.Equal(t, "", Attrs("", nil, nil))
+	assert.Equal(t, "\tFolder: Work/Banks\n\tTags: salary, visa\n\tMeta:\n\t\tbank: Sber\n",
+		Attrs("Work/Banks", []string{"salary", "visa"}, map[string]string{"bank": "Sber"}))
+	assert.Equal(t, "Folders: \n\tWork\n\t  Banks\n\t    Cards\n\tHome\n",
+		Folders([]string{"Work", "Work/Banks", "Work/Banks/Cards", "Home"}))
+}
+
+func TestConflictDiff(t *testing.T) {
+	conflict := models.ConflictModel{Kind: "auth", Name: "github",
+		Local: models.SyncModel{Auth: []models.SyncLoginModel{{Name: "github", Login: "gopher", Password: "local-secret",
+			Updated: hlc.MustParse("2024-01-02T00:00:00Z"), Tags: []string{"work"}}}},
+		Remote: models.SyncModel{Auth: []models.SyncLoginModel{{Name: "github", Login: "gopher", Password: "remote-secret",
+			Updated: hlc.MustParse("2024-01-03T00:00:00Z"), Meta: map[string]string{"url": "github.com"}}}},
+	}
+	diff := ConflictDiff(conflict)
+	assert.Contains(t, diff, "Password: changed")
+	assert.Contains(t, diff, "Tags: work → -")
+	assert.Contains(t, diff, "Meta.url: - → github.com")
+	assert.NotContains(t, diff, "Login:")
+	assert.NotContains(t, diff, "secret")
+
+	conflict.Remote.Auth[0].Deleted = true
+	assert.Contains(t, ConflictDiff(conflict), "Deleted: false → true")
+
+	otp := models.ConflictModel{Kind: "otp", Name: "github",
+		Local:  models.SyncModel{OTPs: []models.SyncOTPModel{{Name: "github", Type: "hotp", Secret: "local-secret", Counter: 3}}},
+		Remote: models.SyncModel{OTPs: []models.SyncOTPModel{{Name: "github", Type: "hotp", Secret: "remote-secret", Counter: 5}}},
+	}
+	diff = ConflictDiff(otp)
+	assert.Contains(t, diff, "Counter: 3 → 5")
+	assert.Contains(t, diff, "Secret: changed")
+	assert.NotContains(t, diff, "secret")
+}
@@ -0,0 +1,86 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	errText "github.com/Dorrrke/GophKeeper-client/internal/domain/errors"
+	"github.com/Dorrrke/GophKeeper-client/internal/domain/models"
+)
+
+var ErrLoginDelayed = errors.New(errText.LoginDelayedError)
+
+// LoginPolicy - ограничение частоты попыток входа.
+type LoginPolicy struct {
+	// BaseDelay - задержка после первой неудачной попытки. Каждая следующая неудача удваивает задержку.
+	BaseDelay time.Duration
+	// MaxDelay - максимальная задержка между попытками.
+	MaxDelay time.Duration
+	// LockoutAfter - число неудачных попыток подряд, после которого вход блокируется. 0 - без блокировки.
+	LockoutAfter int
+	// LockoutCooldown - время блокировки входа.
+	LockoutCooldown time.Duration
+}
+
+// DefaultLoginPolicy - ограничение попыток входа по умолчанию.
+var DefaultLoginPolicy = LoginPolicy{
+	BaseDelay:       time.Second,
+	MaxDelay:        5 * time.Minute,
+	LockoutAfter:    0,
+	LockoutCooldown: time.Hour,
+}
+
+// LoginDelayError сообщает, через сколько можно повторить попытку входа.
+type LoginDelayError struct {
+	// Err - ErrInvalidPassword для неудачной попытки или ErrLoginDelayed, если попытка сделана раньше времени.
+	Err    error
+	Delay  time.Duration
+	Locked bool
+}
+
+func (e *LoginDelayError) Error() string {
+	return fmt.Sprintf("%s: retry in %s", e.Err.Error(), e.Delay.Round(time.Second))
+}
+
+func (e *LoginDelayError) Unwrap() error {
+	return e.Err
+}
+
+// delay возвращает задержку после заданного числа неудачных попыток подряд.
+func (p LoginPolicy) delay(failures int) time.Duration {
+	if failures <= 0 {
+		return 0
+	}
+	delay := p.BaseDelay
+	for i := 1; i < failures && delay < p.MaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > p.MaxDelay {
+		return p.MaxDelay
+	}
+	return delay
+}
+
+// wait возвращает оставшееся время до следующей разрешенной попытки входа.
+func (p LoginPolicy) wait(attempts models.LoginAttemptsModel, now time.Time) (time.Duration, bool) {
+	if now.Before(attempts.LockedUntil) {
+		return attempts.LockedUntil.Sub(now), true
+	}
+	next := attempts.LastFailure.Add(p.delay(attempts.Failures))
+	if attempts.Failures > 0 && now.Before(next) {
+		return next.Sub(now), false
+	}
+	return 0, false
+}
+
+// fail учитывает неудачную попытку входа и блокирует вход после LockoutAfter неудач подряд.
+func (p LoginPolicy) fail(attempts models.LoginAttemptsModel, now time.Time) models.LoginAttemptsModel {
+	attempts.Failures++
+	attempts.LastFailure = now
+	if p.LockoutAfter > 0 && attempts.Failures >= p.LockoutAfter {
+		attempts.Failures = 0
+		attempts.LockedUntil = now.Add(p.LockoutCooldown)
+	}
+	return attempts
+}
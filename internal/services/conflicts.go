@@ -0,0 +1,322 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+
+	errText "github.com/Dorrrke/GophKeeper-client/internal/domain/errors"
+	"github.com/Dorrrke/GophKeeper-client/internal/domain/models"
+	"github.com/Dorrrke/GophKeeper-client/internal/hlc"
+)
+
+var (
+	ErrConflictNotExist = errors.New(errText.ConflictNotExistError)
+	ErrConflictPolicy   = errors.New(errText.ConflictPolicyError)
+)
+
+// ConflictResolution - способ разрешения конфликта синхронизации.
+type ConflictResolution string
+
+const (
+	// KeepLocal оставляет версию с устройства, при синхронизации она заменит версию на сервере.
+	KeepLocal ConflictResolution = "local"
+	// KeepRemote заменяет запись версией с сервера.
+	KeepRemote ConflictResolution = "remote"
+	// KeepBoth принимает версию с сервера, а версию с устройства сохраняет копией под новым именем.
+	KeepBoth ConflictResolution = "both"
+	// ResolveLater откладывает конфликт. До его разрешения запись не отправляется на сервер.
+	ResolveLater ConflictResolution = "skip"
+)
+
+// ConflictResolver выбирает способ разрешения конфликта.
+type ConflictResolver func(conflict models.ConflictModel) (ConflictResolution, error)
+
+// ParseConflictResolution разбирает способ разрешения конфликта из настроек или флага.
+func ParseConflictResolution(value string) (ConflictResolution, error) {
+	switch res := ConflictResolution(value); res {
+	case KeepLocal, KeepRemote, KeepBoth, ResolveLater:
+		return res, nil
+	}
+	return "", ErrConflictPolicy
+}
+
+// PolicyResolver разрешает все конфликты одним способом.
+func PolicyResolver(res ConflictResolution) ConflictResolver {
+	return func(models.ConflictModel) (ConflictResolution, error) {
+		return res, nil
+	}
+}
+
+// SetConflictResolver задает способ разрешения конфликтов при синхронизации.
+// Без него конфликты откладываются до команды conflicts resolve.
+func (kp *KeepService) SetConflictResolver(resolver ConflictResolver) {
+	kp.resolver = resolver
+}
+
+// Conflicts возвращает отложенные конфликты вместе с текущими версиями записей на устройстве.
+func (kp *KeepService) Conflicts(uID int64) ([]models.ConflictModel, error) {
+	conflicts, err := kp.stor.GetConflicts(context.Background(), uID)
+	if err != nil || len(conflicts) == 0 {
+		return conflicts, err
+	}
+	local, err := kp.stor.GetChangedSaves(context.Background(), uID)
+	if err != nil {
+		return nil, err
+	}
+	records := make(map[string]syncRecord)
+	for _, r := range splitRecords(local) {
+		records[recordKey(r.kind, r.uuid)] = r
+	}
+	for i := range conflicts {
+		if r, ok := records[recordKey(conflicts[i].Kind, conflicts[i].UUID)]; ok {
+			conflicts[i].Name, conflicts[i].Local = r.name, r.model
+		}
+	}
+	return conflicts, nil
+}
+
+// ResolveConflict разрешает отложенный конфликт записи. Результат уйдет на сервер при следующей синхронизации.
+func (kp *KeepService) ResolveConflict(kind string, name string, res ConflictResolution, uID int64) error {
+	if !identifiedKind(kind) {
+		return ErrUnknownKind
+	}
+	if res == ResolveLater {
+		return ErrConflictPolicy
+	}
+	conflicts, err := kp.Conflicts(uID)
+	if err != nil {
+		return err
+	}
+	for _, conflict := range conflicts {
+		if conflict.Kind == kind && conflict.Name == name {
+			return kp.applyResolution(context.Background(), conflict, res, uID)
+		}
+	}
+	return ErrConflictNotExist
+}
+
+// applyResolution применяет выбранный способ разрешения конфликта.
+func (kp *KeepService) applyResolution(ctx context.Context, conflict models.ConflictModel, res ConflictResolution, uID int64) error {
+	remote := splitRecords(conflict.Remote)
+	if len(remote) != 1 {
+		return fmt.Errorf("conflict %s %s has no remote version", conflict.Kind, conflict.Name)
+	}
+	switch res {
+	case ResolveLater:
+		return kp.stor.SaveConflict(ctx, conflict, uID)
+	case KeepLocal:
+		if err := kp.stor.RebaseRecord(ctx, conflict.Kind, conflict.UUID, remote[0].updated, uID); err != nil {
+			return err
+		}
+	case KeepBoth:
+		if err := kp.saveLocalCopy(ctx, conflict, uID); err != nil {
+			return err
+		}
+		fallthrough
+	case KeepRemote:
+		if err := kp.stor.Sync(ctx, conflict.Remote); err != nil {
+			return err
+		}
+	default:
+		return ErrConflictPolicy
+	}
+	return kp.stor.DeleteConflict(ctx, conflict.Kind, conflict.UUID, uID)
+}
+
+// saveLocalCopy сохраняет версию записи с устройства как новую запись. Удаленная на устройстве запись не копируется.
+func (kp *KeepService) saveLocalCopy(ctx context.Context, conflict models.ConflictModel, uID int64) error {
+	name := fmt.Sprintf("%s (копия от %s)", conflict.Name, time.Now().Format(time.DateTime))
+	local := conflict.Local
+	var err error
+	switch {
+	case len(local.Cards) == 1 && !local.Cards[0].Deleted:
+		c := local.Cards[0]
+		_, err = kp.cardStor.SaveCard(ctx, models.CardModel{Name: name, Number: c.Number, Date: c.Date, CVVCode: c.CVVCode,
+			Meta: c.Meta, Folder: c.Folder, Tags: c.Tags}, uID)
+	case len(local.Auth) == 1 && !local.Auth[0].Deleted:
+		l := local.Auth[0]
+		_, err = kp.authStor.SaveLogin(ctx, models.LoginModel{Name: name, Login: l.Login, Password: l.Password,
+			Meta: l.Meta, Folder: l.Folder, Tags: l.Tags}, uID)
+	case len(local.Texts) == 1 && !local.Texts[0].Deleted:
+		t := local.Texts[0]
+		_, err = kp.textStor.SaveText(ctx, models.TextDataModel{Name: name, Data: t.Data,
+			Meta: t.Meta, Folder: t.Folder, Tags: t.Tags}, uID)
+	case len(local.Bins) == 1 && !local.Bins[0].Deleted:
+		b := local.Bins[0]
+		_, err = kp.binStor.SaveBin(ctx, models.BinaryDataModel{Name: name, Data: b.Data,
+			Meta: b.Meta, Folder: b.Folder, Tags: b.Tags}, uID)
+	case len(local.OTPs) == 1 && !local.OTPs[0].Deleted:
+		o := local.OTPs[0]
+		_, err = kp.otpStor.SaveOTP(ctx, models.OTPModel{Name: name, Type: o.Type, Secret: o.Secret, Issuer: o.Issuer,
+			Account: o.Account, Algorithm: o.Algorithm, Digits: o.Digits, Period: o.Period, Counter: o.Counter,
+			Meta: o.Meta, Folder: o.Folder, Tags: o.Tags}, uID)
+	}
+	return err
+}
+
+// syncRecord - запись одного из видов с идентификатором в виде модели синхронизации из одной этой записи.
+type syncRecord struct {
+	kind    string
+	name    string
+	uuid    string
+	base    hlc.Timestamp
+	updated hlc.Timestamp
+	deleted bool
+	model   models.SyncModel
+}
+
+// splitRecords разбивает модель синхронизации на отдельные записи с идентификаторами.
+// Папки в конфликтах не участвуют и не возвращаются.
+func splitRecords(m models.SyncModel) []syncRecord {
+	var records []syncRecord
+	for _, d := range m.Cards {
+		records = append(records, syncRecord{kind: KindCard, name: d.Name, uuid: d.UUID, base: d.Base, updated: d.Updated,
+			deleted: d.Deleted, model: models.SyncModel{Cards: []models.SyncCardModel{d}}})
+	}
+	for _, d := range m.Auth {
+		records = append(records, syncRecord{kind: KindLogin, name: d.Name, uuid: d.UUID, base: d.Base, updated: d.Updated,
+			deleted: d.Deleted, model: models.SyncModel{Auth: []models.SyncLoginModel{d}}})
+	}
+	for _, d := range m.Texts {
+		records = append(records, syncRecord{kind: KindText, name: d.Name, uuid: d.UUID, base: d.Base, updated: d.Updated,
+			deleted: d.Deleted, model: models.SyncModel{Texts: []models.SyncTextDataModel{d}}})
+	}
+	for _, d := range m.Bins {
+		records = append(records, syncRecord{kind: KindBin, name: d.Name, uuid: d.UUID, base: d.Base, updated: d.Updated,
+			deleted: d.Deleted, model: models.SyncModel{Bins: []models.SyncBinaryDataModel{d}}})
+	}
+	for _, d := range m.OTPs {
+		records = append(records, syncRecord{kind: KindOTP, name: d.Name, uuid: d.UUID, base: d.Base, updated: d.Updated,
+			deleted: d.Deleted, model: models.SyncModel{OTPs: []models.SyncOTPModel{d}}})
+	}
+	return records
+}
+
+// appendSync добавляет записи src к dst.
+func appendSync(dst *models.SyncModel, src models.SyncModel) {
+	dst.Cards = append(dst.Cards, src.Cards...)
+	dst.Auth = append(dst.Auth, src.Auth...)
+	dst.Texts = append(dst.Texts, src.Texts...)
+	dst.Bins = append(dst.Bins, src.Bins...)
+	dst.OTPs = append(dst.OTPs, src.OTPs...)
+	dst.Folders = append(dst.Folders, src.Folders...)
+}
+
+func emptySync(m models.SyncModel) bool {
+	return len(m.Cards) == 0 && len(m.Auth) == 0 && len(m.Texts) == 0 && len(m.Bins) == 0 &&
+		len(m.OTPs) == 0 && len(m.Folders) == 0
+}
+
+func recordKey(kind string, uuid string) string {
+	return kind + "/" + uuid
+}
+
+// withoutRecords возвращает модель без записей с ключами из skip.
+func withoutRecords(m models.SyncModel, skip map[string]bool) models.SyncModel {
+	if len(skip) == 0 {
+		return m
+	}
+	res := models.SyncModel{Folders: m.Folders}
+	for _, r := range splitRecords(m) {
+		if !skip[recordKey(r.kind, r.uuid)] {
+			appendSync(&res, r.model)
+		}
+	}
+	return res
+}
+
+// detectConflicts отделяет записи с сервера, измененные одновременно с еще не отправленными локальными изменениями.
+// Записи, версия которых на сервере не менялась с последней синхронизации, не возвращаются в rest,
+// чтобы не затереть локальные изменения. Новые версии записей с отложенными конфликтами снова считаются конфликтами.
+func detectConflicts(local models.SyncModel, remote models.SyncModel, pending map[string]bool) ([]models.ConflictModel, models.SyncModel) {
+	byUUID := make(map[string]syncRecord)
+	byName := make(map[string]syncRecord)
+	for _, r := range splitRecords(local) {
+		// Прежние имена переименованных записей идентификатора не имеют.
+		if r.uuid == "" {
+			continue
+		}
+		byUUID[recordKey(r.kind, r.uuid)] = r
+		byName[recordKey(r.kind, r.name)] = r
+	}
+	var conflicts []models.ConflictModel
+	rest := models.SyncModel{Folders: remote.Folders}
+	now := time.Now()
+	for _, r := range splitRecords(remote) {
+		l, ok := byUUID[recordKey(r.kind, r.uuid)]
+		if r.uuid == "" {
+			l, ok = byName[recordKey(r.kind, r.name)]
+		}
+		switch {
+		case !ok:
+			appendSync(&rest, r.model)
+		case !pending[recordKey(l.kind, l.uuid)] && sameContent(l, r):
+			appendSync(&rest, r.model)
+		case !pending[recordKey(l.kind, l.uuid)] && l.base == r.updated:
+		default:
+			conflicts = append(conflicts, models.ConflictModel{
+				Kind: l.kind, Name: l.name, UUID: l.uuid, Local: l.model, Remote: r.model, Detected: now,
+			})
+		}
+	}
+	return conflicts, rest
+}
+
+// sameContent сравнивает содержимое двух версий записи без учета служебных полей.
+func sameContent(a, b syncRecord) bool {
+	if a.deleted && b.deleted {
+		return true
+	}
+	return reflect.DeepEqual(contentOf(a.model), contentOf(b.model))
+}
+
+// contentOf очищает служебные поля записей и приводит пустые атрибуты к nil.
+func contentOf(m models.SyncModel) models.SyncModel {
+	var res models.SyncModel
+	for _, d := range m.Cards {
+		d.UserID, d.UUID, d.Updated, d.Base = 0, "", hlc.Timestamp{}, hlc.Timestamp{}
+		d.Meta, d.Tags = emptyMeta(d.Meta), emptyTags(d.Tags)
+		res.Cards = append(res.Cards, d)
+	}
+	for _, d := range m.Auth {
+		d.UserID, d.UUID, d.Updated, d.Base = 0, "", hlc.Timestamp{}, hlc.Timestamp{}
+		d.Meta, d.Tags = emptyMeta(d.Meta), emptyTags(d.Tags)
+		res.Auth = append(res.Auth, d)
+	}
+	for _, d := range m.Texts {
+		d.UserID, d.UUID, d.Updated, d.Base = 0, "", hlc.Timestamp{}, hlc.Timestamp{}
+		d.Meta, d.Tags = emptyMeta(d.Meta), emptyTags(d.Tags)
+		res.Texts = append(res.Texts, d)
+	}
+	for _, d := range m.Bins {
+		d.UserID, d.UUID, d.Updated, d.Base = 0, "", hlc.Timestamp{}, hlc.Timestamp{}
+		d.Meta, d.Tags = emptyMeta(d.Meta), emptyTags(d.Tags)
+		if len(d.Data) == 0 {
+			d.Data = nil
+		}
+		res.Bins = append(res.Bins, d)
+	}
+	for _, d := range m.OTPs {
+		d.UserID, d.UUID, d.Updated, d.Base = 0, "", hlc.Timestamp{}, hlc.Timestamp{}
+		d.Meta, d.Tags = emptyMeta(d.Meta), emptyTags(d.Tags)
+		res.OTPs = append(res.OTPs, d)
+	}
+	return res
+}
+
+func emptyMeta(meta map[string]string) map[string]string {
+	if len(meta) == 0 {
+		return nil
+	}
+	return meta
+}
+
+func emptyTags(tags []string) []string {
+	if len(tags) == 0 {
+		return nil
+	}
+	return tags
+}
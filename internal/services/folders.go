@@ -0,0 +1,102 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/Dorrrke/GophKeeper-client/internal/folder"
+)
+
+// CreateFolder создает папку. Недостающие родительские папки создаются автоматически.
+func (kp *KeepService) CreateFolder(path string, uID int64) error {
+	path, err := folder.Normalize(path)
+	if err != nil {
+		return err
+	}
+	if path == "" {
+		return fmt.Errorf("%w: %s", folder.ErrInvalid, "root folder already exists")
+	}
+	return kp.stor.CreateFolder(context.Background(), path, uID)
+}
+
+// Folders возвращает пути всех папок пользователя.
+func (kp *KeepService) Folders(uID int64) ([]string, error) {
+	return kp.stor.GetFolders(context.Background(), uID)
+}
+
+// RenameFolder меняет имя папки, оставляя ее в прежней родительской папке.
+func (kp *KeepService) RenameFolder(path string, name string, uID int64) error {
+	path, err := folder.Normalize(path)
+	if err != nil {
+		return err
+	}
+	name = strings.TrimSpace(name)
+	if path == "" || name == "" || strings.Contains(name, folder.Separator) {
+		return fmt.Errorf("%w: %q", folder.ErrInvalid, name)
+	}
+	return kp.moveFolder(path, folder.Join(folder.Parent(path), name), uID)
+}
+
+// MoveFolder переносит папку со всем содержимым в другую родительскую папку. Пустой путь - корень.
+func (kp *KeepService) MoveFolder(path string, parent string, uID int64) error {
+	path, err := folder.Normalize(path)
+	if err != nil {
+		return err
+	}
+	parent, err = folder.Normalize(parent)
+	if err != nil {
+		return err
+	}
+	if path == "" {
+		return fmt.Errorf("%w: %s", folder.ErrInvalid, "root folder cannot be moved")
+	}
+	if folder.Contains(path, parent) {
+		return folder.ErrCycle
+	}
+	return kp.moveFolder(path, folder.Join(parent, folder.Base(path)), uID)
+}
+
+func (kp *KeepService) moveFolder(from string, to string, uID int64) error {
+	if from == to {
+		return nil
+	}
+	return kp.stor.MoveFolder(context.Background(), from, to, uID)
+}
+
+// RecordFilter отбирает записи по папке и тегам.
+type RecordFilter struct {
+	// Folder - папка, записи которой нужны, вместе с вложенными папками. Пустая строка - без фильтра.
+	Folder string
+	// Tags - теги, которые должны быть у записи одновременно.
+	Tags []string
+}
+
+// NewRecordFilter проверяет путь папки и собирает фильтр.
+func NewRecordFilter(path string, tags []string) (RecordFilter, error) {
+	path, err := folder.Normalize(path)
+	if err != nil {
+		return RecordFilter{}, err
+	}
+	return RecordFilter{Folder: path, Tags: tags}, nil
+}
+
+// Match сообщает, подходит ли под фильтр запись с указанными папкой и тегами.
+func (f RecordFilter) Match(path string, tags []string) bool {
+	if !folder.Contains(f.Folder, path) {
+		return false
+	}
+	for _, want := range f.Tags {
+		found := false
+		for _, tag := range tags {
+			if tag == want {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,148 @@
+package services
+
+import (
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/Dorrrke/GophKeeper-client/internal/coder"
+	"github.com/Dorrrke/GophKeeper-client/internal/config"
+	errText "github.com/Dorrrke/GophKeeper-client/internal/domain/errors"
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var (
+	ErrHashAlgorithm = errors.New(errText.HashAlgorithmError)
+	ErrInvalidHash   = errors.New(errText.InvalidHashError)
+	ErrBcryptCost    = errors.New(errText.BcryptCostError)
+)
+
+// Алгоритмы хеширования пароля пользователя.
+const (
+	HashArgon2id = "argon2id"
+	HashBcrypt   = "bcrypt"
+)
+
+const (
+	argon2Prefix  = "$argon2id$"
+	argon2HashLen = 32
+)
+
+// Hasher хеширует пароль пользователя для хранения в таблице users.
+// Алгоритм и параметры записываются в саму строку хеша.
+type Hasher interface {
+	Hash(pass string) (string, error)
+	// NeedsRehash сообщает, что хеш получен другим алгоритмом или с другими параметрами.
+	NeedsRehash(hash string) bool
+}
+
+// NewHasher создает хешер по настройкам клиента.
+func NewHasher(cfg config.HashConfig) (Hasher, error) {
+	switch cfg.Algorithm {
+	case HashArgon2id:
+		params := coder.DefaultKDFParams
+		if cfg.Argon2Params != "" {
+			var err error
+			params, err = coder.ParseKDFParams(cfg.Argon2Params)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return Argon2Hasher{Params: params}, nil
+	case HashBcrypt:
+		if cfg.BcryptCost < bcrypt.MinCost || cfg.BcryptCost > bcrypt.MaxCost {
+			return nil, fmt.Errorf("%w: %d", ErrBcryptCost, cfg.BcryptCost)
+		}
+		return BcryptHasher{Cost: cfg.BcryptCost}, nil
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrHashAlgorithm, cfg.Algorithm)
+	}
+}
+
+// BcryptHasher - хеширование bcrypt с заданной стоимостью.
+type BcryptHasher struct {
+	Cost int
+}
+
+func (h BcryptHasher) Hash(pass string) (string, error) {
+	hashedPass, err := bcrypt.GenerateFromPassword([]byte(pass), h.Cost)
+	if err != nil {
+		return "", err
+	}
+	return string(hashedPass), nil
+}
+
+func (h BcryptHasher) NeedsRehash(hash string) bool {
+	cost, err := bcrypt.Cost([]byte(hash))
+	if err != nil {
+		return true
+	}
+	return cost != h.Cost
+}
+
+// Argon2Hasher - хеширование Argon2id. Хеш записывается в формате PHC:
+// $argon2id$v=19$m=65536,t=3,p=4$<соль>$<хеш>.
+type Argon2Hasher struct {
+	Params coder.KDFParams
+}
+
+func (h Argon2Hasher) Hash(pass string) (string, error) {
+	salt, err := coder.NewSalt()
+	if err != nil {
+		return "", err
+	}
+	key := argon2.IDKey([]byte(pass), salt, h.Params.Time, h.Params.Memory, h.Params.Threads, argon2HashLen)
+	return fmt.Sprintf("%sv=%d$%s$%s$%s", argon2Prefix, argon2.Version, h.Params.String(),
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key)), nil
+}
+
+func (h Argon2Hasher) NeedsRehash(hash string) bool {
+	params, _, _, err := parseArgon2Hash(hash)
+	if err != nil {
+		return true
+	}
+	return params != h.Params
+}
+
+// parseArgon2Hash разбирает хеш Argon2id в формате PHC.
+func parseArgon2Hash(hash string) (coder.KDFParams, []byte, []byte, error) {
+	parts := strings.Split(strings.TrimPrefix(hash, argon2Prefix), "$")
+	if !strings.HasPrefix(hash, argon2Prefix) || len(parts) != 4 {
+		return coder.KDFParams{}, nil, nil, ErrInvalidHash
+	}
+	var version int
+	if _, err := fmt.Sscanf(parts[0], "v=%d", &version); err != nil || version != argon2.Version {
+		return coder.KDFParams{}, nil, nil, ErrInvalidHash
+	}
+	params, err := coder.ParseKDFParams(parts[1])
+	if err != nil {
+		return coder.KDFParams{}, nil, nil, err
+	}
+	salt, err := base64.RawStdEncoding.DecodeString(parts[2])
+	if err != nil {
+		return coder.KDFParams{}, nil, nil, ErrInvalidHash
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[3])
+	if err != nil || len(key) == 0 {
+		return coder.KDFParams{}, nil, nil, ErrInvalidHash
+	}
+	return params, salt, key, nil
+}
+
+// matchPass сверяет пароль с хешем из базы данных. Алгоритм определяется по формату хеша,
+// поэтому проверяются и хеши, созданные до смены настроек.
+func matchPass(pass string, hashFromDB string) bool {
+	if strings.HasPrefix(hashFromDB, argon2Prefix) {
+		params, salt, key, err := parseArgon2Hash(hashFromDB)
+		if err != nil {
+			return false
+		}
+		got := argon2.IDKey([]byte(pass), salt, params.Time, params.Memory, params.Threads, uint32(len(key)))
+		return subtle.ConstantTimeCompare(got, key) == 1
+	}
+	err := bcrypt.CompareHashAndPassword([]byte(hashFromDB), []byte(pass))
+	return err == nil
+}
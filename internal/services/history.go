@@ -0,0 +1,108 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	errText "github.com/Dorrrke/GophKeeper-client/internal/domain/errors"
+	"github.com/Dorrrke/GophKeeper-client/internal/domain/models"
+)
+
+var ErrUnknownKind = errors.New(errText.UnknownKindError)
+
+// Виды записей, для которых хранится история версий и корзина.
+const (
+	KindCard  = AccessKindCard
+	KindLogin = AccessKindLogin
+	KindText  = "text"
+	KindBin   = "bin"
+)
+
+// History возвращает сохраненные предыдущие версии записи, начиная с самой новой.
+func (kp *KeepService) History(kind string, name string, uID int64) ([]models.HistoryModel, error) {
+	if !knownKind(kind) {
+		return nil, ErrUnknownKind
+	}
+	return kp.stor.GetHistory(context.Background(), kind, name, uID)
+}
+
+// RestoreVersion возвращает записи содержимое указанной версии.
+// Текущее содержимое при этом само сохраняется в историю, поэтому восстановление можно отменить.
+// Версия записывается как есть, без проверки ввода: она могла быть сохранена до появления проверок.
+func (kp *KeepService) RestoreVersion(kind string, name string, version int, uID int64) error {
+	if !knownKind(kind) {
+		return ErrUnknownKind
+	}
+	ctx := context.Background()
+	prev, err := kp.stor.GetHistoryVersion(ctx, kind, name, version, uID)
+	if err != nil {
+		return err
+	}
+	switch kind {
+	case KindCard:
+		var card models.CardModel
+		if err := json.Unmarshal(prev.Data, &card); err != nil {
+			return err
+		}
+		card.Name = name
+		card.Meta, card.Folder, card.Tags = restoredAttrs(card.Meta, card.Folder, card.Tags)
+		return kp.cardStor.UpdateCard(ctx, card, uID)
+	case KindLogin:
+		var auth models.LoginModel
+		if err := json.Unmarshal(prev.Data, &auth); err != nil {
+			return err
+		}
+		auth.Name = name
+		auth.Meta, auth.Folder, auth.Tags = restoredAttrs(auth.Meta, auth.Folder, auth.Tags)
+		return kp.authStor.UpdateLogin(ctx, auth, uID)
+	case KindText:
+		var text models.TextDataModel
+		if err := json.Unmarshal(prev.Data, &text); err != nil {
+			return err
+		}
+		text.Name = name
+		text.Meta, text.Folder, text.Tags = restoredAttrs(text.Meta, text.Folder, text.Tags)
+		return kp.textStor.UpdateText(ctx, text, uID)
+	default:
+		var bin models.BinaryDataModel
+		if err := json.Unmarshal(prev.Data, &bin); err != nil {
+			return err
+		}
+		bin.Name = name
+		bin.Meta, bin.Folder, bin.Tags = restoredAttrs(bin.Meta, bin.Folder, bin.Tags)
+		return kp.binStor.UpdateBin(ctx, bin, uID)
+	}
+}
+
+func knownKind(kind string) bool {
+	switch kind {
+	case KindCard, KindLogin, KindText, KindBin:
+		return true
+	}
+	return false
+}
+
+// KindOTP - вид записей OTP. История и корзина для них не хранятся,
+// но, как и записи других видов, они имеют идентификатор: их можно переименовать, и они участвуют в конфликтах.
+const KindOTP = "otp"
+
+// identifiedKind сообщает, сопоставляются ли записи вида kind при синхронизации по идентификатору.
+func identifiedKind(kind string) bool {
+	return knownKind(kind) || kind == KindOTP
+}
+
+// restoredAttrs дополняет атрибуты версии так, чтобы обновление заменило их целиком:
+// пустые метаданные и теги удаляют текущие, пустая папка переносит запись в корень.
+func restoredAttrs(meta map[string]string, path string, tags []string) (map[string]string, string, []string) {
+	if meta == nil {
+		meta = map[string]string{}
+	}
+	if path == "" {
+		path = models.RootFolder
+	}
+	if tags == nil {
+		tags = []string{}
+	}
+	return meta, path, tags
+}
@@ -3,23 +3,57 @@ package services
 import (
 	"context"
 	"errors"
+	"time"
 
+	"github.com/Dorrrke/GophKeeper-client/internal/audit"
+	"github.com/Dorrrke/GophKeeper-client/internal/cardcheck"
+	"github.com/Dorrrke/GophKeeper-client/internal/coder"
 	errText "github.com/Dorrrke/GophKeeper-client/internal/domain/errors"
 	"github.com/Dorrrke/GophKeeper-client/internal/domain/models"
-	"golang.org/x/crypto/bcrypt"
+	"github.com/Dorrrke/GophKeeper-client/internal/hlc"
+	"github.com/Dorrrke/GophKeeper-client/internal/otp"
+	"github.com/Dorrrke/GophKeeper-client/internal/storage"
 )
 
 var ErrInvalidPassword = errors.New(errText.InvalidPasswordError)
 
+// SessionTTL - максимальное время жизни сессии, после которого нужно снова войти в систему.
+const SessionTTL = 12 * time.Hour
+
 type Storage interface {
 	Sync(ctx context.Context, model models.SyncModel) error
-	ClearDB(ctx context.Context, uId int64) error
-	GetAllSaves(ctx context.Context, uID int64) (models.SyncModel, error)
+	ClearDB(ctx context.Context, uId int64, before time.Time) error
+	GetChangedSaves(ctx context.Context, uID int64) (models.SyncModel, error)
+	MarkSynced(ctx context.Context, pushed models.SyncModel, uID int64) error
+	SetVaultKey(key []byte) error
+	EncryptExisting(ctx context.Context, uID int64) error
+	LogAccess(ctx context.Context, entry models.AccessLogModel, uID int64) error
+	GetAccessLog(ctx context.Context, uID int64, limit int) ([]models.AccessLogModel, error)
+	CreateFolder(ctx context.Context, path string, uID int64) error
+	GetFolders(ctx context.Context, uID int64) ([]string, error)
+	MoveFolder(ctx context.Context, from string, to string, uID int64) error
+	GetHistory(ctx context.Context, kind string, name string, uID int64) ([]models.HistoryModel, error)
+	GetHistoryVersion(ctx context.Context, kind string, name string, version int, uID int64) (models.HistoryModel, error)
+	GetTrash(ctx context.Context, uID int64) ([]models.TrashModel, error)
+	RestoreDeleted(ctx context.Context, kind string, name string, uID int64) error
+	Rename(ctx context.Context, kind string, oldName string, newName string, uID int64) error
+	ClearRenames(ctx context.Context, uID int64) error
+	SaveConflict(ctx context.Context, conflict models.ConflictModel, uID int64) error
+	GetConflicts(ctx context.Context, uID int64) ([]models.ConflictModel, error)
+	DeleteConflict(ctx context.Context, kind string, uuid string, uID int64) error
+	RebaseRecord(ctx context.Context, kind string, uuid string, base hlc.Timestamp, uID int64) error
 }
 
 type UserStorage interface {
 	SaveUser(ctx context.Context, user models.UserModel) (int64, error)
 	GetUserHash(ctx context.Context, login string) (int64, string, error)
+	GetUsers(ctx context.Context) ([]models.UserModel, error)
+	UpdateUserHash(ctx context.Context, uID int64, hash string) error
+	GetLoginAttempts(ctx context.Context, login string) (models.LoginAttemptsModel, error)
+	SaveLoginAttempts(ctx context.Context, login string, attempts models.LoginAttemptsModel) error
+	ResetLoginAttempts(ctx context.Context, login string) error
+	GetUserKDF(ctx context.Context, uID int64) (models.KDFModel, error)
+	SaveUserKDF(ctx context.Context, uID int64, kdf models.KDFModel) error
 }
 
 type CardStorage interface {
@@ -54,10 +88,20 @@ type TextStorage interface {
 	UpdateText(ctx context.Context, data models.TextDataModel, uID int64) error
 }
 
+type OTPStorage interface {
+	SaveOTP(ctx context.Context, otp models.OTPModel, uID int64) (int64, error)
+	GetAllOTP(ctx context.Context, uID int64) ([]models.OTPModel, error)
+	GetOTPByName(ctx context.Context, name string, uID int64) (models.OTPModel, error)
+	DeleteOTP(ctx context.Context, name string, uID int64) error
+	UpdateOTP(ctx context.Context, otp models.OTPModel, uID int64) error
+}
+
 type Client interface {
-	Register(ctx context.Context, login, password string) error
-	Login(ctx context.Context, login, password string) error
+	Register(ctx context.Context, login, password string) (models.TokensModel, error)
+	Login(ctx context.Context, login, password string) (models.TokensModel, error)
 	Sync(ctx context.Context, model models.SyncModel, uID int64) (models.SyncModel, error)
+	SetTokens(tokens models.TokensModel)
+	Tokens() models.TokensModel
 }
 
 type KeepService struct {
@@ -68,23 +112,44 @@ type KeepService struct {
 	textStor   TextStorage
 	binStor    BinStorage
 	authStor   AuthStorage
+	otpStor    OTPStorage
+	hasher     Hasher
+	policy     LoginPolicy
+	// trashRetention - время, которое удаленные записи хранятся в корзине после синхронизации.
+	trashRetention time.Duration
+	// resolver выбирает способ разрешения конфликтов при синхронизации, без него конфликты откладываются.
+	resolver ConflictResolver
 }
 
 // TODO: Добавить килент
-func New(client Client, stor Storage, uStor UserStorage, cStor CardStorage, tStor TextStorage, bStor BinStorage, aStor AuthStorage) *KeepService {
+func New(client Client, stor Storage, uStor UserStorage, cStor CardStorage, tStor TextStorage, bStor BinStorage, aStor AuthStorage, oStor OTPStorage) *KeepService {
 	return &KeepService{
-		keepClient: client,
-		stor:       stor,
-		userStor:   uStor,
-		cardStor:   cStor,
-		textStor:   tStor,
-		binStor:    bStor,
-		authStor:   aStor,
+		keepClient:     client,
+		stor:           stor,
+		userStor:       uStor,
+		cardStor:       cStor,
+		textStor:       tStor,
+		binStor:        bStor,
+		authStor:       aStor,
+		otpStor:        oStor,
+		hasher:         Argon2Hasher{Params: coder.DefaultKDFParams},
+		policy:         DefaultLoginPolicy,
+		trashRetention: DefaultTrashRetention,
 	}
 }
 
+// SetHasher задает алгоритм хеширования паролей пользователей.
+func (kp *KeepService) SetHasher(hasher Hasher) {
+	kp.hasher = hasher
+}
+
+// SetLoginPolicy задает ограничение частоты попыток входа.
+func (kp *KeepService) SetLoginPolicy(policy LoginPolicy) {
+	kp.policy = policy
+}
+
 func (kp *KeepService) RegisterUser(login string, pass string) (int64, error) {
-	hash, err := hashPass(pass)
+	hash, err := kp.hasher.Hash(pass)
 	if err != nil {
 		return -1, err
 	}
@@ -95,25 +160,144 @@ func (kp *KeepService) RegisterUser(login string, pass string) (int64, error) {
 	if err != nil {
 		return -1, err
 	}
+	if _, err := kp.newUserKDF(uID); err != nil {
+		return -1, err
+	}
 	return uID, nil
 }
 
+// UnlockVault получает ключ хранилища пользователя из мастер-пароля и открывает им хранилище.
+// Пользователям, созданным до появления ключа хранилища, соль и параметры генерируются при первом вызове,
+// а сохраненные ранее в открытом виде данные шифруются.
+func (kp *KeepService) UnlockVault(uID int64, pass string) ([]byte, error) {
+	kdf, err := kp.userStor.GetUserKDF(context.Background(), uID)
+	if err != nil {
+		return nil, err
+	}
+	if len(kdf.Salt) == 0 {
+		kdf, err = kp.newUserKDF(uID)
+		if err != nil {
+			return nil, err
+		}
+	}
+	params, err := coder.ParseKDFParams(kdf.Params)
+	if err != nil {
+		return nil, err
+	}
+	key := coder.DeriveKey(pass, kdf.Salt, params)
+	if err := kp.UseVaultKey(key); err != nil {
+		return nil, err
+	}
+	if err := kp.stor.EncryptExisting(context.Background(), uID); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// UseVaultKey открывает хранилище ключом, полученным при входе в систему.
+func (kp *KeepService) UseVaultKey(key []byte) error {
+	return kp.stor.SetVaultKey(key)
+}
+
+func (kp *KeepService) newUserKDF(uID int64) (models.KDFModel, error) {
+	salt, err := coder.NewSalt()
+	if err != nil {
+		return models.KDFModel{}, err
+	}
+	kdf := models.KDFModel{
+		Salt:   salt,
+		Params: coder.DefaultKDFParams.String(),
+	}
+	if err := kp.userStor.SaveUserKDF(context.Background(), uID, kdf); err != nil {
+		return models.KDFModel{}, err
+	}
+	return kdf, nil
+}
+
+// LoginUser проверяет мастер-пароль пользователя. Неудачные попытки учитываются по логину,
+// в том числе для логинов, которых нет в локальной базе: для них так же выполняется хеширование пароля,
+// а ошибка не отличается от ошибки неверного пароля.
 func (kp *KeepService) LoginUser(login string, pass string) (models.UserModel, error) {
-	uID, hashFromDB, err := kp.userStor.GetUserHash(context.Background(), login)
+	attempts, err := kp.userStor.GetLoginAttempts(context.Background(), login)
 	if err != nil {
 		return models.UserModel{}, err
 	}
-	if !matchPass(pass, hashFromDB) {
-		return models.UserModel{}, ErrInvalidPassword
+	now := time.Now()
+	if wait, locked := kp.policy.wait(attempts, now); wait > 0 {
+		return models.UserModel{}, &LoginDelayError{Err: ErrLoginDelayed, Delay: wait, Locked: locked}
+	}
+	uID, hashFromDB, err := kp.userStor.GetUserHash(context.Background(), login)
+	if err != nil && !errors.Is(err, storage.ErrUserNotExist) {
+		return models.UserModel{}, err
+	}
+	var valid bool
+	if err == nil {
+		valid = matchPass(pass, hashFromDB)
+	} else if _, err := kp.hasher.Hash(pass); err != nil {
+		return models.UserModel{}, err
+	}
+	if !valid {
+		attempts = kp.policy.fail(attempts, now)
+		if err := kp.userStor.SaveLoginAttempts(context.Background(), login, attempts); err != nil {
+			return models.UserModel{}, err
+		}
+		wait, locked := kp.policy.wait(attempts, now)
+		return models.UserModel{}, &LoginDelayError{Err: ErrInvalidPassword, Delay: wait, Locked: locked}
+	}
+	if attempts != (models.LoginAttemptsModel{}) {
+		if err := kp.userStor.ResetLoginAttempts(context.Background(), login); err != nil {
+			return models.UserModel{}, err
+		}
+	}
+	if kp.hasher.NeedsRehash(hashFromDB) {
+		hash, err := kp.hasher.Hash(pass)
+		if err != nil {
+			return models.UserModel{}, err
+		}
+		if err := kp.userStor.UpdateUserHash(context.Background(), uID, hash); err != nil {
+			return models.UserModel{}, err
+		}
 	}
 	return models.UserModel{
 		UserID: uID,
 		Login:  login,
-		Hash:   pass,
 	}, nil
 }
 
+// NewSession создает сессию пользователя. Сессия истекает вместе с токеном сервера,
+// но не позже чем через SessionTTL.
+func (kp *KeepService) NewSession(user models.UserModel, syncKey []byte, tokens models.TokensModel) models.SessionModel {
+	now := time.Now()
+	expiresAt := now.Add(SessionTTL)
+	if !tokens.ExpiresAt.IsZero() && tokens.ExpiresAt.Before(expiresAt) {
+		expiresAt = tokens.ExpiresAt
+	}
+	return models.SessionModel{
+		UserID:       user.UserID,
+		Login:        user.Login,
+		Tokens:       tokens,
+		SyncKey:      syncKey,
+		ExpiresAt:    expiresAt,
+		LastActivity: now,
+	}
+}
+
+// Users возвращает пользователей, зарегистрированных в локальной базе.
+func (kp *KeepService) Users() ([]models.UserModel, error) {
+	return kp.userStor.GetUsers(context.Background())
+}
+
+// ServerTokens возвращает токены сервера, которые могли обновиться при синхронизации.
+func (kp *KeepService) ServerTokens() models.TokensModel {
+	return kp.keepClient.Tokens()
+}
+
+// SaveCard проверяет номер, срок действия и CVV карты и сохраняет ее в нормализованном виде.
 func (kp *KeepService) SaveCard(card models.CardModel, uID int64) (int64, error) {
+	card, err := cardcheck.Normalize(card)
+	if err != nil {
+		return -1, err
+	}
 	cID, err := kp.cardStor.SaveCard(context.Background(), card, uID)
 	if err != nil {
 		return -1, err
@@ -169,6 +353,49 @@ func (kp *KeepService) GetLogins(uID int64) ([]models.LoginModel, error) {
 	return logins, nil
 }
 
+// AuditLogins проверяет стойкость, повторное использование и возраст сохраненных паролей.
+func (kp *KeepService) AuditLogins(uID int64, maxAge time.Duration) (audit.Report, error) {
+	logins, err := kp.authStor.GetAllLogins(context.Background(), uID)
+	if err != nil {
+		return audit.Report{}, err
+	}
+	return audit.Analyze(logins, maxAge, time.Now()), nil
+}
+
+// CheckPwnedLogins проверяет сохраненные пароли по локальному списку утечек.
+func (kp *KeepService) CheckPwnedLogins(uID int64, src audit.PwnedSource) ([]audit.PwnedEntry, error) {
+	logins, err := kp.authStor.GetAllLogins(context.Background(), uID)
+	if err != nil {
+		return nil, err
+	}
+	return audit.CheckPwned(logins, src)
+}
+
+// Виды записей и действия в журнале доступа.
+const (
+	AccessKindCard  = "card"
+	AccessKindLogin = "auth"
+	AccessReveal    = "reveal"
+)
+
+// LogReveal записывает в журнал доступа показ секретов перечисленных записей.
+// Секреты нельзя показывать, если запись в журнал не удалась.
+func (kp *KeepService) LogReveal(uID int64, kind string, names ...string) error {
+	now := time.Now()
+	for _, name := range names {
+		entry := models.AccessLogModel{Kind: kind, Name: name, Action: AccessReveal, At: now}
+		if err := kp.stor.LogAccess(context.Background(), entry, uID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AccessLog возвращает последние limit записей журнала доступа.
+func (kp *KeepService) AccessLog(uID int64, limit int) ([]models.AccessLogModel, error) {
+	return kp.stor.GetAccessLog(context.Background(), uID, limit)
+}
+
 func (kp *KeepService) GetTextData(uID int64) ([]models.TextDataModel, error) {
 	tData, err := kp.textStor.GetAllTextData(context.Background(), uID)
 	if err != nil {
@@ -227,9 +454,13 @@ func (kp *KeepService) DeleteBinByName(name string, uID int64) error {
 	return err
 }
 
+// UpdateCard проверяет карту так же, как SaveCard, и обновляет сохраненную запись.
 func (kp *KeepService) UpdateCard(card models.CardModel, uID int64) error {
-	err := kp.cardStor.UpdateCard(context.Background(), card, uID)
-	return err
+	card, err := cardcheck.Normalize(card)
+	if err != nil {
+		return err
+	}
+	return kp.cardStor.UpdateCard(context.Background(), card, uID)
 }
 
 func (kp *KeepService) UpdateLogin(auth models.LoginModel, uID int64) error {
@@ -247,43 +478,134 @@ func (kp *KeepService) UpdateBin(data models.BinaryDataModel, uID int64) error {
 	return err
 }
 
-func (kp *KeepService) ServerLogin(user models.UserModel) error {
-	err := kp.keepClient.Login(context.Background(), user.Login, user.Hash)
+func (kp *KeepService) SaveOTP(otpData models.OTPModel, uID int64) (int64, error) {
+	oID, err := kp.otpStor.SaveOTP(context.Background(), otpData, uID)
+	if err != nil {
+		return -1, err
+	}
+	return oID, nil
+}
+
+func (kp *KeepService) GetOTPs(uID int64) ([]models.OTPModel, error) {
+	otps, err := kp.otpStor.GetAllOTP(context.Background(), uID)
+	if err != nil {
+		return nil, err
+	}
+	return otps, nil
+}
+
+func (kp *KeepService) GetOTPByName(name string, uID int64) (models.OTPModel, error) {
+	otpData, err := kp.otpStor.GetOTPByName(context.Background(), name, uID)
+	if err != nil {
+		return models.OTPModel{}, err
+	}
+	return otpData, nil
+}
+
+func (kp *KeepService) DeleteOTPByName(name string, uID int64) error {
+	err := kp.otpStor.DeleteOTP(context.Background(), name, uID)
 	return err
 }
 
-func (kp *KeepService) ServerRegister(user models.UserModel) error {
-	err := kp.keepClient.Register(context.Background(), user.Login, user.Hash)
+func (kp *KeepService) UpdateOTP(otpData models.OTPModel, uID int64) error {
+	err := kp.otpStor.UpdateOTP(context.Background(), otpData, uID)
 	return err
 }
 
+// GenerateOTP возвращает текущий одноразовый пароль и число секунд до его смены.
+// Для HOTP счетчик увеличивается после каждого выданного кода.
+func (kp *KeepService) GenerateOTP(name string, uID int64, now time.Time) (string, int, error) {
+	rec, err := kp.otpStor.GetOTPByName(context.Background(), name, uID)
+	if err != nil {
+		return "", 0, err
+	}
+	code, err := otp.Code(rec, now)
+	if err != nil {
+		return "", 0, err
+	}
+	if rec.Type == otp.TypeHOTP {
+		rec.Counter++
+		if err := kp.otpStor.UpdateOTP(context.Background(), rec, uID); err != nil {
+			return "", 0, err
+		}
+	}
+	return code, otp.Remaining(rec, now), nil
+}
+
+// ServerLogin выполняет вход на сервер. Сервер получает пароль входа из keys, а не мастер-пароль.
+func (kp *KeepService) ServerLogin(login string, keys coder.ServerKeys) (models.TokensModel, error) {
+	return kp.keepClient.Login(context.Background(), login, keys.Auth)
+}
+
+// ServerRegister регистрирует пользователя на сервере с паролем входа из keys.
+func (kp *KeepService) ServerRegister(login string, keys coder.ServerKeys) (models.TokensModel, error) {
+	return kp.keepClient.Register(context.Background(), login, keys.Auth)
+}
+
+// SyncBD получает с сервера все записи пользователя и отправляет только записи, измененные на устройстве
+// после последней синхронизации. Протокол сервера не поддерживает отметку синхронизации, поэтому записи
+// с сервера загружаются целиком, а неизменившиеся пропускаются при сопоставлении с локальными.
+// Записи, одновременно измененные на устройстве и на сервере, считаются конфликтами и разрешаются
+// через SetConflictResolver. Неразрешенные конфликты сохраняются, такие записи не отправляются до их разрешения.
 func (kp *KeepService) SyncBD(uID int64) error {
-	localModel, err := kp.stor.GetAllSaves(context.Background(), uID)
+	ctx := context.Background()
+	localModel, err := kp.stor.GetChangedSaves(ctx, uID)
 	if err != nil {
 		return err
 	}
-	sModel, err := kp.keepClient.Sync(context.Background(), localModel, uID)
+	stored, err := kp.stor.GetConflicts(ctx, uID)
 	if err != nil {
 		return err
 	}
-	if err = kp.stor.Sync(context.Background(), sModel); err != nil {
+	pending := make(map[string]bool)
+	for _, conflict := range stored {
+		pending[recordKey(conflict.Kind, conflict.UUID)] = true
+	}
+	remote, err := kp.keepClient.Sync(ctx, models.SyncModel{}, uID)
+	if err != nil {
 		return err
 	}
-	if err = kp.stor.ClearDB(context.Background(), uID); err != nil {
+	conflicts, rest := detectConflicts(localModel, remote, pending)
+	if err = kp.stor.Sync(ctx, rest); err != nil {
 		return err
 	}
-	return nil
-}
+	for _, conflict := range conflicts {
+		res := ResolveLater
+		if kp.resolver != nil {
+			if res, err = kp.resolver(conflict); err != nil {
+				return err
+			}
+		}
+		if res == ResolveLater {
+			pending[recordKey(conflict.Kind, conflict.UUID)] = true
+		}
+		if err = kp.applyResolution(ctx, conflict, res, uID); err != nil {
+			return err
+		}
+	}
 
-func hashPass(pass string) (string, error) {
-	hashedPass, err := bcrypt.GenerateFromPassword([]byte(pass), bcrypt.MinCost)
+	pushModel, err := kp.stor.GetChangedSaves(ctx, uID)
 	if err != nil {
-		return "", err
+		return err
 	}
-	return string(hashedPass), nil
-}
-
-func matchPass(pass string, hashFromDB string) bool {
-	err := bcrypt.CompareHashAndPassword([]byte(hashFromDB), []byte(pass))
-	return err == nil
+	pushModel = withoutRecords(pushModel, pending)
+	if !emptySync(pushModel) {
+		sModel, err := kp.keepClient.Sync(ctx, pushModel, uID)
+		if err != nil {
+			return err
+		}
+		if err = kp.stor.Sync(ctx, withoutRecords(sModel, pending)); err != nil {
+			return err
+		}
+	}
+	if err = kp.stor.MarkSynced(ctx, pushModel, uID); err != nil {
+		return err
+	}
+	if err = kp.stor.ClearRenames(ctx, uID); err != nil {
+		return err
+	}
+	if err = kp.stor.ClearDB(ctx, uID, time.Now().Add(-kp.trashRetention)); err != nil {
+		return err
+	}
+	return nil
 }
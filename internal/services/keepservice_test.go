@@ -3,15 +3,25 @@ package services
 import (
 	"context"
 	"errors"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/Dorrrke/GophKeeper-client/internal/cardcheck"
 	"github.com/Dorrrke/GophKeeper-client/internal/client"
+	"github.com/Dorrrke/GophKeeper-client/internal/coder"
+	"github.com/Dorrrke/GophKeeper-client/internal/config"
 	"github.com/Dorrrke/GophKeeper-client/internal/domain/models"
+	"github.com/Dorrrke/GophKeeper-client/internal/folder"
+	"github.com/Dorrrke/GophKeeper-client/internal/hlc"
+	"github.com/Dorrrke/GophKeeper-client/internal/otp"
+	"github.com/Dorrrke/GophKeeper-client/internal/storage"
 	_ "github.com/golang-migrate/migrate/v4/database/sqlite3"
 	_ "github.com/golang-migrate/migrate/v4/source/file"
 	"github.com/golang/mock/gomock"
 	_ "github.com/mattn/go-sqlite3"
 	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/bcrypt"
 )
 
 func TestSaveCard(t *testing.T) {
@@ -30,9 +40,9 @@ func TestSaveCard(t *testing.T) {
 			name: "Test SaveCard function #1; Default call",
 			card: models.CardModel{
 				Name:    "Card1",
-				Number:  "132514562235",
+				Number:  "4111111111111111",
 				Date:    "09/33",
-				CVVCode: 844,
+				CVVCode: "844",
 			},
 			uID: 1,
 			want: want{
@@ -44,9 +54,9 @@ func TestSaveCard(t *testing.T) {
 			name: "Test SaveCard function #2; Error call",
 			card: models.CardModel{
 				Name:    "Card12",
-				Number:  "13251454362235",
+				Number:  "5500000000000004",
 				Date:    "09/44",
-				CVVCode: 854,
+				CVVCode: "854",
 			},
 			uID: 4,
 			want: want{
@@ -67,13 +77,46 @@ func TestSaveCard(t *testing.T) {
 			m5 := NewMockTextStorage(ctrl)
 			m6 := NewMockUserStorage(ctrl)
 			m1.EXPECT().SaveCard(context.Background(), tc.card, tc.uID).Return(tc.want.uID, tc.want.err)
-			service := New(&client.KeeperClient{}, m4, m6, m1, m5, m3, m2)
+			service := New(&client.KeeperClient{}, m4, m6, m1, m5, m3, m2, nil)
 			res, _ := service.SaveCard(tc.card, tc.uID)
 			assert.Equal(t, tc.want.uID, res)
 		})
 	}
 }
 
+func TestSaveCardValidation(t *testing.T) {
+	tests := []struct {
+		name string
+		card models.CardModel
+		err  error
+	}{
+		{"Test SaveCard validation #1; Luhn", models.CardModel{Name: "c", Number: "4111111111111112", Date: "09/33", CVVCode: "123"}, cardcheck.ErrNumber},
+		{"Test SaveCard validation #2; Date", models.CardModel{Name: "c", Number: "4111111111111111", Date: "9/33", CVVCode: "123"}, cardcheck.ErrDate},
+		{"Test SaveCard validation #3; CVV", models.CardModel{Name: "c", Number: "4111111111111111", Date: "09/33", CVVCode: "12"}, cardcheck.ErrCVV},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			cStor := NewMockCardStorage(ctrl)
+			service := New(&client.KeeperClient{}, nil, nil, cStor, nil, nil, nil, nil)
+			_, err := service.SaveCard(tc.card, 1)
+			assert.ErrorIs(t, err, tc.err)
+			assert.ErrorIs(t, service.UpdateCard(tc.card, 1), tc.err)
+		})
+	}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	cStor := NewMockCardStorage(ctrl)
+	want := models.CardModel{Name: "c", Number: "4111111111111111", Date: "09/33", CVVCode: "012"}
+	cStor.EXPECT().SaveCard(context.Background(), want, int64(1)).Return(int64(3), nil)
+	service := New(&client.KeeperClient{}, nil, nil, cStor, nil, nil, nil, nil)
+	id, err := service.SaveCard(models.CardModel{Name: "c", Number: "4111 1111 1111 1111", Date: "09/2033", CVVCode: "012"}, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), id)
+}
+
 func TestSaveLogin(t *testing.T) {
 	type want struct {
 		uID int64
@@ -125,7 +168,7 @@ func TestSaveLogin(t *testing.T) {
 			m5 := NewMockTextStorage(ctrl)
 			m6 := NewMockUserStorage(ctrl)
 			m2.EXPECT().SaveLogin(context.Background(), tc.login, tc.uID).Return(tc.want.uID, tc.want.err)
-			service := New(&client.KeeperClient{}, m4, m6, m1, m5, m3, m2)
+			service := New(&client.KeeperClient{}, m4, m6, m1, m5, m3, m2, nil)
 			res, _ := service.SaveLogin(tc.login, tc.uID)
 			assert.Equal(t, tc.want.uID, res)
 		})
@@ -181,7 +224,7 @@ func TestSaveTextData(t *testing.T) {
 			m5 := NewMockTextStorage(ctrl)
 			m6 := NewMockUserStorage(ctrl)
 			m5.EXPECT().SaveText(context.Background(), tc.text, tc.uID).Return(tc.want.uID, tc.want.err)
-			service := New(&client.KeeperClient{}, m4, m6, m1, m5, m3, m2)
+			service := New(&client.KeeperClient{}, m4, m6, m1, m5, m3, m2, nil)
 			res, _ := service.SaveTextData(tc.text, tc.uID)
 			assert.Equal(t, tc.want.uID, res)
 		})
@@ -237,7 +280,7 @@ func TestSaveBinaryData(t *testing.T) {
 			m5 := NewMockTextStorage(ctrl)
 			m6 := NewMockUserStorage(ctrl)
 			m3.EXPECT().SaveBin(context.Background(), tc.text, tc.uID).Return(tc.want.uID, tc.want.err)
-			service := New(&client.KeeperClient{}, m4, m6, m1, m5, m3, m2)
+			service := New(&client.KeeperClient{}, m4, m6, m1, m5, m3, m2, nil)
 			res, _ := service.SaveBinaryData(tc.text, tc.uID)
 			assert.Equal(t, tc.want.uID, res)
 		})
@@ -297,7 +340,7 @@ func TestGetBins(t *testing.T) {
 			m5 := NewMockTextStorage(ctrl)
 			m6 := NewMockUserStorage(ctrl)
 			m3.EXPECT().GetAllBin(context.Background(), tc.uID).Return(tc.want.bins, tc.want.err)
-			service := New(&client.KeeperClient{}, m4, m6, m1, m5, m3, m2)
+			service := New(&client.KeeperClient{}, m4, m6, m1, m5, m3, m2, nil)
 			res, _ := service.GetBins(tc.uID)
 			assert.Equal(t, tc.want.bins, res)
 		})
@@ -324,19 +367,19 @@ func TestGetCards(t *testing.T) {
 						Name:    "Card123",
 						Number:  "1325145434362235",
 						Date:    "09/34",
-						CVVCode: 854,
+						CVVCode: "854",
 					},
 					{
 						Name:    "Card125",
 						Number:  "13251464754362235",
 						Date:    "12/44",
-						CVVCode: 854,
+						CVVCode: "854",
 					},
 					{
 						Name:    "Card126",
 						Number:  "1325143454362235",
 						Date:    "05/34",
-						CVVCode: 854,
+						CVVCode: "854",
 					},
 				},
 				err: nil,
@@ -363,7 +406,7 @@ func TestGetCards(t *testing.T) {
 			m5 := NewMockTextStorage(ctrl)
 			m6 := NewMockUserStorage(ctrl)
 			m1.EXPECT().GetAllCards(context.Background(), tc.uID).Return(tc.want.cards, tc.want.err)
-			service := New(&client.KeeperClient{}, m4, m6, m1, m5, m3, m2)
+			service := New(&client.KeeperClient{}, m4, m6, m1, m5, m3, m2, nil)
 			res, _ := service.GetCards(tc.uID)
 			assert.Equal(t, tc.want.cards, res)
 		})
@@ -426,7 +469,7 @@ func TestGetLogins(t *testing.T) {
 			m5 := NewMockTextStorage(ctrl)
 			m6 := NewMockUserStorage(ctrl)
 			m2.EXPECT().GetAllLogins(context.Background(), tc.uID).Return(tc.want.logins, tc.want.err)
-			service := New(&client.KeeperClient{}, m4, m6, m1, m5, m3, m2)
+			service := New(&client.KeeperClient{}, m4, m6, m1, m5, m3, m2, nil)
 			res, _ := service.GetLogins(tc.uID)
 			assert.Equal(t, tc.want.logins, res)
 		})
@@ -486,7 +529,7 @@ func TestGetTextData(t *testing.T) {
 			m5 := NewMockTextStorage(ctrl)
 			m6 := NewMockUserStorage(ctrl)
 			m5.EXPECT().GetAllTextData(context.Background(), tc.uID).Return(tc.want.text, tc.want.err)
-			service := New(&client.KeeperClient{}, m4, m6, m1, m5, m3, m2)
+			service := New(&client.KeeperClient{}, m4, m6, m1, m5, m3, m2, nil)
 			res, _ := service.GetTextData(tc.uID)
 			assert.Equal(t, tc.want.text, res)
 		})
@@ -513,7 +556,7 @@ func TestGetCardByName(t *testing.T) {
 					Name:    "Card125",
 					Number:  "13251464754362235",
 					Date:    "12/44",
-					CVVCode: 854,
+					CVVCode: "854",
 				},
 				err: nil,
 			},
@@ -539,7 +582,7 @@ func TestGetCardByName(t *testing.T) {
 			m5 := NewMockTextStorage(ctrl)
 			m6 := NewMockUserStorage(ctrl)
 			m1.EXPECT().GetCardByName(context.Background(), tc.cName, tc.uID).Return(tc.want.data, tc.want.err)
-			service := New(&client.KeeperClient{}, m4, m6, m1, m5, m3, m2)
+			service := New(&client.KeeperClient{}, m4, m6, m1, m5, m3, m2, nil)
 			res, _ := service.GetCardByName(tc.cName, tc.uID)
 			assert.Equal(t, tc.want.data, res)
 		})
@@ -591,7 +634,7 @@ func GetLoginByName(t *testing.T) {
 			m5 := NewMockTextStorage(ctrl)
 			m6 := NewMockUserStorage(ctrl)
 			m2.EXPECT().GetLoginByName(context.Background(), tc.cName, tc.uID).Return(tc.want.data, tc.want.err)
-			service := New(&client.KeeperClient{}, m4, m6, m1, m5, m3, m2)
+			service := New(&client.KeeperClient{}, m4, m6, m1, m5, m3, m2, nil)
 			res, _ := service.GetLoginByName(tc.cName, tc.uID)
 			assert.Equal(t, tc.want.data, res)
 		})
@@ -642,7 +685,7 @@ func TesGetTextDataByName(t *testing.T) {
 			m5 := NewMockTextStorage(ctrl)
 			m6 := NewMockUserStorage(ctrl)
 			m5.EXPECT().GetTextDataByName(context.Background(), tc.cName, tc.uID).Return(tc.want.data, tc.want.err)
-			service := New(&client.KeeperClient{}, m4, m6, m1, m5, m3, m2)
+			service := New(&client.KeeperClient{}, m4, m6, m1, m5, m3, m2, nil)
 			res, _ := service.GetTextDataByName(tc.cName, tc.uID)
 			assert.Equal(t, tc.want.data, res)
 		})
@@ -693,13 +736,662 @@ func TestGetBinByName(t *testing.T) {
 			m5 := NewMockTextStorage(ctrl)
 			m6 := NewMockUserStorage(ctrl)
 			m3.EXPECT().GetBinByName(context.Background(), tc.cName, tc.uID).Return(tc.want.data, tc.want.err)
-			service := New(&client.KeeperClient{}, m4, m6, m1, m5, m3, m2)
+			service := New(&client.KeeperClient{}, m4, m6, m1, m5, m3, m2, nil)
 			res, _ := service.GetBinByName(tc.cName, tc.uID)
 			assert.Equal(t, tc.want.data, res)
 		})
 	}
 }
 
+func TestUnlockVault(t *testing.T) {
+	type want struct {
+		keyLen int
+		newKDF bool
+		err    error
+	}
+	type test struct {
+		name string
+		uID  int64
+		pass string
+		kdf  models.KDFModel
+		want want
+	}
+	tests := []test{
+		{
+			name: "Test UnlockVault function #1; Default call",
+			uID:  1,
+			pass: "master",
+			kdf: models.KDFModel{
+				Salt:   []byte("0123456789abcdef"),
+				Params: "m=1024,t=1,p=1",
+			},
+			want: want{
+				keyLen: coder.KeyLen,
+				err:    nil,
+			},
+		},
+		{
+			name: "Test UnlockVault function #2; User without kdf",
+			uID:  2,
+			pass: "master",
+			kdf:  models.KDFModel{},
+			want: want{
+				keyLen: coder.KeyLen,
+				newKDF: true,
+				err:    nil,
+			},
+		},
+		{
+			name: "Test UnlockVault function #3; Invalid params",
+			uID:  3,
+			pass: "master",
+			kdf: models.KDFModel{
+				Salt:   []byte("0123456789abcdef"),
+				Params: "bcrypt",
+			},
+			want: want{
+				err: coder.ErrInvalidKDFParam,
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			m1 := NewMockCardStorage(ctrl)
+			m2 := NewMockAuthStorage(ctrl)
+			m3 := NewMockBinStorage(ctrl)
+			m4 := NewMockStorage(ctrl)
+			m5 := NewMockTextStorage(ctrl)
+			m6 := NewMockUserStorage(ctrl)
+			m6.EXPECT().GetUserKDF(context.Background(), tc.uID).Return(tc.kdf, nil).AnyTimes()
+			if tc.want.newKDF {
+				m6.EXPECT().SaveUserKDF(context.Background(), tc.uID, gomock.Any()).Return(nil)
+			}
+			if tc.want.err == nil {
+				m4.EXPECT().SetVaultKey(gomock.Any()).Return(nil).AnyTimes()
+				m4.EXPECT().EncryptExisting(context.Background(), tc.uID).Return(nil).AnyTimes()
+			}
+			service := New(&client.KeeperClient{}, m4, m6, m1, m5, m3, m2, nil)
+			key, err := service.UnlockVault(tc.uID, tc.pass)
+			assert.ErrorIs(t, err, tc.want.err)
+			assert.Len(t, key, tc.want.keyLen)
+			if tc.want.err == nil && !tc.want.newKDF {
+				again, err := service.UnlockVault(tc.uID, tc.pass)
+				assert.NoError(t, err)
+				assert.Equal(t, key, again)
+			}
+		})
+	}
+}
+
+func TestNewSession(t *testing.T) {
+	type test struct {
+		name   string
+		tokens models.TokensModel
+		maxTTL time.Duration
+	}
+	tests := []test{
+		{
+			name:   "Test NewSession function #1; Without server token",
+			tokens: models.TokensModel{},
+			maxTTL: SessionTTL,
+		},
+		{
+			name: "Test NewSession function #2; Token expires before session ttl",
+			tokens: models.TokensModel{
+				AccessToken: "token",
+				ExpiresAt:   time.Now().Add(time.Hour),
+			},
+			maxTTL: time.Hour,
+		},
+		{
+			name: "Test NewSession function #3; Token expires after session ttl",
+			tokens: models.TokensModel{
+				AccessToken: "token",
+				ExpiresAt:   time.Now().Add(48 * time.Hour),
+			},
+			maxTTL: SessionTTL,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			service := New(&client.KeeperClient{}, nil, nil, nil, nil, nil, nil, nil)
+			user := models.UserModel{UserID: 1, Login: "user"}
+			session := service.NewSession(user, []byte("key"), tc.tokens)
+			assert.Equal(t, user.UserID, session.UserID)
+			assert.Equal(t, user.Login, session.Login)
+			assert.Equal(t, tc.tokens, session.Tokens)
+			assert.WithinDuration(t, time.Now().Add(tc.maxTTL), session.ExpiresAt, time.Minute)
+			assert.WithinDuration(t, time.Now(), session.LastActivity, time.Minute)
+		})
+	}
+}
+
+func TestServerAuth(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	keepClient := NewMockClient(ctrl)
+	service := New(keepClient, nil, nil, nil, nil, nil, nil, nil)
+
+	keys, err := coder.DeriveServerKeys("master", "gopher")
+	assert.NoError(t, err)
+	var sent []string
+	record := func(_ context.Context, _ string, password string) (models.TokensModel, error) {
+		sent = append(sent, password)
+		return models.TokensModel{AccessToken: "token"}, nil
+	}
+	keepClient.EXPECT().Login(context.Background(), "gopher", gomock.Any()).DoAndReturn(record)
+	keepClient.EXPECT().Register(context.Background(), "gopher", gomock.Any()).DoAndReturn(record)
+	_, err = service.ServerLogin("gopher", keys)
+	assert.NoError(t, err)
+	_, err = service.ServerRegister("gopher", keys)
+	assert.NoError(t, err)
+
+	// Сервер не получает мастер-пароль и не может получить из отправленного пароля ключ синхронизации.
+	assert.Equal(t, []string{keys.Auth, keys.Auth}, sent)
+	assert.NotEqual(t, "master", sent[0])
+	assert.NotContains(t, sent[0], string(keys.Sync))
+	fromSent, err := coder.DeriveServerKeys(sent[0], "gopher")
+	assert.NoError(t, err)
+	assert.NotEqual(t, keys.Sync, fromSent.Sync)
+}
+
+func TestLoginUser(t *testing.T) {
+	fastArgon := Argon2Hasher{Params: coder.KDFParams{Time: 1, Memory: 1024, Threads: 1}}
+	legacyHash, err := BcryptHasher{Cost: bcrypt.MinCost}.Hash("master")
+	assert.NoError(t, err)
+	currentHash, err := fastArgon.Hash("master")
+	assert.NoError(t, err)
+
+	type want struct {
+		rehash bool
+		err    error
+	}
+	type test struct {
+		name string
+		hash string
+		pass string
+		want want
+	}
+	tests := []test{
+		{
+			name: "Test LoginUser function #1; Current hash",
+			hash: currentHash,
+			pass: "master",
+			want: want{
+				rehash: false,
+				err:    nil,
+			},
+		},
+		{
+			name: "Test LoginUser function #2; Legacy bcrypt hash is upgraded",
+			hash: legacyHash,
+			pass: "master",
+			want: want{
+				rehash: true,
+				err:    nil,
+			},
+		},
+		{
+			name: "Test LoginUser function #3; Invalid password",
+			hash: legacyHash,
+			pass: "wrong",
+			want: want{
+				rehash: false,
+				err:    ErrInvalidPassword,
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			m6 := NewMockUserStorage(ctrl)
+			m6.EXPECT().GetUserHash(context.Background(), "user").Return(int64(1), tc.hash, nil)
+			m6.EXPECT().GetLoginAttempts(context.Background(), "user").Return(models.LoginAttemptsModel{}, nil)
+			if tc.want.err != nil {
+				m6.EXPECT().SaveLoginAttempts(context.Background(), "user", gomock.Any()).Return(nil)
+			}
+			if tc.want.rehash {
+				m6.EXPECT().UpdateUserHash(context.Background(), int64(1), gomock.Any()).DoAndReturn(
+					func(_ context.Context, _ int64, hash string) error {
+						assert.False(t, fastArgon.NeedsRehash(hash))
+						assert.True(t, matchPass(tc.pass, hash))
+						return nil
+					})
+			}
+			service := New(&client.KeeperClient{}, nil, m6, nil, nil, nil, nil, nil)
+			service.SetHasher(fastArgon)
+			user, err := service.LoginUser("user", tc.pass)
+			assert.ErrorIs(t, err, tc.want.err)
+			if tc.want.err == nil {
+				assert.Equal(t, int64(1), user.UserID)
+			}
+		})
+	}
+}
+
+func TestNewHasher(t *testing.T) {
+	type want struct {
+		hasher Hasher
+		err    error
+	}
+	type test struct {
+		name string
+		cfg  config.HashConfig
+		want want
+	}
+	tests := []test{
+		{
+			name: "Test NewHasher function #1; Argon2id with default params",
+			cfg:  config.HashConfig{Algorithm: HashArgon2id},
+			want: want{
+				hasher: Argon2Hasher{Params: coder.DefaultKDFParams},
+			},
+		},
+		{
+			name: "Test NewHasher function #2; Argon2id with params",
+			cfg:  config.HashConfig{Algorithm: HashArgon2id, Argon2Params: "m=1024,t=2,p=1"},
+			want: want{
+				hasher: Argon2Hasher{Params: coder.KDFParams{Time: 2, Memory: 1024, Threads: 1}},
+			},
+		},
+		{
+			name: "Test NewHasher function #3; Bcrypt",
+			cfg:  config.HashConfig{Algorithm: HashBcrypt, BcryptCost: 12},
+			want: want{
+				hasher: BcryptHasher{Cost: 12},
+			},
+		},
+		{
+			name: "Test NewHasher function #4; Bcrypt cost out of range",
+			cfg:  config.HashConfig{Algorithm: HashBcrypt, BcryptCost: 1},
+			want: want{
+				err: ErrBcryptCost,
+			},
+		},
+		{
+			name: "Test NewHasher function #5; Unknown algorithm",
+			cfg:  config.HashConfig{Algorithm: "md5"},
+			want: want{
+				err: ErrHashAlgorithm,
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			hasher, err := NewHasher(tc.cfg)
+			assert.ErrorIs(t, err, tc.want.err)
+			assert.Equal(t, tc.want.hasher, hasher)
+		})
+	}
+}
+
+func TestHasherNeedsRehash(t *testing.T) {
+	fastArgon := Argon2Hasher{Params: coder.KDFParams{Time: 1, Memory: 1024, Threads: 1}}
+	argonHash, err := fastArgon.Hash("master")
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(argonHash, "$argon2id$v=19$m=1024,t=1,p=1$"))
+	bcryptHash, err := BcryptHasher{Cost: bcrypt.MinCost}.Hash("master")
+	assert.NoError(t, err)
+
+	assert.True(t, matchPass("master", argonHash))
+	assert.False(t, matchPass("other", argonHash))
+	assert.True(t, matchPass("master", bcryptHash))
+
+	assert.False(t, fastArgon.NeedsRehash(argonHash))
+	assert.True(t, Argon2Hasher{Params: coder.DefaultKDFParams}.NeedsRehash(argonHash))
+	assert.True(t, fastArgon.NeedsRehash(bcryptHash))
+	assert.False(t, BcryptHasher{Cost: bcrypt.MinCost}.NeedsRehash(bcryptHash))
+	assert.True(t, BcryptHasher{Cost: 12}.NeedsRehash(bcryptHash))
+	assert.True(t, BcryptHasher{Cost: 12}.NeedsRehash(argonHash))
+}
+
+func TestLoginBackoff(t *testing.T) {
+	fastArgon := Argon2Hasher{Params: coder.KDFParams{Time: 1, Memory: 1024, Threads: 1}}
+	hash, err := fastArgon.Hash("master")
+	assert.NoError(t, err)
+	policy := LoginPolicy{
+		BaseDelay:       time.Second,
+		MaxDelay:        time.Minute,
+		LockoutAfter:    3,
+		LockoutCooldown: time.Hour,
+	}
+
+	type want struct {
+		err    error
+		delay  time.Duration
+		locked bool
+		saved  *models.LoginAttemptsModel
+		reset  bool
+	}
+	type test struct {
+		name     string
+		pass     string
+		attempts models.LoginAttemptsModel
+		userErr  error
+		want     want
+	}
+	tests := []test{
+		{
+			name: "Test LoginBackoff #1; First failure",
+			pass: "wrong",
+			want: want{
+				err:   ErrInvalidPassword,
+				delay: time.Second,
+				saved: &models.LoginAttemptsModel{Failures: 1},
+			},
+		},
+		{
+			name:     "Test LoginBackoff #2; Retry before delay",
+			pass:     "master",
+			attempts: models.LoginAttemptsModel{Failures: 2, LastFailure: time.Now()},
+			want: want{
+				err:   ErrLoginDelayed,
+				delay: 2 * time.Second,
+			},
+		},
+		{
+			name:     "Test LoginBackoff #3; Failure after delay doubles it",
+			pass:     "wrong",
+			attempts: models.LoginAttemptsModel{Failures: 1, LastFailure: time.Now().Add(-time.Minute)},
+			want: want{
+				err:   ErrInvalidPassword,
+				delay: 2 * time.Second,
+				saved: &models.LoginAttemptsModel{Failures: 2},
+			},
+		},
+		{
+			name:     "Test LoginBackoff #4; Lockout after failures",
+			pass:     "wrong",
+			attempts: models.LoginAttemptsModel{Failures: 2, LastFailure: time.Now().Add(-time.Minute)},
+			want: want{
+				err:    ErrInvalidPassword,
+				delay:  time.Hour,
+				locked: true,
+				saved:  &models.LoginAttemptsModel{Failures: 0},
+			},
+		},
+		{
+			name:     "Test LoginBackoff #5; Locked login",
+			pass:     "master",
+			attempts: models.LoginAttemptsModel{LockedUntil: time.Now().Add(30 * time.Minute)},
+			want: want{
+				err:    ErrLoginDelayed,
+				delay:  30 * time.Minute,
+				locked: true,
+			},
+		},
+		{
+			name:     "Test LoginBackoff #6; Success after cooldown resets counter",
+			pass:     "master",
+			attempts: models.LoginAttemptsModel{LockedUntil: time.Now().Add(-time.Minute)},
+			want: want{
+				reset: true,
+			},
+		},
+		{
+			name:    "Test LoginBackoff #7; Unknown login is throttled like a wrong password",
+			pass:    "master",
+			userErr: storage.ErrUserNotExist,
+			want: want{
+				err:   ErrInvalidPassword,
+				delay: time.Second,
+				saved: &models.LoginAttemptsModel{Failures: 1},
+			},
+		},
+		{
+			name:     "Test LoginBackoff #8; Unknown login after failures",
+			pass:     "master",
+			attempts: models.LoginAttemptsModel{Failures: 1, LastFailure: time.Now()},
+			userErr:  storage.ErrUserNotExist,
+			want: want{
+				err:   ErrLoginDelayed,
+				delay: time.Second,
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			m6 := NewMockUserStorage(ctrl)
+			m6.EXPECT().GetLoginAttempts(context.Background(), "user").Return(tc.attempts, nil)
+			if !errors.Is(tc.want.err, ErrLoginDelayed) {
+				if tc.userErr != nil {
+					m6.EXPECT().GetUserHash(context.Background(), "user").Return(int64(-1), "", tc.userErr)
+				} else {
+					m6.EXPECT().GetUserHash(context.Background(), "user").Return(int64(1), hash, nil)
+				}
+			}
+			if tc.want.saved != nil {
+				m6.EXPECT().SaveLoginAttempts(context.Background(), "user", gomock.Any()).DoAndReturn(
+					func(_ context.Context, _ string, attempts models.LoginAttemptsModel) error {
+						assert.Equal(t, tc.want.saved.Failures, attempts.Failures)
+						assert.WithinDuration(t, time.Now(), attempts.LastFailure, time.Second)
+						return nil
+					})
+			}
+			if tc.want.reset {
+				m6.EXPECT().ResetLoginAttempts(context.Background(), "user").Return(nil)
+			}
+			service := New(&client.KeeperClient{}, nil, m6, nil, nil, nil, nil, nil)
+			service.SetHasher(fastArgon)
+			service.SetLoginPolicy(policy)
+			_, err := service.LoginUser("user", tc.pass)
+			assert.ErrorIs(t, err, tc.want.err)
+			var delayErr *LoginDelayError
+			if tc.want.err == nil {
+				assert.False(t, errors.As(err, &delayErr))
+				return
+			}
+			assert.True(t, errors.As(err, &delayErr))
+			assert.InDelta(t, tc.want.delay, delayErr.Delay, float64(time.Second))
+			assert.Equal(t, tc.want.locked, delayErr.Locked)
+		})
+	}
+}
+
+func TestGenerateOTP(t *testing.T) {
+	type want struct {
+		code      string
+		remaining int
+		counter   uint64
+		err       error
+	}
+	type test struct {
+		name string
+		otp  models.OTPModel
+		err  error
+		want want
+	}
+	tests := []test{
+		{
+			name: "Test GenerateOTP function #1; Totp",
+			otp: models.OTPModel{Name: "github", Type: otp.TypeTOTP, Secret: "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ",
+				Algorithm: "SHA1", Digits: 8, Period: 30},
+			want: want{
+				code:      "94287082",
+				remaining: 1,
+			},
+		},
+		{
+			name: "Test GenerateOTP function #2; Hotp increments counter",
+			otp: models.OTPModel{Name: "vpn", Type: otp.TypeHOTP, Secret: "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ",
+				Algorithm: "SHA1", Digits: 6, Counter: 1},
+			want: want{
+				code:    "287082",
+				counter: 2,
+			},
+		},
+		{
+			name: "Test GenerateOTP function #3; Not found",
+			otp:  models.OTPModel{Name: "none"},
+			err:  storage.ErrOTPNotExist,
+			want: want{
+				err: storage.ErrOTPNotExist,
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			m7 := NewMockOTPStorage(ctrl)
+			m7.EXPECT().GetOTPByName(context.Background(), tc.otp.Name, int64(1)).Return(tc.otp, tc.err)
+			if tc.want.counter != 0 {
+				updated := tc.otp
+				updated.Counter = tc.want.counter
+				m7.EXPECT().UpdateOTP(context.Background(), updated, int64(1)).Return(nil)
+			}
+			service := New(&client.KeeperClient{}, nil, nil, nil, nil, nil, nil, m7)
+			code, remaining, err := service.GenerateOTP(tc.otp.Name, 1, time.Unix(59, 0))
+			assert.ErrorIs(t, err, tc.want.err)
+			assert.Equal(t, tc.want.code, code)
+			assert.Equal(t, tc.want.remaining, remaining)
+		})
+	}
+}
+
+func TestLogReveal(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	stor := NewMockStorage(ctrl)
+	var logged []models.AccessLogModel
+	stor.EXPECT().LogAccess(context.Background(), gomock.Any(), int64(3)).DoAndReturn(
+		func(_ context.Context, entry models.AccessLogModel, _ int64) error {
+			logged = append(logged, entry)
+			return nil
+		}).Times(2)
+	service := New(&client.KeeperClient{}, stor, nil, nil, nil, nil, nil, nil)
+
+	assert.NoError(t, service.LogReveal(3, AccessKindCard, "visa", "mir"))
+	assert.Len(t, logged, 2)
+	assert.Equal(t, "visa", logged[0].Name)
+	assert.Equal(t, AccessKindCard, logged[1].Kind)
+	assert.Equal(t, AccessReveal, logged[1].Action)
+	assert.False(t, logged[0].At.IsZero())
+
+	stor.EXPECT().LogAccess(context.Background(), gomock.Any(), int64(3)).Return(errors.New("disk full"))
+	assert.Error(t, service.LogReveal(3, AccessKindLogin, "mail", "bank"))
+}
+
+func TestMoveFolder(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	stor := NewMockStorage(ctrl)
+	stor.EXPECT().MoveFolder(gomock.Any(), "Work/Banks", "Banks", int64(1)).Return(nil)
+	stor.EXPECT().MoveFolder(gomock.Any(), "Work/Banks", "Work/Finance", int64(1)).Return(nil)
+	service := New(&client.KeeperClient{}, stor, nil, nil, nil, nil, nil, nil)
+
+	assert.NoError(t, service.MoveFolder("Work/Banks", "/", 1))
+	assert.NoError(t, service.RenameFolder("/Work/Banks/", "Finance", 1))
+	assert.NoError(t, service.MoveFolder("Work/Banks", "Work", 1))
+	assert.ErrorIs(t, service.MoveFolder("Work", "Work/Banks", 1), folder.ErrCycle)
+	assert.ErrorIs(t, service.MoveFolder("/", "Work", 1), folder.ErrInvalid)
+	assert.ErrorIs(t, service.RenameFolder("Work", "a/b", 1), folder.ErrInvalid)
+}
+
+func TestRecordFilter(t *testing.T) {
+	filter, err := NewRecordFilter("Work", []string{"salary"})
+	assert.NoError(t, err)
+	assert.True(t, filter.Match("Work/Banks", []string{"visa", "salary"}))
+	assert.False(t, filter.Match("Workshop", []string{"salary"}))
+	assert.False(t, filter.Match("Work", []string{"visa"}))
+	assert.True(t, RecordFilter{}.Match("", nil))
+}
+
+func TestRestoreVersion(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	stor := NewMockStorage(ctrl)
+	aStor := NewMockAuthStorage(ctrl)
+	cStor := NewMockCardStorage(ctrl)
+	data := []byte(`{"Name":"github","Login":"gopher","Password":"old-secret","Tags":["work"]}`)
+	stor.EXPECT().GetHistoryVersion(context.Background(), KindLogin, "github", 2, int64(1)).
+		Return(models.HistoryModel{Version: 2, Data: data}, nil)
+	aStor.EXPECT().UpdateLogin(context.Background(), models.LoginModel{
+		Name: "github", Login: "gopher", Password: "old-secret",
+		Meta: map[string]string{}, Folder: models.RootFolder, Tags: []string{"work"},
+	}, int64(1)).Return(nil)
+	service := New(&client.KeeperClient{}, stor, nil, cStor, nil, nil, aStor, nil)
+
+	assert.NoError(t, service.RestoreVersion(KindLogin, "github", 2, 1))
+
+	// Версия карты, сохраненная до появления проверки реквизитов, восстанавливается без изменений.
+	data = []byte(`{"Name":"visa","Number":"1234 5678","Date":"2020-01","CVVCode":"7"}`)
+	stor.EXPECT().GetHistoryVersion(context.Background(), KindCard, "visa", 3, int64(1)).
+		Return(models.HistoryModel{Version: 3, Data: data}, nil)
+	cStor.EXPECT().UpdateCard(context.Background(), models.CardModel{
+		Name: "visa", Number: "1234 5678", Date: "2020-01", CVVCode: "7",
+		Meta: map[string]string{}, Folder: models.RootFolder, Tags: []string{},
+	}, int64(1)).Return(nil)
+	assert.NoError(t, service.RestoreVersion(KindCard, "visa", 3, 1))
+
+	stor.EXPECT().GetHistoryVersion(context.Background(), KindCard, "visa", 9, int64(1)).
+		Return(models.HistoryModel{}, storage.ErrVersionNotExist)
+	assert.ErrorIs(t, service.RestoreVersion(KindCard, "visa", 9, 1), storage.ErrVersionNotExist)
+	assert.ErrorIs(t, service.RestoreVersion("otp", "github", 1, 1), ErrUnknownKind)
+	_, err := service.History("note", "github", 1)
+	assert.ErrorIs(t, err, ErrUnknownKind)
+}
+
+func TestTrash(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	stor := NewMockStorage(ctrl)
+	keepClient := NewMockClient(ctrl)
+	service := New(keepClient, stor, nil, nil, nil, nil, nil, nil)
+	service.SetTrashRetention(48 * time.Hour)
+
+	var purged []time.Time
+	stor.EXPECT().ClearDB(context.Background(), int64(1), gomock.Any()).DoAndReturn(
+		func(_ context.Context, _ int64, before time.Time) error {
+			purged = append(purged, before)
+			return nil
+		}).Times(2)
+	stor.EXPECT().GetChangedSaves(context.Background(), int64(1)).Return(models.SyncModel{}, nil).Times(2)
+	stor.EXPECT().GetConflicts(context.Background(), int64(1)).Return(nil, nil)
+	keepClient.EXPECT().Sync(context.Background(), models.SyncModel{}, int64(1)).Return(models.SyncModel{}, nil)
+	stor.EXPECT().Sync(context.Background(), models.SyncModel{}).Return(nil)
+	stor.EXPECT().MarkSynced(context.Background(), models.SyncModel{}, int64(1)).Return(nil)
+	stor.EXPECT().ClearRenames(context.Background(), int64(1)).Return(nil)
+
+	assert.NoError(t, service.SyncBD(1))
+	assert.NoError(t, service.EmptyTrash(1, 0))
+	assert.WithinDuration(t, time.Now().Add(-48*time.Hour), purged[0], time.Minute)
+	assert.WithinDuration(t, time.Now(), purged[1], time.Minute)
+
+	stor.EXPECT().RestoreDeleted(context.Background(), KindText, "note", int64(1)).Return(storage.ErrTextNotExist)
+	assert.ErrorIs(t, service.RestoreDeleted(KindText, "note", 1), storage.ErrTextNotExist)
+	assert.ErrorIs(t, service.RestoreDeleted("folder", "Work", 1), ErrUnknownKind)
+}
+
+func TestRename(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	stor := NewMockStorage(ctrl)
+	service := New(&client.KeeperClient{}, stor, nil, nil, nil, nil, nil, nil)
+
+	stor.EXPECT().Rename(context.Background(), KindCard, "visa", "salary", int64(1)).Return(nil)
+	assert.NoError(t, service.Rename(KindCard, "visa", " salary ", 1))
+	stor.EXPECT().Rename(context.Background(), KindLogin, "github", "gitlab", int64(1)).Return(storage.ErrLoginAlredyExist)
+	assert.ErrorIs(t, service.Rename(KindLogin, "github", "gitlab", 1), storage.ErrLoginAlredyExist)
+
+	assert.NoError(t, service.Rename(KindText, "note", "note", 1))
+	assert.ErrorIs(t, service.Rename(KindText, "note", "  ", 1), ErrEmptyName)
+	stor.EXPECT().Rename(context.Background(), KindOTP, "github", "gitlab", int64(1)).Return(nil)
+	assert.NoError(t, service.Rename(KindOTP, "github", "gitlab", 1))
+	assert.ErrorIs(t, service.Rename("folder", "Work", "Home", 1), ErrUnknownKind)
+}
+
 // func TestSyncBD(t *testing.T) {
 // 	type want struct {
 // 		data models.SyncModel
@@ -718,14 +1410,14 @@ func TestGetBinByName(t *testing.T) {
 // 				Name:    "Test card",
 // 				Number:  "8899223344556677",
 // 				Date:    "08/29",
-// 				CVVCode: 654,
+// 				CVVCode: "654",
 // 			},
 // 			sCard: models.SyncCardModel{
 // 				UserID:  1,
 // 				Name:    "Test card",
 // 				Number:  "8899223344556677",
 // 				Date:    "08/29",
-// 				CVVCode: 654,
+// 				CVVCode: "654",
 // 				Deleted: false,
 // 				Updated: time.Now().Format(time.RFC3339),
 // 			},
@@ -815,3 +1507,147 @@ func TestGetBinByName(t *testing.T) {
 // 	}
 // 	return nil
 // }
+
+func TestSyncConflicts(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	stor := NewMockStorage(ctrl)
+	cStor := NewMockCardStorage(ctrl)
+	keepClient := NewMockClient(ctrl)
+	service := New(keepClient, stor, nil, cStor, nil, nil, nil, nil)
+
+	base := hlc.MustParse("2024-01-01T00:00:00Z")
+	localCard := models.SyncCardModel{UserID: 1, Name: "visa", UUID: "u1", Number: "1111", Updated: hlc.MustParse("2024-01-02T00:00:00Z"), Base: base}
+	remoteCard := models.SyncCardModel{UserID: 1, Name: "visa", UUID: "u1", Number: "2222", Updated: hlc.MustParse("2024-01-03T00:00:00Z")}
+	localLogin := models.SyncLoginModel{UserID: 1, Name: "github", UUID: "u2", Login: "gopher", Updated: hlc.MustParse("2024-01-02T00:00:00Z"), Base: base}
+	remoteLogin := models.SyncLoginModel{UserID: 1, Name: "github", UUID: "u2", Login: "gopher", Updated: hlc.MustParse("2024-01-03T00:00:00Z"),
+		Meta: map[string]string{}}
+	localText := models.SyncTextDataModel{UserID: 1, Name: "note", UUID: "u3", Data: "new", Updated: hlc.MustParse("2024-01-02T00:00:00Z"), Base: base}
+	remoteText := models.SyncTextDataModel{UserID: 1, Name: "note", UUID: "u3", Data: "old", Updated: base}
+	remoteBin := models.SyncBinaryDataModel{UserID: 1, Name: "key", UUID: "u4", Data: []byte("key"), Updated: base}
+
+	local := models.SyncModel{Cards: []models.SyncCardModel{localCard}, Auth: []models.SyncLoginModel{localLogin},
+		Texts: []models.SyncTextDataModel{localText}}
+	remote := models.SyncModel{Cards: []models.SyncCardModel{remoteCard}, Auth: []models.SyncLoginModel{remoteLogin},
+		Texts: []models.SyncTextDataModel{remoteText}, Bins: []models.SyncBinaryDataModel{remoteBin}}
+
+	t.Run("keep local", func(t *testing.T) {
+		var asked []models.ConflictModel
+		service.SetConflictResolver(func(c models.ConflictModel) (ConflictResolution, error) {
+			asked = append(asked, c)
+			return KeepLocal, nil
+		})
+		rebased := localCard
+		rebased.Updated, rebased.Base = hlc.MustParse("2024-01-03T00:00:01Z"), remoteCard.Updated
+		push := models.SyncModel{Cards: []models.SyncCardModel{rebased}, Texts: []models.SyncTextDataModel{localText}}
+		gomock.InOrder(
+			stor.EXPECT().GetChangedSaves(context.Background(), int64(1)).Return(local, nil),
+			stor.EXPECT().GetConflicts(context.Background(), int64(1)).Return(nil, nil),
+			keepClient.EXPECT().Sync(context.Background(), models.SyncModel{}, int64(1)).Return(remote, nil),
+			stor.EXPECT().Sync(context.Background(), models.SyncModel{Auth: []models.SyncLoginModel{remoteLogin},
+				Bins: []models.SyncBinaryDataModel{remoteBin}}).Return(nil),
+			stor.EXPECT().RebaseRecord(context.Background(), KindCard, "u1", remoteCard.Updated, int64(1)).Return(nil),
+			stor.EXPECT().DeleteConflict(context.Background(), KindCard, "u1", int64(1)).Return(nil),
+			stor.EXPECT().GetChangedSaves(context.Background(), int64(1)).Return(push, nil),
+		)
+		keepClient.EXPECT().Sync(context.Background(), push, int64(1)).Return(models.SyncModel{}, nil)
+		stor.EXPECT().Sync(context.Background(), models.SyncModel{}).Return(nil)
+		stor.EXPECT().MarkSynced(context.Background(), push, int64(1)).Return(nil)
+		stor.EXPECT().ClearRenames(context.Background(), int64(1)).Return(nil)
+		stor.EXPECT().ClearDB(context.Background(), int64(1), gomock.Any()).Return(nil)
+
+		assert.NoError(t, service.SyncBD(1))
+		if assert.Len(t, asked, 1) {
+			assert.Equal(t, "visa", asked[0].Name)
+			assert.Equal(t, KindCard, asked[0].Kind)
+			assert.Equal(t, []models.SyncCardModel{localCard}, asked[0].Local.Cards)
+			assert.Equal(t, []models.SyncCardModel{remoteCard}, asked[0].Remote.Cards)
+		}
+	})
+
+	t.Run("resolve later", func(t *testing.T) {
+		service.SetConflictResolver(nil)
+		push := models.SyncModel{Cards: []models.SyncCardModel{localCard}, Texts: []models.SyncTextDataModel{localText}}
+		gomock.InOrder(
+			stor.EXPECT().GetChangedSaves(context.Background(), int64(1)).Return(local, nil),
+			stor.EXPECT().GetConflicts(context.Background(), int64(1)).Return(nil, nil),
+			keepClient.EXPECT().Sync(context.Background(), models.SyncModel{}, int64(1)).Return(remote, nil),
+			stor.EXPECT().Sync(context.Background(), gomock.Any()).Return(nil),
+			stor.EXPECT().SaveConflict(context.Background(), gomock.Any(), int64(1)).Return(nil),
+			stor.EXPECT().GetChangedSaves(context.Background(), int64(1)).Return(push, nil),
+		)
+		// Запись с отложенным конфликтом не отправляется на сервер.
+		pushed := models.SyncModel{Texts: []models.SyncTextDataModel{localText}}
+		keepClient.EXPECT().Sync(context.Background(), pushed, int64(1)).Return(models.SyncModel{
+			Cards: []models.SyncCardModel{remoteCard}}, nil)
+		stor.EXPECT().Sync(context.Background(), models.SyncModel{}).Return(nil)
+		stor.EXPECT().MarkSynced(context.Background(), pushed, int64(1)).Return(nil)
+		stor.EXPECT().ClearRenames(context.Background(), int64(1)).Return(nil)
+		stor.EXPECT().ClearDB(context.Background(), int64(1), gomock.Any()).Return(nil)
+
+		assert.NoError(t, service.SyncBD(1))
+	})
+
+	t.Run("keep both", func(t *testing.T) {
+		stored := models.ConflictModel{Kind: KindCard, Name: "visa", UUID: "u1",
+			Remote: models.SyncModel{Cards: []models.SyncCardModel{remoteCard}}}
+		stor.EXPECT().GetConflicts(context.Background(), int64(1)).Return([]models.ConflictModel{stored}, nil).Times(3)
+		stor.EXPECT().GetChangedSaves(context.Background(), int64(1)).Return(local, nil).Times(3)
+
+		conflicts, err := service.Conflicts(1)
+		assert.NoError(t, err)
+		if assert.Len(t, conflicts, 1) {
+			assert.Equal(t, []models.SyncCardModel{localCard}, conflicts[0].Local.Cards)
+		}
+
+		cStor.EXPECT().SaveCard(context.Background(), gomock.Any(), int64(1)).DoAndReturn(
+			func(_ context.Context, card models.CardModel, _ int64) (int64, error) {
+				assert.True(t, strings.HasPrefix(card.Name, "visa (копия от "))
+				assert.Equal(t, "1111", card.Number)
+				return 2, nil
+			})
+		stor.EXPECT().Sync(context.Background(), stored.Remote).Return(nil)
+		stor.EXPECT().DeleteConflict(context.Background(), KindCard, "u1", int64(1)).Return(nil)
+		assert.NoError(t, service.ResolveConflict(KindCard, "visa", KeepBoth, 1))
+
+		assert.ErrorIs(t, service.ResolveConflict(KindCard, "mastercard", KeepRemote, 1), ErrConflictNotExist)
+		assert.ErrorIs(t, service.ResolveConflict(KindCard, "visa", ResolveLater, 1), ErrConflictPolicy)
+		assert.ErrorIs(t, service.ResolveConflict("folder", "visa", KeepRemote, 1), ErrUnknownKind)
+	})
+
+	t.Run("otp", func(t *testing.T) {
+		// Переименованная на сервере запись OTP сопоставляется с локальной по идентификатору, а не по имени.
+		localOTP := models.SyncOTPModel{UserID: 1, Name: "github", UUID: "u5", Type: "hotp", Secret: "JBSWY3DP", Counter: 3,
+			Updated: hlc.MustParse("2024-01-02T00:00:00Z"), Base: base}
+		remoteOTP := models.SyncOTPModel{UserID: 1, Name: "gitlab", UUID: "u5", Type: "hotp", Secret: "JBSWY3DP", Counter: 5,
+			Updated: hlc.MustParse("2024-01-03T00:00:00Z")}
+		var asked []models.ConflictModel
+		service.SetConflictResolver(func(c models.ConflictModel) (ConflictResolution, error) {
+			asked = append(asked, c)
+			return KeepRemote, nil
+		})
+		gomock.InOrder(
+			stor.EXPECT().GetChangedSaves(context.Background(), int64(1)).Return(
+				models.SyncModel{OTPs: []models.SyncOTPModel{localOTP}}, nil),
+			stor.EXPECT().GetConflicts(context.Background(), int64(1)).Return(nil, nil),
+			keepClient.EXPECT().Sync(context.Background(), models.SyncModel{}, int64(1)).Return(
+				models.SyncModel{OTPs: []models.SyncOTPModel{remoteOTP}}, nil),
+			stor.EXPECT().Sync(context.Background(), models.SyncModel{}).Return(nil),
+			stor.EXPECT().Sync(context.Background(), models.SyncModel{OTPs: []models.SyncOTPModel{remoteOTP}}).Return(nil),
+			stor.EXPECT().DeleteConflict(context.Background(), KindOTP, "u5", int64(1)).Return(nil),
+			stor.EXPECT().GetChangedSaves(context.Background(), int64(1)).Return(models.SyncModel{}, nil),
+			stor.EXPECT().MarkSynced(context.Background(), models.SyncModel{}, int64(1)).Return(nil),
+			stor.EXPECT().ClearRenames(context.Background(), int64(1)).Return(nil),
+			stor.EXPECT().ClearDB(context.Background(), int64(1), gomock.Any()).Return(nil),
+		)
+
+		assert.NoError(t, service.SyncBD(1))
+		if assert.Len(t, asked, 1) {
+			assert.Equal(t, KindOTP, asked[0].Kind)
+			assert.Equal(t, "github", asked[0].Name)
+		}
+	})
+
+	_, err := ParseConflictResolution("theirs")
+	assert.ErrorIs(t, err, ErrConflictPolicy)
+}
@@ -0,0 +1,14 @@
+DROP INDEX IF EXISTS idx_renames_name;
+DROP TABLE IF EXISTS renames;
+
+DROP INDEX IF EXISTS idx_card_uuid;
+ALTER TABLE cards DROP COLUMN uuid;
+
+DROP INDEX IF EXISTS idx_login_uuid;
+ALTER TABLE logins DROP COLUMN uuid;
+
+DROP INDEX IF EXISTS idx_text_uuid;
+ALTER TABLE text_data DROP COLUMN uuid;
+
+DROP INDEX IF EXISTS idx_binares_uuid;
+ALTER TABLE binares_data DROP COLUMN uuid;
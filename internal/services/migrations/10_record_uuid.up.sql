@@ -0,0 +1,28 @@
+ALTER TABLE cards ADD COLUMN uuid TEXT;
+UPDATE cards SET uuid = lower(hex(randomblob(4)) || '-' || hex(randomblob(2)) || '-4' || substr(hex(randomblob(2)), 2) || '-' ||
+    substr('89ab', 1 + abs(random()) % 4, 1) || substr(hex(randomblob(2)), 2) || '-' || hex(randomblob(6))) WHERE uuid IS NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_card_uuid ON cards (uuid);
+
+ALTER TABLE logins ADD COLUMN uuid TEXT;
+UPDATE logins SET uuid = lower(hex(randomblob(4)) || '-' || hex(randomblob(2)) || '-4' || substr(hex(randomblob(2)), 2) || '-' ||
+    substr('89ab', 1 + abs(random()) % 4, 1) || substr(hex(randomblob(2)), 2) || '-' || hex(randomblob(6))) WHERE uuid IS NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_login_uuid ON logins (uuid);
+
+ALTER TABLE text_data ADD COLUMN uuid TEXT;
+UPDATE text_data SET uuid = lower(hex(randomblob(4)) || '-' || hex(randomblob(2)) || '-4' || substr(hex(randomblob(2)), 2) || '-' ||
+    substr('89ab', 1 + abs(random()) % 4, 1) || substr(hex(randomblob(2)), 2) || '-' || hex(randomblob(6))) WHERE uuid IS NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_text_uuid ON text_data (uuid);
+
+ALTER TABLE binares_data ADD COLUMN uuid TEXT;
+UPDATE binares_data SET uuid = lower(hex(randomblob(4)) || '-' || hex(randomblob(2)) || '-4' || substr(hex(randomblob(2)), 2) || '-' ||
+    substr('89ab', 1 + abs(random()) % 4, 1) || substr(hex(randomblob(2)), 2) || '-' || hex(randomblob(6))) WHERE uuid IS NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_binares_uuid ON binares_data (uuid);
+
+CREATE TABLE IF NOT EXISTS renames (
+    rId INTEGER PRIMARY KEY,
+    kind TEXT,
+    name TEXT,
+    uId INTEGER,
+    last_update TEXT
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_renames_name ON renames (uId, kind, name);
@@ -0,0 +1,11 @@
+DROP INDEX IF EXISTS idx_card_user_name;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_card_name ON cards(name);
+
+DROP INDEX IF EXISTS idx_login_user_name;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_login_name ON logins (name);
+
+DROP INDEX IF EXISTS idx_text_user_name;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_text_name ON text_data (name);
+
+DROP INDEX IF EXISTS idx_binares_user_name;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_binares_name ON binares_data (name);
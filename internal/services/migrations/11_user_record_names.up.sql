@@ -0,0 +1,11 @@
+DROP INDEX IF EXISTS idx_card_name;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_card_user_name ON cards (uId, name);
+
+DROP INDEX IF EXISTS idx_login_name;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_login_user_name ON logins (uId, name);
+
+DROP INDEX IF EXISTS idx_text_name;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_text_user_name ON text_data (uId, name);
+
+DROP INDEX IF EXISTS idx_binares_name;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_binares_user_name ON binares_data (uId, name);
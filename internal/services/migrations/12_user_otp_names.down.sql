@@ -0,0 +1 @@
+CREATE UNIQUE INDEX IF NOT EXISTS idx_otp_user_name ON otp (uId, name);
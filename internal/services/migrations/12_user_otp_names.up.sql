@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_otp_name;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_otp_user_name ON otp (uId, name);
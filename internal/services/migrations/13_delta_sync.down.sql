@@ -0,0 +1,17 @@
+DROP TABLE IF EXISTS sync_state;
+
+ALTER TABLE folders DROP COLUMN synced_update;
+
+ALTER TABLE otp DROP COLUMN synced_update;
+
+DROP INDEX IF EXISTS idx_binares_sync;
+ALTER TABLE binares_data DROP COLUMN synced_update;
+
+DROP INDEX IF EXISTS idx_text_sync;
+ALTER TABLE text_data DROP COLUMN synced_update;
+
+DROP INDEX IF EXISTS idx_login_sync;
+ALTER TABLE logins DROP COLUMN synced_update;
+
+DROP INDEX IF EXISTS idx_card_sync;
+ALTER TABLE cards DROP COLUMN synced_update;
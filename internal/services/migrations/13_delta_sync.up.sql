@@ -0,0 +1,20 @@
+ALTER TABLE cards ADD COLUMN synced_update TEXT;
+CREATE INDEX IF NOT EXISTS idx_card_sync ON cards (uId, last_update, synced_update);
+
+ALTER TABLE logins ADD COLUMN synced_update TEXT;
+CREATE INDEX IF NOT EXISTS idx_login_sync ON logins (uId, last_update, synced_update);
+
+ALTER TABLE text_data ADD COLUMN synced_update TEXT;
+CREATE INDEX IF NOT EXISTS idx_text_sync ON text_data (uId, last_update, synced_update);
+
+ALTER TABLE binares_data ADD COLUMN synced_update TEXT;
+CREATE INDEX IF NOT EXISTS idx_binares_sync ON binares_data (uId, last_update, synced_update);
+
+ALTER TABLE otp ADD COLUMN synced_update TEXT;
+
+ALTER TABLE folders ADD COLUMN synced_update TEXT;
+
+CREATE TABLE IF NOT EXISTS sync_state (
+    uId INTEGER PRIMARY KEY,
+    cursor TEXT NOT NULL
+);
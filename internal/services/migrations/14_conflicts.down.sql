@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_conflict_record;
+DROP TABLE IF EXISTS conflicts;
@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS conflicts (
+    cfId INTEGER PRIMARY KEY,
+    kind TEXT NOT NULL,
+    record_uuid TEXT NOT NULL,
+    name TEXT NOT NULL,
+    data TEXT NOT NULL,
+    uId INTEGER NOT NULL,
+    detected_at TEXT NOT NULL
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_conflict_record ON conflicts (uId, kind, record_uuid);
@@ -0,0 +1,21 @@
+UPDATE cards SET last_update = substr(last_update, 1, 19) || 'Z' WHERE last_update LIKE '%/%';
+UPDATE cards SET synced_update = substr(synced_update, 1, 19) || 'Z' WHERE synced_update LIKE '%/%';
+
+UPDATE logins SET last_update = substr(last_update, 1, 19) || 'Z' WHERE last_update LIKE '%/%';
+UPDATE logins SET synced_update = substr(synced_update, 1, 19) || 'Z' WHERE synced_update LIKE '%/%';
+
+UPDATE text_data SET last_update = substr(last_update, 1, 19) || 'Z' WHERE last_update LIKE '%/%';
+UPDATE text_data SET synced_update = substr(synced_update, 1, 19) || 'Z' WHERE synced_update LIKE '%/%';
+
+UPDATE binares_data SET last_update = substr(last_update, 1, 19) || 'Z' WHERE last_update LIKE '%/%';
+UPDATE binares_data SET synced_update = substr(synced_update, 1, 19) || 'Z' WHERE synced_update LIKE '%/%';
+
+UPDATE otp SET last_update = substr(last_update, 1, 19) || 'Z' WHERE last_update LIKE '%/%';
+UPDATE otp SET synced_update = substr(synced_update, 1, 19) || 'Z' WHERE synced_update LIKE '%/%';
+
+UPDATE folders SET last_update = substr(last_update, 1, 19) || 'Z' WHERE last_update LIKE '%/%';
+UPDATE folders SET synced_update = substr(synced_update, 1, 19) || 'Z' WHERE synced_update LIKE '%/%';
+
+UPDATE renames SET last_update = substr(last_update, 1, 19) || 'Z' WHERE last_update LIKE '%/%';
+
+DROP TABLE IF EXISTS clock;
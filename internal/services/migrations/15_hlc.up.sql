@@ -0,0 +1,38 @@
+CREATE TABLE IF NOT EXISTS clock (
+    device TEXT NOT NULL,
+    last TEXT NOT NULL
+);
+INSERT INTO clock (device, last) SELECT lower(hex(randomblob(8))), '' WHERE NOT EXISTS (SELECT 1 FROM clock);
+
+UPDATE cards SET last_update = strftime('%Y-%m-%dT%H:%M:%fZ', last_update) || '/0000/0000000000000000'
+WHERE strftime('%Y-%m-%dT%H:%M:%fZ', last_update) IS NOT NULL;
+UPDATE cards SET synced_update = strftime('%Y-%m-%dT%H:%M:%fZ', synced_update) || '/0000/0000000000000000'
+WHERE strftime('%Y-%m-%dT%H:%M:%fZ', synced_update) IS NOT NULL;
+
+UPDATE logins SET last_update = strftime('%Y-%m-%dT%H:%M:%fZ', last_update) || '/0000/0000000000000000'
+WHERE strftime('%Y-%m-%dT%H:%M:%fZ', last_update) IS NOT NULL;
+UPDATE logins SET synced_update = strftime('%Y-%m-%dT%H:%M:%fZ', synced_update) || '/0000/0000000000000000'
+WHERE strftime('%Y-%m-%dT%H:%M:%fZ', synced_update) IS NOT NULL;
+
+UPDATE text_data SET last_update = strftime('%Y-%m-%dT%H:%M:%fZ', last_update) || '/0000/0000000000000000'
+WHERE strftime('%Y-%m-%dT%H:%M:%fZ', last_update) IS NOT NULL;
+UPDATE text_data SET synced_update = strftime('%Y-%m-%dT%H:%M:%fZ', synced_update) || '/0000/0000000000000000'
+WHERE strftime('%Y-%m-%dT%H:%M:%fZ', synced_update) IS NOT NULL;
+
+UPDATE binares_data SET last_update = strftime('%Y-%m-%dT%H:%M:%fZ', last_update) || '/0000/0000000000000000'
+WHERE strftime('%Y-%m-%dT%H:%M:%fZ', last_update) IS NOT NULL;
+UPDATE binares_data SET synced_update = strftime('%Y-%m-%dT%H:%M:%fZ', synced_update) || '/0000/0000000000000000'
+WHERE strftime('%Y-%m-%dT%H:%M:%fZ', synced_update) IS NOT NULL;
+
+UPDATE otp SET last_update = strftime('%Y-%m-%dT%H:%M:%fZ', last_update) || '/0000/0000000000000000'
+WHERE strftime('%Y-%m-%dT%H:%M:%fZ', last_update) IS NOT NULL;
+UPDATE otp SET synced_update = strftime('%Y-%m-%dT%H:%M:%fZ', synced_update) || '/0000/0000000000000000'
+WHERE strftime('%Y-%m-%dT%H:%M:%fZ', synced_update) IS NOT NULL;
+
+UPDATE folders SET last_update = strftime('%Y-%m-%dT%H:%M:%fZ', last_update) || '/0000/0000000000000000'
+WHERE strftime('%Y-%m-%dT%H:%M:%fZ', last_update) IS NOT NULL;
+UPDATE folders SET synced_update = strftime('%Y-%m-%dT%H:%M:%fZ', synced_update) || '/0000/0000000000000000'
+WHERE strftime('%Y-%m-%dT%H:%M:%fZ', synced_update) IS NOT NULL;
+
+UPDATE renames SET last_update = strftime('%Y-%m-%dT%H:%M:%fZ', last_update) || '/0000/0000000000000000'
+WHERE strftime('%Y-%m-%dT%H:%M:%fZ', last_update) IS NOT NULL;
@@ -0,0 +1,11 @@
+DROP INDEX IF EXISTS idx_card_user_uuid;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_card_uuid ON cards (uuid);
+
+DROP INDEX IF EXISTS idx_login_user_uuid;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_login_uuid ON logins (uuid);
+
+DROP INDEX IF EXISTS idx_text_user_uuid;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_text_uuid ON text_data (uuid);
+
+DROP INDEX IF EXISTS idx_binares_user_uuid;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_binares_uuid ON binares_data (uuid);
@@ -0,0 +1,11 @@
+DROP INDEX IF EXISTS idx_card_uuid;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_card_user_uuid ON cards (uId, uuid);
+
+DROP INDEX IF EXISTS idx_login_uuid;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_login_user_uuid ON logins (uId, uuid);
+
+DROP INDEX IF EXISTS idx_text_uuid;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_text_user_uuid ON text_data (uId, uuid);
+
+DROP INDEX IF EXISTS idx_binares_uuid;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_binares_user_uuid ON binares_data (uId, uuid);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_otp_user_uuid;
+ALTER TABLE otp DROP COLUMN uuid;
@@ -0,0 +1,4 @@
+ALTER TABLE otp ADD COLUMN uuid TEXT;
+UPDATE otp SET uuid = lower(hex(randomblob(4)) || '-' || hex(randomblob(2)) || '-4' || substr(hex(randomblob(2)), 2) || '-' ||
+    substr('89ab', 1 + abs(random()) % 4, 1) || substr(hex(randomblob(2)), 2) || '-' || hex(randomblob(6))) WHERE uuid IS NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_otp_user_uuid ON otp (uId, uuid);
@@ -0,0 +1 @@
+ALTER TABLE users ADD COLUMN fields_sealed INTEGER NOT NULL DEFAULT 0;
@@ -0,0 +1,4 @@
+CREATE TABLE IF NOT EXISTS sync_state (
+    uId INTEGER PRIMARY KEY,
+    cursor TEXT NOT NULL
+);
@@ -0,0 +1,2 @@
+ALTER TABLE users DROP COLUMN kdf_params;
+ALTER TABLE users DROP COLUMN kdf_salt;
@@ -0,0 +1,2 @@
+ALTER TABLE users ADD COLUMN kdf_salt BLOB;
+ALTER TABLE users ADD COLUMN kdf_params TEXT;
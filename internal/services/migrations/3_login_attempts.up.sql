@@ -0,0 +1,6 @@
+CREATE TABLE IF NOT EXISTS login_attempts (
+    login TEXT PRIMARY KEY,
+    failures INTEGER NOT NULL DEFAULT 0,
+    last_failure TEXT,
+    locked_until TEXT
+);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_otp_user_name;
+DROP TABLE IF EXISTS otp;
@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS otp (
+    oId INTEGER PRIMARY KEY,
+    name TEXT,
+    type TEXT,
+    secret TEXT,
+    issuer TEXT,
+    account TEXT,
+    algorithm TEXT,
+    digits INTEGER,
+    period INTEGER,
+    counter INTEGER,
+    uId INTEGER,
+    deleted INTEGER,
+    last_update TEXT
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_otp_user_name ON otp (uId, name);
@@ -0,0 +1,15 @@
+CREATE TABLE cards_old (
+    cId INTEGER PRIMARY KEY, 
+    name TEXT, 
+    number TEXT, 
+    date TEXT, 
+    cvv INTEGER,
+    uId INTEGER,
+    deleted INTEGER,
+    last_update TEXT
+);
+INSERT INTO cards_old (cId, name, number, date, cvv, uId, deleted, last_update)
+    SELECT cId, name, number, date, cvv, uId, deleted, last_update FROM cards;
+DROP TABLE cards;
+ALTER TABLE cards_old RENAME TO cards;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_card_name ON cards(name);
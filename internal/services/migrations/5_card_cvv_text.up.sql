@@ -0,0 +1,15 @@
+CREATE TABLE cards_new (
+    cId INTEGER PRIMARY KEY, 
+    name TEXT, 
+    number TEXT, 
+    date TEXT, 
+    cvv TEXT,
+    uId INTEGER,
+    deleted INTEGER,
+    last_update TEXT
+);
+INSERT INTO cards_new (cId, name, number, date, cvv, uId, deleted, last_update)
+    SELECT cId, name, number, date, CAST(cvv AS TEXT), uId, deleted, last_update FROM cards;
+DROP TABLE cards;
+ALTER TABLE cards_new RENAME TO cards;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_card_name ON cards(name);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_access_log_user;
+DROP TABLE IF EXISTS access_log;
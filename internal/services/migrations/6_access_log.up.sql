@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS access_log (
+    aId INTEGER PRIMARY KEY,
+    uId INTEGER,
+    kind TEXT,
+    name TEXT,
+    action TEXT,
+    at TEXT
+);
+CREATE INDEX IF NOT EXISTS idx_access_log_user ON access_log (uId, at);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_metadata_key;
+DROP TABLE IF EXISTS metadata;
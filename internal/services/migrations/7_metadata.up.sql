@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS metadata (
+    mId INTEGER PRIMARY KEY,
+    kind TEXT,
+    record_id INTEGER,
+    key TEXT,
+    value TEXT,
+    uId INTEGER
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_metadata_key ON metadata (kind, record_id, key);
@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS idx_record_tag_name;
+DROP INDEX IF EXISTS idx_record_tag;
+DROP TABLE IF EXISTS record_tags;
+DROP INDEX IF EXISTS idx_record_folder;
+DROP TABLE IF EXISTS record_folders;
+DROP INDEX IF EXISTS idx_folder_path;
+DROP TABLE IF EXISTS folders;
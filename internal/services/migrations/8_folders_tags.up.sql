@@ -0,0 +1,25 @@
+CREATE TABLE IF NOT EXISTS folders (
+    fId INTEGER PRIMARY KEY,
+    path TEXT,
+    uId INTEGER,
+    deleted INTEGER,
+    last_update TEXT
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_folder_path ON folders (uId, path);
+
+CREATE TABLE IF NOT EXISTS record_folders (
+    kind TEXT,
+    record_id INTEGER,
+    folder TEXT,
+    uId INTEGER
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_record_folder ON record_folders (kind, record_id);
+
+CREATE TABLE IF NOT EXISTS record_tags (
+    kind TEXT,
+    record_id INTEGER,
+    tag TEXT,
+    uId INTEGER
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_record_tag ON record_tags (kind, record_id, tag);
+CREATE INDEX IF NOT EXISTS idx_record_tag_name ON record_tags (uId, tag);
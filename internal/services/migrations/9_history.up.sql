@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS history (
+    hId INTEGER PRIMARY KEY,
+    kind TEXT,
+    record_id INTEGER,
+    version INTEGER,
+    data TEXT,
+    uId INTEGER,
+    created_at TEXT
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_history_version ON history (kind, record_id, version);
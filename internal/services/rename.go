@@ -0,0 +1,27 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	errText "github.com/Dorrrke/GophKeeper-client/internal/domain/errors"
+)
+
+var ErrEmptyName = errors.New(errText.EmptyNameError)
+
+// Rename меняет имя записи, сохраняя ее идентификатор, метаданные и историю.
+// При синхронизации сервер и другие устройства получают переименование, а не удаление и новую запись.
+func (kp *KeepService) Rename(kind string, oldName string, newName string, uID int64) error {
+	if !identifiedKind(kind) {
+		return ErrUnknownKind
+	}
+	newName = strings.TrimSpace(newName)
+	if newName == "" {
+		return ErrEmptyName
+	}
+	if newName == oldName {
+		return nil
+	}
+	return kp.stor.Rename(context.Background(), kind, oldName, newName, uID)
+}
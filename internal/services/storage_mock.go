@@ -7,8 +7,10 @@ package services
 import (
 	context "context"
 	reflect "reflect"
+	time "time"
 
 	models "github.com/Dorrrke/GophKeeper-client/internal/domain/models"
+	hlc "github.com/Dorrrke/GophKeeper-client/internal/hlc"
 	gomock "github.com/golang/mock/gomock"
 )
 
@@ -36,32 +38,290 @@ func (m *MockStorage) EXPECT() *MockStorageMockRecorder {
 }
 
 // ClearDB mocks base method.
-func (m *MockStorage) ClearDB(ctx context.Context, uId int64) error {
+func (m *MockStorage) ClearDB(ctx context.Context, uId int64, before time.Time) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ClearDB", ctx, uId)
+	ret := m.ctrl.Call(m, "ClearDB", ctx, uId, before)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // ClearDB indicates an expected call of ClearDB.
-func (mr *MockStorageMockRecorder) ClearDB(ctx, uId interface{}) *gomock.Call {
+func (mr *MockStorageMockRecorder) ClearDB(ctx, uId, before interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClearDB", reflect.TypeOf((*MockStorage)(nil).ClearDB), ctx, uId)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClearDB", reflect.TypeOf((*MockStorage)(nil).ClearDB), ctx, uId, before)
 }
 
-// GetAllSaves mocks base method.
-func (m *MockStorage) GetAllSaves(ctx context.Context, uID int64) (models.SyncModel, error) {
+// ClearRenames mocks base method.
+func (m *MockStorage) ClearRenames(ctx context.Context, uID int64) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetAllSaves", ctx, uID)
+	ret := m.ctrl.Call(m, "ClearRenames", ctx, uID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ClearRenames indicates an expected call of ClearRenames.
+func (mr *MockStorageMockRecorder) ClearRenames(ctx, uID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClearRenames", reflect.TypeOf((*MockStorage)(nil).ClearRenames), ctx, uID)
+}
+
+// CreateFolder mocks base method.
+func (m *MockStorage) CreateFolder(ctx context.Context, path string, uID int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateFolder", ctx, path, uID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateFolder indicates an expected call of CreateFolder.
+func (mr *MockStorageMockRecorder) CreateFolder(ctx, path, uID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateFolder", reflect.TypeOf((*MockStorage)(nil).CreateFolder), ctx, path, uID)
+}
+
+// DeleteConflict mocks base method.
+func (m *MockStorage) DeleteConflict(ctx context.Context, kind, uuid string, uID int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteConflict", ctx, kind, uuid, uID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteConflict indicates an expected call of DeleteConflict.
+func (mr *MockStorageMockRecorder) DeleteConflict(ctx, kind, uuid, uID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteConflict", reflect.TypeOf((*MockStorage)(nil).DeleteConflict), ctx, kind, uuid, uID)
+}
+
+// EncryptExisting mocks base method.
+func (m *MockStorage) EncryptExisting(ctx context.Context, uID int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EncryptExisting", ctx, uID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// EncryptExisting indicates an expected call of EncryptExisting.
+func (mr *MockStorageMockRecorder) EncryptExisting(ctx, uID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EncryptExisting", reflect.TypeOf((*MockStorage)(nil).EncryptExisting), ctx, uID)
+}
+
+// GetAccessLog mocks base method.
+func (m *MockStorage) GetAccessLog(ctx context.Context, uID int64, limit int) ([]models.AccessLogModel, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAccessLog", ctx, uID, limit)
+	ret0, _ := ret[0].([]models.AccessLogModel)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAccessLog indicates an expected call of GetAccessLog.
+func (mr *MockStorageMockRecorder) GetAccessLog(ctx, uID, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAccessLog", reflect.TypeOf((*MockStorage)(nil).GetAccessLog), ctx, uID, limit)
+}
+
+// GetChangedSaves mocks base method.
+func (m *MockStorage) GetChangedSaves(ctx context.Context, uID int64) (models.SyncModel, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetChangedSaves", ctx, uID)
 	ret0, _ := ret[0].(models.SyncModel)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetAllSaves indicates an expected call of GetAllSaves.
-func (mr *MockStorageMockRecorder) GetAllSaves(ctx, uID interface{}) *gomock.Call {
+// GetChangedSaves indicates an expected call of GetChangedSaves.
+func (mr *MockStorageMockRecorder) GetChangedSaves(ctx, uID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetChangedSaves", reflect.TypeOf((*MockStorage)(nil).GetChangedSaves), ctx, uID)
+}
+
+// GetConflicts mocks base method.
+func (m *MockStorage) GetConflicts(ctx context.Context, uID int64) ([]models.ConflictModel, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetConflicts", ctx, uID)
+	ret0, _ := ret[0].([]models.ConflictModel)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetConflicts indicates an expected call of GetConflicts.
+func (mr *MockStorageMockRecorder) GetConflicts(ctx, uID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetConflicts", reflect.TypeOf((*MockStorage)(nil).GetConflicts), ctx, uID)
+}
+
+// GetFolders mocks base method.
+func (m *MockStorage) GetFolders(ctx context.Context, uID int64) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetFolders", ctx, uID)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetFolders indicates an expected call of GetFolders.
+func (mr *MockStorageMockRecorder) GetFolders(ctx, uID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFolders", reflect.TypeOf((*MockStorage)(nil).GetFolders), ctx, uID)
+}
+
+// GetHistory mocks base method.
+func (m *MockStorage) GetHistory(ctx context.Context, kind, name string, uID int64) ([]models.HistoryModel, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetHistory", ctx, kind, name, uID)
+	ret0, _ := ret[0].([]models.HistoryModel)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetHistory indicates an expected call of GetHistory.
+func (mr *MockStorageMockRecorder) GetHistory(ctx, kind, name, uID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetHistory", reflect.TypeOf((*MockStorage)(nil).GetHistory), ctx, kind, name, uID)
+}
+
+// GetHistoryVersion mocks base method.
+func (m *MockStorage) GetHistoryVersion(ctx context.Context, kind, name string, version int, uID int64) (models.HistoryModel, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetHistoryVersion", ctx, kind, name, version, uID)
+	ret0, _ := ret[0].(models.HistoryModel)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetHistoryVersion indicates an expected call of GetHistoryVersion.
+func (mr *MockStorageMockRecorder) GetHistoryVersion(ctx, kind, name, version, uID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetHistoryVersion", reflect.TypeOf((*MockStorage)(nil).GetHistoryVersion), ctx, kind, name, version, uID)
+}
+
+// GetTrash mocks base method.
+func (m *MockStorage) GetTrash(ctx context.Context, uID int64) ([]models.TrashModel, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTrash", ctx, uID)
+	ret0, _ := ret[0].([]models.TrashModel)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTrash indicates an expected call of GetTrash.
+func (mr *MockStorageMockRecorder) GetTrash(ctx, uID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTrash", reflect.TypeOf((*MockStorage)(nil).GetTrash), ctx, uID)
+}
+
+// LogAccess mocks base method.
+func (m *MockStorage) LogAccess(ctx context.Context, entry models.AccessLogModel, uID int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LogAccess", ctx, entry, uID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// LogAccess indicates an expected call of LogAccess.
+func (mr *MockStorageMockRecorder) LogAccess(ctx, entry, uID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LogAccess", reflect.TypeOf((*MockStorage)(nil).LogAccess), ctx, entry, uID)
+}
+
+// MarkSynced mocks base method.
+func (m *MockStorage) MarkSynced(ctx context.Context, pushed models.SyncModel, uID int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkSynced", ctx, pushed, uID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MarkSynced indicates an expected call of MarkSynced.
+func (mr *MockStorageMockRecorder) MarkSynced(ctx, pushed, uID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkSynced", reflect.TypeOf((*MockStorage)(nil).MarkSynced), ctx, pushed, uID)
+}
+
+// MoveFolder mocks base method.
+func (m *MockStorage) MoveFolder(ctx context.Context, from, to string, uID int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MoveFolder", ctx, from, to, uID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MoveFolder indicates an expected call of MoveFolder.
+func (mr *MockStorageMockRecorder) MoveFolder(ctx, from, to, uID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MoveFolder", reflect.TypeOf((*MockStorage)(nil).MoveFolder), ctx, from, to, uID)
+}
+
+// RebaseRecord mocks base method.
+func (m *MockStorage) RebaseRecord(ctx context.Context, kind, uuid string, base hlc.Timestamp, uID int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RebaseRecord", ctx, kind, uuid, base, uID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RebaseRecord indicates an expected call of RebaseRecord.
+func (mr *MockStorageMockRecorder) RebaseRecord(ctx, kind, uuid, base, uID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RebaseRecord", reflect.TypeOf((*MockStorage)(nil).RebaseRecord), ctx, kind, uuid, base, uID)
+}
+
+// Rename mocks base method.
+func (m *MockStorage) Rename(ctx context.Context, kind, oldName, newName string, uID int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Rename", ctx, kind, oldName, newName, uID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Rename indicates an expected call of Rename.
+func (mr *MockStorageMockRecorder) Rename(ctx, kind, oldName, newName, uID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Rename", reflect.TypeOf((*MockStorage)(nil).Rename), ctx, kind, oldName, newName, uID)
+}
+
+// RestoreDeleted mocks base method.
+func (m *MockStorage) RestoreDeleted(ctx context.Context, kind, name string, uID int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RestoreDeleted", ctx, kind, name, uID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RestoreDeleted indicates an expected call of RestoreDeleted.
+func (mr *MockStorageMockRecorder) RestoreDeleted(ctx, kind, name, uID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RestoreDeleted", reflect.TypeOf((*MockStorage)(nil).RestoreDeleted), ctx, kind, name, uID)
+}
+
+// SaveConflict mocks base method.
+func (m *MockStorage) SaveConflict(ctx context.Context, conflict models.ConflictModel, uID int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveConflict", ctx, conflict, uID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveConflict indicates an expected call of SaveConflict.
+func (mr *MockStorageMockRecorder) SaveConflict(ctx, conflict, uID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveConflict", reflect.TypeOf((*MockStorage)(nil).SaveConflict), ctx, conflict, uID)
+}
+
+// SetVaultKey mocks base method.
+func (m *MockStorage) SetVaultKey(key []byte) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetVaultKey", key)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetVaultKey indicates an expected call of SetVaultKey.
+func (mr *MockStorageMockRecorder) SetVaultKey(key interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAllSaves", reflect.TypeOf((*MockStorage)(nil).GetAllSaves), ctx, uID)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetVaultKey", reflect.TypeOf((*MockStorage)(nil).SetVaultKey), key)
 }
 
 // Sync mocks base method.
@@ -101,6 +361,21 @@ func (m *MockUserStorage) EXPECT() *MockUserStorageMockRecorder {
 	return m.recorder
 }
 
+// GetLoginAttempts mocks base method.
+func (m *MockUserStorage) GetLoginAttempts(ctx context.Context, login string) (models.LoginAttemptsModel, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetLoginAttempts", ctx, login)
+	ret0, _ := ret[0].(models.LoginAttemptsModel)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetLoginAttempts indicates an expected call of GetLoginAttempts.
+func (mr *MockUserStorageMockRecorder) GetLoginAttempts(ctx, login interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLoginAttempts", reflect.TypeOf((*MockUserStorage)(nil).GetLoginAttempts), ctx, login)
+}
+
 // GetUserHash mocks base method.
 func (m *MockUserStorage) GetUserHash(ctx context.Context, login string) (int64, string, error) {
 	m.ctrl.T.Helper()
@@ -117,6 +392,64 @@ func (mr *MockUserStorageMockRecorder) GetUserHash(ctx, login interface{}) *gomo
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserHash", reflect.TypeOf((*MockUserStorage)(nil).GetUserHash), ctx, login)
 }
 
+// GetUserKDF mocks base method.
+func (m *MockUserStorage) GetUserKDF(ctx context.Context, uID int64) (models.KDFModel, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserKDF", ctx, uID)
+	ret0, _ := ret[0].(models.KDFModel)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserKDF indicates an expected call of GetUserKDF.
+func (mr *MockUserStorageMockRecorder) GetUserKDF(ctx, uID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserKDF", reflect.TypeOf((*MockUserStorage)(nil).GetUserKDF), ctx, uID)
+}
+
+// GetUsers mocks base method.
+func (m *MockUserStorage) GetUsers(ctx context.Context) ([]models.UserModel, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUsers", ctx)
+	ret0, _ := ret[0].([]models.UserModel)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUsers indicates an expected call of GetUsers.
+func (mr *MockUserStorageMockRecorder) GetUsers(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUsers", reflect.TypeOf((*MockUserStorage)(nil).GetUsers), ctx)
+}
+
+// ResetLoginAttempts mocks base method.
+func (m *MockUserStorage) ResetLoginAttempts(ctx context.Context, login string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ResetLoginAttempts", ctx, login)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ResetLoginAttempts indicates an expected call of ResetLoginAttempts.
+func (mr *MockUserStorageMockRecorder) ResetLoginAttempts(ctx, login interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResetLoginAttempts", reflect.TypeOf((*MockUserStorage)(nil).ResetLoginAttempts), ctx, login)
+}
+
+// SaveLoginAttempts mocks base method.
+func (m *MockUserStorage) SaveLoginAttempts(ctx context.Context, login string, attempts models.LoginAttemptsModel) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveLoginAttempts", ctx, login, attempts)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveLoginAttempts indicates an expected call of SaveLoginAttempts.
+func (mr *MockUserStorageMockRecorder) SaveLoginAttempts(ctx, login, attempts interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveLoginAttempts", reflect.TypeOf((*MockUserStorage)(nil).SaveLoginAttempts), ctx, login, attempts)
+}
+
 // SaveUser mocks base method.
 func (m *MockUserStorage) SaveUser(ctx context.Context, user models.UserModel) (int64, error) {
 	m.ctrl.T.Helper()
@@ -132,6 +465,34 @@ func (mr *MockUserStorageMockRecorder) SaveUser(ctx, user interface{}) *gomock.C
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveUser", reflect.TypeOf((*MockUserStorage)(nil).SaveUser), ctx, user)
 }
 
+// SaveUserKDF mocks base method.
+func (m *MockUserStorage) SaveUserKDF(ctx context.Context, uID int64, kdf models.KDFModel) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveUserKDF", ctx, uID, kdf)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveUserKDF indicates an expected call of SaveUserKDF.
+func (mr *MockUserStorageMockRecorder) SaveUserKDF(ctx, uID, kdf interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveUserKDF", reflect.TypeOf((*MockUserStorage)(nil).SaveUserKDF), ctx, uID, kdf)
+}
+
+// UpdateUserHash mocks base method.
+func (m *MockUserStorage) UpdateUserHash(ctx context.Context, uID int64, hash string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateUserHash", ctx, uID, hash)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateUserHash indicates an expected call of UpdateUserHash.
+func (mr *MockUserStorageMockRecorder) UpdateUserHash(ctx, uID, hash interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateUserHash", reflect.TypeOf((*MockUserStorage)(nil).UpdateUserHash), ctx, uID, hash)
+}
+
 // MockCardStorage is a mock of CardStorage interface.
 type MockCardStorage struct {
 	ctrl     *gomock.Controller
@@ -516,6 +877,102 @@ func (mr *MockTextStorageMockRecorder) UpdateText(ctx, data, uID interface{}) *g
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateText", reflect.TypeOf((*MockTextStorage)(nil).UpdateText), ctx, data, uID)
 }
 
+// MockOTPStorage is a mock of OTPStorage interface.
+type MockOTPStorage struct {
+	ctrl     *gomock.Controller
+	recorder *MockOTPStorageMockRecorder
+}
+
+// MockOTPStorageMockRecorder is the mock recorder for MockOTPStorage.
+type MockOTPStorageMockRecorder struct {
+	mock *MockOTPStorage
+}
+
+// NewMockOTPStorage creates a new mock instance.
+func NewMockOTPStorage(ctrl *gomock.Controller) *MockOTPStorage {
+	mock := &MockOTPStorage{ctrl: ctrl}
+	mock.recorder = &MockOTPStorageMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockOTPStorage) EXPECT() *MockOTPStorageMockRecorder {
+	return m.recorder
+}
+
+// DeleteOTP mocks base method.
+func (m *MockOTPStorage) DeleteOTP(ctx context.Context, name string, uID int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteOTP", ctx, name, uID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteOTP indicates an expected call of DeleteOTP.
+func (mr *MockOTPStorageMockRecorder) DeleteOTP(ctx, name, uID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteOTP", reflect.TypeOf((*MockOTPStorage)(nil).DeleteOTP), ctx, name, uID)
+}
+
+// GetAllOTP mocks base method.
+func (m *MockOTPStorage) GetAllOTP(ctx context.Context, uID int64) ([]models.OTPModel, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAllOTP", ctx, uID)
+	ret0, _ := ret[0].([]models.OTPModel)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAllOTP indicates an expected call of GetAllOTP.
+func (mr *MockOTPStorageMockRecorder) GetAllOTP(ctx, uID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAllOTP", reflect.TypeOf((*MockOTPStorage)(nil).GetAllOTP), ctx, uID)
+}
+
+// GetOTPByName mocks base method.
+func (m *MockOTPStorage) GetOTPByName(ctx context.Context, name string, uID int64) (models.OTPModel, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetOTPByName", ctx, name, uID)
+	ret0, _ := ret[0].(models.OTPModel)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetOTPByName indicates an expected call of GetOTPByName.
+func (mr *MockOTPStorageMockRecorder) GetOTPByName(ctx, name, uID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOTPByName", reflect.TypeOf((*MockOTPStorage)(nil).GetOTPByName), ctx, name, uID)
+}
+
+// SaveOTP mocks base method.
+func (m *MockOTPStorage) SaveOTP(ctx context.Context, otp models.OTPModel, uID int64) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveOTP", ctx, otp, uID)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SaveOTP indicates an expected call of SaveOTP.
+func (mr *MockOTPStorageMockRecorder) SaveOTP(ctx, otp, uID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveOTP", reflect.TypeOf((*MockOTPStorage)(nil).SaveOTP), ctx, otp, uID)
+}
+
+// UpdateOTP mocks base method.
+func (m *MockOTPStorage) UpdateOTP(ctx context.Context, otp models.OTPModel, uID int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateOTP", ctx, otp, uID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateOTP indicates an expected call of UpdateOTP.
+func (mr *MockOTPStorageMockRecorder) UpdateOTP(ctx, otp, uID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateOTP", reflect.TypeOf((*MockOTPStorage)(nil).UpdateOTP), ctx, otp, uID)
+}
+
 // MockClient is a mock of Client interface.
 type MockClient struct {
 	ctrl     *gomock.Controller
@@ -540,11 +997,12 @@ func (m *MockClient) EXPECT() *MockClientMockRecorder {
 }
 
 // Login mocks base method.
-func (m *MockClient) Login(ctx context.Context, login, password string) error {
+func (m *MockClient) Login(ctx context.Context, login, password string) (models.TokensModel, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "Login", ctx, login, password)
-	ret0, _ := ret[0].(error)
-	return ret0
+	ret0, _ := ret[0].(models.TokensModel)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
 // Login indicates an expected call of Login.
@@ -554,11 +1012,12 @@ func (mr *MockClientMockRecorder) Login(ctx, login, password interface{}) *gomoc
 }
 
 // Register mocks base method.
-func (m *MockClient) Register(ctx context.Context, login, password string) error {
+func (m *MockClient) Register(ctx context.Context, login, password string) (models.TokensModel, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "Register", ctx, login, password)
-	ret0, _ := ret[0].(error)
-	return ret0
+	ret0, _ := ret[0].(models.TokensModel)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
 // Register indicates an expected call of Register.
@@ -567,6 +1026,18 @@ func (mr *MockClientMockRecorder) Register(ctx, login, password interface{}) *go
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Register", reflect.TypeOf((*MockClient)(nil).Register), ctx, login, password)
 }
 
+// SetTokens mocks base method.
+func (m *MockClient) SetTokens(tokens models.TokensModel) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SetTokens", tokens)
+}
+
+// SetTokens indicates an expected call of SetTokens.
+func (mr *MockClientMockRecorder) SetTokens(tokens interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetTokens", reflect.TypeOf((*MockClient)(nil).SetTokens), tokens)
+}
+
 // Sync mocks base method.
 func (m *MockClient) Sync(ctx context.Context, model models.SyncModel, uID int64) (models.SyncModel, error) {
 	m.ctrl.T.Helper()
@@ -581,3 +1052,17 @@ func (mr *MockClientMockRecorder) Sync(ctx, model, uID interface{}) *gomock.Call
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Sync", reflect.TypeOf((*MockClient)(nil).Sync), ctx, model, uID)
 }
+
+// Tokens mocks base method.
+func (m *MockClient) Tokens() models.TokensModel {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Tokens")
+	ret0, _ := ret[0].(models.TokensModel)
+	return ret0
+}
+
+// Tokens indicates an expected call of Tokens.
+func (mr *MockClientMockRecorder) Tokens() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Tokens", reflect.TypeOf((*MockClient)(nil).Tokens))
+}
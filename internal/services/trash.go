@@ -0,0 +1,35 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"github.com/Dorrrke/GophKeeper-client/internal/domain/models"
+)
+
+// DefaultTrashRetention - время хранения удаленных записей в корзине по умолчанию.
+const DefaultTrashRetention = 30 * 24 * time.Hour
+
+// SetTrashRetention задает время, после которого удаленные записи окончательно удаляются при синхронизации.
+func (kp *KeepService) SetTrashRetention(retention time.Duration) {
+	kp.trashRetention = retention
+}
+
+// Trash возвращает удаленные записи, которые еще можно восстановить.
+func (kp *KeepService) Trash(uID int64) ([]models.TrashModel, error) {
+	return kp.stor.GetTrash(context.Background(), uID)
+}
+
+// RestoreDeleted возвращает запись из корзины.
+func (kp *KeepService) RestoreDeleted(kind string, name string, uID int64) error {
+	if !knownKind(kind) {
+		return ErrUnknownKind
+	}
+	return kp.stor.RestoreDeleted(context.Background(), kind, name, uID)
+}
+
+// EmptyTrash окончательно удаляет записи, пролежавшие в корзине дольше olderThan. 0 - удаляет все.
+// Записи, удаление которых еще не подтверждено сервером, остаются в корзине.
+func (kp *KeepService) EmptyTrash(uID int64, olderThan time.Duration) error {
+	return kp.stor.ClearDB(context.Background(), uID, time.Now().Add(-olderThan))
+}
@@ -0,0 +1,52 @@
+package storage
+
+import (
+	"context"
+	"time"
+
+	"github.com/Dorrrke/GophKeeper-client/internal/domain/models"
+)
+
+// LogAccess добавляет запись в журнал доступа пользователя.
+func (s *Storage) LogAccess(ctx context.Context, entry models.AccessLogModel, uID int64) error {
+	stmt, err := s.db.Prepare("INSERT INTO access_log(uId, kind, name, action, at) VALUES(?,?,?,?,?)")
+	if err != nil {
+		return err
+	}
+	_, err = stmt.ExecContext(ctx, uID, entry.Kind, entry.Name, entry.Action, entry.At.UTC().Format(time.RFC3339Nano))
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// GetAccessLog возвращает последние limit записей журнала доступа, начиная с самых новых.
+func (s *Storage) GetAccessLog(ctx context.Context, uID int64, limit int) ([]models.AccessLogModel, error) {
+	stmt, err := s.db.Prepare("SELECT kind, name, action, at FROM access_log WHERE uId = ? ORDER BY aId DESC LIMIT ?")
+	if err != nil {
+		return nil, err
+	}
+	rows, err := stmt.QueryContext(ctx, uID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var entries []models.AccessLogModel
+	for rows.Next() {
+		var entry models.AccessLogModel
+		var at string
+		if err := rows.Scan(&entry.Kind, &entry.Name, &entry.Action, &at); err != nil {
+			return nil, err
+		}
+		entry.At, err = time.Parse(time.RFC3339Nano, at)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
@@ -0,0 +1,147 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/Dorrrke/GophKeeper-client/internal/folder"
+	"github.com/Dorrrke/GophKeeper-client/internal/hlc"
+)
+
+// Виды записей, к которым привязываются метаданные, папки и теги.
+const (
+	kindCard  = "card"
+	kindLogin = "auth"
+	kindText  = "text"
+	kindBin   = "bin"
+	kindOTP   = "otp"
+)
+
+// recordTable - таблица записей одного вида и ее первичный ключ.
+type recordTable struct {
+	table string
+	idCol string
+}
+
+var recordTables = map[string]recordTable{
+	kindCard:  {table: "cards", idCol: "cId"},
+	kindLogin: {table: "logins", idCol: "lId"},
+	kindText:  {table: "text_data", idCol: "tId"},
+	kindBin:   {table: "binares_data", idCol: "bId"},
+	kindOTP:   {table: "otp", idCol: "oId"},
+}
+
+// attrTables - таблицы, строки которых привязаны к записям по виду и идентификатору.
+var attrTables = []string{"metadata", "record_folders", "record_tags", "history"}
+
+// recordAttrs - атрибуты записи, которые хранятся отдельно от нее: метаданные, папка и теги.
+type recordAttrs struct {
+	meta   map[string]string
+	folder string
+	tags   []string
+}
+
+// saveAttrs сохраняет атрибуты записи с указанным именем в транзакции tx, в которой сохраняется сама запись.
+// При replace все атрибуты заменяются, иначе меняются только заданные:
+// метаданные и теги, отличные от nil, и непустая папка (RootFolder - перенос в корень).
+// now - метка для папок, которые придется создать при переносе записи.
+func (s *Storage) saveAttrs(ctx context.Context, tx *sql.Tx, kind string, name string, uID int64, attrs recordAttrs, replace bool,
+	now hlc.Timestamp) error {
+	rt := recordTables[kind]
+	var recordID int64
+	err := tx.QueryRowContext(ctx,
+		fmt.Sprintf("SELECT %s FROM %s WHERE name = ? AND uId = ?", rt.idCol, rt.table), name, uID).Scan(&recordID)
+	if err != nil {
+		return err
+	}
+	if replace || attrs.meta != nil {
+		if err := s.replaceMeta(ctx, tx, kind, recordID, uID, attrs.meta); err != nil {
+			return err
+		}
+	}
+	if replace || attrs.folder != "" {
+		if err := setRecordFolder(ctx, tx, kind, recordID, uID, attrs.folder, now); err != nil {
+			return err
+		}
+	}
+	if replace || attrs.tags != nil {
+		if err := replaceTags(ctx, tx, kind, recordID, uID, attrs.tags); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// folderTime возвращает метку для папок, которые придется создать при переносе записи в папку path.
+// Для записи без папки метка не нужна и часы не сдвигаются.
+func (s *Storage) folderTime(ctx context.Context, path string) (hlc.Timestamp, error) {
+	if path, err := folder.Normalize(path); err != nil || path == "" {
+		return hlc.Timestamp{}, nil
+	}
+	return s.tick(ctx)
+}
+
+// inTx выполняет fn в одной транзакции и фиксирует ее, если fn завершилась без ошибки.
+func (s *Storage) inTx(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if err := fn(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// getAttrs возвращает атрибуты записи с указанным именем.
+func (s *Storage) getAttrs(ctx context.Context, kind string, name string, uID int64) (recordAttrs, error) {
+	all, err := s.loadAttrs(ctx, kind, uID, "AND r.name = ?", name)
+	if err != nil {
+		return recordAttrs{}, err
+	}
+	return all[name], nil
+}
+
+// getAllAttrs возвращает атрибуты всех записей одного вида по имени записи.
+func (s *Storage) getAllAttrs(ctx context.Context, kind string, uID int64) (map[string]recordAttrs, error) {
+	return s.loadAttrs(ctx, kind, uID, "")
+}
+
+func (s *Storage) loadAttrs(ctx context.Context, kind string, uID int64, filter string, args ...any) (map[string]recordAttrs, error) {
+	attrs := make(map[string]recordAttrs)
+	if err := s.loadMeta(ctx, kind, uID, attrs, filter, args...); err != nil {
+		return nil, err
+	}
+	if err := s.loadFolders(ctx, kind, uID, attrs, filter, args...); err != nil {
+		return nil, err
+	}
+	if err := s.loadTags(ctx, kind, uID, attrs, filter, args...); err != nil {
+		return nil, err
+	}
+	return attrs, nil
+}
+
+// queryAttrs выполняет запрос к таблице атрибутов (псевдоним m), соединенной с таблицей записей (псевдоним r).
+func (s *Storage) queryAttrs(ctx context.Context, selectFrom string, kind string, uID int64, filter string, args ...any) (*sql.Rows, error) {
+	rt := recordTables[kind]
+	query := fmt.Sprintf(`%s JOIN %s r ON m.record_id = r.%s WHERE m.kind = ? AND m.uId = ? %s`,
+		selectFrom, rt.table, rt.idCol, filter)
+	return s.db.QueryContext(ctx, query, append([]any{kind, uID}, args...)...)
+}
+
+// clearOrphanAttrs удаляет атрибуты записей, которых больше нет в базе.
+func (s *Storage) clearOrphanAttrs(ctx context.Context, uID int64) error {
+	for kind, rt := range recordTables {
+		for _, table := range attrTables {
+			_, err := s.db.ExecContext(ctx,
+				fmt.Sprintf("DELETE FROM %s WHERE kind = ? AND uId = ? AND record_id NOT IN (SELECT %s FROM %s)", table, rt.idCol, rt.table),
+				kind, uID)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/Dorrrke/GophKeeper-client/internal/domain/models"
+	"github.com/Dorrrke/GophKeeper-client/internal/hlc"
+)
+
+// tick выдает метку изменения записи по гибридным часам устройства.
+// Последняя выданная метка сохраняется в базе, поэтому метки растут и между запусками клиента.
+// Вызывается до начала транзакции: метка сохраняется отдельным запросом.
+func (s *Storage) tick(ctx context.Context) (hlc.Timestamp, error) {
+	clock, err := s.loadClock(ctx)
+	if err != nil {
+		return hlc.Timestamp{}, err
+	}
+	ts := clock.Now()
+	return ts, s.saveClock(ctx, ts)
+}
+
+// observe учитывает метки записей с сервера, чтобы следующие локальные изменения получили более поздние метки.
+func (s *Storage) observe(ctx context.Context, model models.SyncModel) error {
+	var latest hlc.Timestamp
+	seen := func(ts hlc.Timestamp) {
+		if ts.After(latest) {
+			latest = ts
+		}
+	}
+	for _, d := range model.Cards {
+		seen(d.Updated)
+	}
+	for _, d := range model.Auth {
+		seen(d.Updated)
+	}
+	for _, d := range model.Texts {
+		seen(d.Updated)
+	}
+	for _, d := range model.Bins {
+		seen(d.Updated)
+	}
+	for _, d := range model.OTPs {
+		seen(d.Updated)
+	}
+	for _, d := range model.Folders {
+		seen(d.Updated)
+	}
+	return s.update(ctx, latest)
+}
+
+// update учитывает метку с другого устройства. Нулевая метка игнорируется.
+func (s *Storage) update(ctx context.Context, remote hlc.Timestamp) error {
+	if remote.IsZero() {
+		return nil
+	}
+	clock, err := s.loadClock(ctx)
+	if err != nil {
+		return err
+	}
+	return s.saveClock(ctx, clock.Update(remote))
+}
+
+// loadClock возвращает часы устройства, при первом обращении читая их состояние из базы.
+// База без часов получает новый идентификатор устройства.
+func (s *Storage) loadClock(ctx context.Context) (*hlc.Clock, error) {
+	s.clockMu.Lock()
+	defer s.clockMu.Unlock()
+	if s.clock != nil {
+		return s.clock, nil
+	}
+	var device string
+	var last hlc.Timestamp
+	err := s.db.QueryRowContext(ctx, "SELECT device, last FROM clock").Scan(&device, &last)
+	if errors.Is(err, sql.ErrNoRows) {
+		if device, err = hlc.NewDevice(); err != nil {
+			return nil, err
+		}
+		_, err = s.db.ExecContext(ctx, "INSERT INTO clock(device, last) VALUES(?, '')", device)
+	}
+	if err != nil {
+		return nil, err
+	}
+	s.clock = hlc.NewClock(device, last)
+	return s.clock, nil
+}
+
+// saveClock сохраняет метку, если она позже сохраненной ранее.
+func (s *Storage) saveClock(ctx context.Context, last hlc.Timestamp) error {
+	_, err := s.db.ExecContext(ctx, "UPDATE clock SET last = ? WHERE last < ?", last.String(), last.String())
+	return err
+}
@@ -0,0 +1,95 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/Dorrrke/GophKeeper-client/internal/domain/models"
+	"github.com/Dorrrke/GophKeeper-client/internal/hlc"
+)
+
+// SaveConflict сохраняет неразрешенный конфликт вместе с зашифрованной версией записи с сервера.
+// Повторный конфликт той же записи заменяет сохраненную версию.
+func (s *Storage) SaveConflict(ctx context.Context, conflict models.ConflictModel, uID int64) error {
+	data, err := json.Marshal(conflict.Remote)
+	if err != nil {
+		return err
+	}
+	sealed, err := s.seal(data, fieldConflictData, uID)
+	if err != nil {
+		return err
+	}
+	_, err = s.db.ExecContext(ctx, `INSERT INTO conflicts(kind, record_uuid, name, data, uId, detected_at) VALUES(?,?,?,?,?,?)
+	ON CONFLICT (uId, kind, record_uuid) DO UPDATE SET name = excluded.name, data = excluded.data, detected_at = excluded.detected_at`,
+		conflict.Kind, conflict.UUID, conflict.Name, sealed, uID, conflict.Detected.UTC().Format(time.RFC3339Nano))
+	return err
+}
+
+// GetConflicts возвращает неразрешенные конфликты пользователя с версиями записей с сервера, начиная с самых старых.
+func (s *Storage) GetConflicts(ctx context.Context, uID int64) ([]models.ConflictModel, error) {
+	rows, err := s.db.QueryContext(ctx,
+		"SELECT kind, record_uuid, name, data, detected_at FROM conflicts WHERE uId = ? ORDER BY detected_at", uID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var conflicts []models.ConflictModel
+	for rows.Next() {
+		var conflict models.ConflictModel
+		var data, detected string
+		if err := rows.Scan(&conflict.Kind, &conflict.UUID, &conflict.Name, &data, &detected); err != nil {
+			return nil, err
+		}
+		remote, err := s.open(data, fieldConflictData, uID)
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(remote, &conflict.Remote); err != nil {
+			return nil, err
+		}
+		conflict.Detected, err = time.Parse(time.RFC3339Nano, detected)
+		if err != nil {
+			return nil, err
+		}
+		conflicts = append(conflicts, conflict)
+	}
+	return conflicts, rows.Err()
+}
+
+// DeleteConflict забывает разрешенный конфликт. Отсутствие конфликта ошибкой не считается.
+func (s *Storage) DeleteConflict(ctx context.Context, kind string, uuid string, uID int64) error {
+	_, err := s.db.ExecContext(ctx, "DELETE FROM conflicts WHERE uId = ? AND kind = ? AND record_uuid = ?", uID, kind, uuid)
+	return err
+}
+
+// RebaseRecord переносит локальное изменение записи на версию с сервера base и выдает ему новую метку,
+// которая позже base, чтобы при следующей синхронизации изменение заменило эту версию на сервере.
+func (s *Storage) RebaseRecord(ctx context.Context, kind string, uuid string, base hlc.Timestamp, uID int64) error {
+	notExist, ok := recordNotExist[kind]
+	if !ok {
+		return fmt.Errorf("unknown record kind %q", kind)
+	}
+	if err := s.update(ctx, base); err != nil {
+		return err
+	}
+	updated, err := s.tick(ctx)
+	if err != nil {
+		return err
+	}
+	res, err := s.db.ExecContext(ctx,
+		fmt.Sprintf("UPDATE %s SET last_update = ?, synced_update = ? WHERE uuid = ? AND uId = ?", recordTables[kind].table),
+		updated, base, uuid, uID)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return notExist
+	}
+	return nil
+}
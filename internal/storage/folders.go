@@ -0,0 +1,277 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/Dorrrke/GophKeeper-client/internal/domain/models"
+	"github.com/Dorrrke/GophKeeper-client/internal/folder"
+	"github.com/Dorrrke/GophKeeper-client/internal/hlc"
+)
+
+// CreateFolder создает папку вместе с недостающими родительскими папками.
+func (s *Storage) CreateFolder(ctx context.Context, path string, uID int64) error {
+	now, err := s.tick(ctx)
+	if err != nil {
+		return err
+	}
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	exists, err := folderExists(ctx, tx, path, uID)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return ErrFolderExist
+	}
+	if err := ensureFolder(ctx, tx, path, uID, now); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// GetFolders возвращает пути всех папок пользователя в алфавитном порядке.
+func (s *Storage) GetFolders(ctx context.Context, uID int64) ([]string, error) {
+	rows, err := s.db.QueryContext(ctx, "SELECT path FROM folders WHERE uId = ? AND deleted = 0", uID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var paths []string
+	for rows.Next() {
+		var path string
+		if err := rows.Scan(&path); err != nil {
+			return nil, err
+		}
+		paths = append(paths, path)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	sort.Strings(paths)
+	return paths, nil
+}
+
+// MoveFolder переносит папку from со всеми вложенными папками и записями в to.
+// Старые пути помечаются удаленными, а время изменения перенесенных записей обновляется,
+// чтобы новое расположение ушло на сервер при синхронизации.
+func (s *Storage) MoveFolder(ctx context.Context, from string, to string, uID int64) error {
+	now, err := s.tick(ctx)
+	if err != nil {
+		return err
+	}
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	exists, err := folderExists(ctx, tx, from, uID)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return ErrFolderNotExist
+	}
+	exists, err = folderExists(ctx, tx, to, uID)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return ErrFolderExist
+	}
+
+	// substr в SQLite считает символы, а не байты.
+	fromLen, toLen := utf8.RuneCountInString(from), utf8.RuneCountInString(to)
+	rows, err := tx.QueryContext(ctx, `SELECT path FROM folders WHERE uId = ? AND deleted = 0
+	AND (path = ? OR substr(path, 1, ?) = ?)`, uID, from, fromLen+1, from+folder.Separator)
+	if err != nil {
+		return err
+	}
+	var paths []string
+	for rows.Next() {
+		var path string
+		if err := rows.Scan(&path); err != nil {
+			rows.Close()
+			return err
+		}
+		paths = append(paths, path)
+	}
+	if err := rows.Close(); err != nil {
+		return err
+	}
+
+	for _, path := range paths {
+		if err := ensureFolder(ctx, tx, folder.Move(path, from, to), uID, now); err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx, "UPDATE folders SET deleted = 1, last_update = ? WHERE uId = ? AND path = ?", now, uID, path)
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = tx.ExecContext(ctx, `UPDATE record_folders SET folder = ? || substr(folder, ?)
+	WHERE uId = ? AND (folder = ? OR substr(folder, 1, ?) = ?)`,
+		to, fromLen+1, uID, from, fromLen+1, from+folder.Separator)
+	if err != nil {
+		return err
+	}
+	for kind, rt := range recordTables {
+		_, err = tx.ExecContext(ctx, fmt.Sprintf(`UPDATE %s SET last_update = ? WHERE %s IN
+		(SELECT record_id FROM record_folders WHERE kind = ? AND uId = ? AND (folder = ? OR substr(folder, 1, ?) = ?))`,
+			rt.table, rt.idCol), now, kind, uID, to, toLen+1, to+folder.Separator)
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// getSyncFolders возвращает папки пользователя, включая удаленные, для синхронизации.
+// filter дополняет условие выборки.
+func (s *Storage) getSyncFolders(ctx context.Context, uID int64, filter string) ([]models.SyncFolderModel, error) {
+	rows, err := s.db.QueryContext(ctx, "SELECT path, uId, deleted, last_update FROM folders WHERE uId = ? "+filter, uID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var folders []models.SyncFolderModel
+	for rows.Next() {
+		var data models.SyncFolderModel
+		if err := rows.Scan(&data.Path, &data.UserID, &data.Deleted, &data.Updated); err != nil {
+			return nil, err
+		}
+		folders = append(folders, data)
+	}
+	return folders, rows.Err()
+}
+
+// syncFolders сохраняет папки, полученные с сервера.
+func (s *Storage) syncFolders(ctx context.Context, folders []models.SyncFolderModel) error {
+	stmt, err := s.db.Prepare(`INSERT INTO folders(path, uId, deleted, last_update, synced_update) VALUES(?,?,?,?,?)
+	ON CONFLICT (uId, path) DO UPDATE SET deleted = excluded.deleted, last_update = excluded.last_update,
+	synced_update = excluded.synced_update`)
+	if err != nil {
+		return err
+	}
+	for _, f := range folders {
+		_, err = stmt.ExecContext(ctx, f.Path, f.UserID, f.Deleted, f.Updated, f.Updated)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func folderExists(ctx context.Context, tx *sql.Tx, path string, uID int64) (bool, error) {
+	if path == "" {
+		return true, nil
+	}
+	var deleted bool
+	err := tx.QueryRowContext(ctx, "SELECT deleted FROM folders WHERE uId = ? AND path = ?", uID, path).Scan(&deleted)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, nil
+		}
+		return false, err
+	}
+	return !deleted, nil
+}
+
+// ensureFolder создает папку и все ее родительские папки, восстанавливая удаленные. now - метка изменения папок.
+func ensureFolder(ctx context.Context, tx *sql.Tx, path string, uID int64, now hlc.Timestamp) error {
+	for _, p := range folder.Ancestors(path) {
+		_, err := tx.ExecContext(ctx, `INSERT INTO folders(path, uId, deleted, last_update) VALUES(?,?,0,?)
+		ON CONFLICT (uId, path) DO UPDATE SET deleted = 0, last_update = excluded.last_update WHERE deleted = 1`,
+			p, uID, now)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// setRecordFolder помещает запись в папку. Пустой путь или RootFolder переносят запись в корень.
+// now - метка изменения папки, если ее придется создать.
+func setRecordFolder(ctx context.Context, tx *sql.Tx, kind string, recordID int64, uID int64, path string, now hlc.Timestamp) error {
+	path, err := folder.Normalize(path)
+	if err != nil {
+		return err
+	}
+	if path == "" {
+		_, err = tx.ExecContext(ctx, "DELETE FROM record_folders WHERE kind = ? AND record_id = ?", kind, recordID)
+		return err
+	}
+	if err := ensureFolder(ctx, tx, path, uID, now); err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, `INSERT INTO record_folders(kind, record_id, folder, uId) VALUES(?,?,?,?)
+	ON CONFLICT (kind, record_id) DO UPDATE SET folder = excluded.folder`, kind, recordID, path, uID)
+	return err
+}
+
+// replaceTags заменяет теги записи. Повторяющиеся и пустые теги отбрасываются.
+func replaceTags(ctx context.Context, tx *sql.Tx, kind string, recordID int64, uID int64, tags []string) error {
+	_, err := tx.ExecContext(ctx, "DELETE FROM record_tags WHERE kind = ? AND record_id = ?", kind, recordID)
+	if err != nil {
+		return err
+	}
+	for _, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		if tag == "" {
+			continue
+		}
+		_, err = tx.ExecContext(ctx, "INSERT OR IGNORE INTO record_tags(kind, record_id, tag, uId) VALUES(?,?,?,?)",
+			kind, recordID, tag, uID)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// loadFolders добавляет в attrs папки записей одного вида.
+func (s *Storage) loadFolders(ctx context.Context, kind string, uID int64, attrs map[string]recordAttrs, filter string, args ...any) error {
+	rows, err := s.queryAttrs(ctx, "SELECT r.name, m.folder FROM record_folders m", kind, uID, filter, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var name, path string
+		if err := rows.Scan(&name, &path); err != nil {
+			return err
+		}
+		a := attrs[name]
+		a.folder = path
+		attrs[name] = a
+	}
+	return rows.Err()
+}
+
+// loadTags добавляет в attrs теги записей одного вида в алфавитном порядке.
+func (s *Storage) loadTags(ctx context.Context, kind string, uID int64, attrs map[string]recordAttrs, filter string, args ...any) error {
+	rows, err := s.queryAttrs(ctx, "SELECT r.name, m.tag FROM record_tags m", kind, uID, filter+" ORDER BY m.tag", args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var name, tag string
+		if err := rows.Scan(&name, &tag); err != nil {
+			return err
+		}
+		a := attrs[name]
+		a.tags = append(a.tags, tag)
+		attrs[name] = a
+	}
+	return rows.Err()
+}
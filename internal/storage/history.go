@@ -0,0 +1,149 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Dorrrke/GophKeeper-client/internal/domain/models"
+)
+
+// recordNotExist - ошибки отсутствия записи каждого вида с идентификатором.
+var recordNotExist = map[string]error{
+	kindCard:  ErrCardNotExist,
+	kindLogin: ErrLoginNotExist,
+	kindText:  ErrTextNotExist,
+	kindBin:   ErrBinDataNotExist,
+	kindOTP:   ErrOTPNotExist,
+}
+
+// SetHistoryRetention задает число хранимых предыдущих версий каждой записи. 0 - без ограничения.
+func (s *Storage) SetHistoryRetention(limit int) {
+	s.historyLimit = limit
+}
+
+// GetHistory возвращает версии записи, начиная с самой новой, без их содержимого.
+func (s *Storage) GetHistory(ctx context.Context, kind string, name string, uID int64) ([]models.HistoryModel, error) {
+	recordID, err := s.historyRecordID(ctx, kind, name, uID)
+	if err != nil {
+		return nil, err
+	}
+	stmt, err := s.db.Prepare("SELECT version, created_at FROM history WHERE kind = ? AND record_id = ? AND uId = ? ORDER BY version DESC")
+	if err != nil {
+		return nil, err
+	}
+	rows, err := stmt.QueryContext(ctx, kind, recordID, uID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var versions []models.HistoryModel
+	for rows.Next() {
+		var version models.HistoryModel
+		var created string
+		if err := rows.Scan(&version.Version, &created); err != nil {
+			return nil, err
+		}
+		version.Created, err = time.Parse(time.RFC3339Nano, created)
+		if err != nil {
+			return nil, err
+		}
+		versions = append(versions, version)
+	}
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+	return versions, nil
+}
+
+// GetHistoryVersion возвращает указанную версию записи вместе с расшифрованным содержимым.
+func (s *Storage) GetHistoryVersion(ctx context.Context, kind string, name string, version int, uID int64) (models.HistoryModel, error) {
+	recordID, err := s.historyRecordID(ctx, kind, name, uID)
+	if err != nil {
+		return models.HistoryModel{}, err
+	}
+	stmt, err := s.db.Prepare("SELECT version, created_at, data FROM history WHERE kind = ? AND record_id = ? AND version = ? AND uId = ?")
+	if err != nil {
+		return models.HistoryModel{}, err
+	}
+	var res models.HistoryModel
+	var created, data string
+	err = stmt.QueryRowContext(ctx, kind, recordID, version, uID).Scan(&res.Version, &created, &data)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.HistoryModel{}, ErrVersionNotExist
+		}
+		return models.HistoryModel{}, err
+	}
+	res.Created, err = time.Parse(time.RFC3339Nano, created)
+	if err != nil {
+		return models.HistoryModel{}, err
+	}
+	res.Data, err = s.open(data, fieldHistoryData, uID)
+	if err != nil {
+		return models.HistoryModel{}, err
+	}
+	return res, nil
+}
+
+// saveHistory сохраняет текущее содержимое записи как новую версию и удаляет версии сверх historyLimit.
+// Версия пишется в транзакции tx, в которой обновляется сама запись.
+func (s *Storage) saveHistory(ctx context.Context, tx *sql.Tx, kind string, name string, uID int64, record any) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	sealed, err := s.seal(data, fieldHistoryData, uID)
+	if err != nil {
+		return err
+	}
+	rt := recordTables[kind]
+	var recordID int64
+	err = tx.QueryRowContext(ctx,
+		fmt.Sprintf("SELECT %s FROM %s WHERE name = ? AND uId = ?", rt.idCol, rt.table), name, uID).Scan(&recordID)
+	if err != nil {
+		return err
+	}
+	var version int
+	err = tx.QueryRowContext(ctx, "SELECT COALESCE(MAX(version), 0) + 1 FROM history WHERE kind = ? AND record_id = ?",
+		kind, recordID).Scan(&version)
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, "INSERT INTO history(kind, record_id, version, data, uId, created_at) VALUES(?,?,?,?,?,?)",
+		kind, recordID, version, sealed, uID, time.Now().Format(time.RFC3339Nano))
+	if err != nil {
+		return err
+	}
+	if s.historyLimit > 0 {
+		_, err = tx.ExecContext(ctx, "DELETE FROM history WHERE kind = ? AND record_id = ? AND version <= ?",
+			kind, recordID, version-s.historyLimit)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// historyRecordID возвращает идентификатор записи, к которому привязана ее история.
+func (s *Storage) historyRecordID(ctx context.Context, kind string, name string, uID int64) (int64, error) {
+	notExist, ok := recordNotExist[kind]
+	if !ok {
+		return 0, fmt.Errorf("unknown record kind %q", kind)
+	}
+	rt := recordTables[kind]
+	var recordID int64
+	err := s.db.QueryRowContext(ctx,
+		fmt.Sprintf("SELECT %s FROM %s WHERE name = ? AND uId = ? AND deleted = 0", rt.idCol, rt.table), name, uID).Scan(&recordID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, notExist
+		}
+		return 0, err
+	}
+	return recordID, nil
+}
@@ -0,0 +1,105 @@
+package storage
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/Dorrrke/GophKeeper-client/internal/hlc"
+)
+
+// syncRecord - сведения о записи с сервера, по которым она сопоставляется с локальными записями.
+type syncRecord struct {
+	uuid    string
+	name    string
+	deleted bool
+	updated hlc.Timestamp
+	uID     int64
+}
+
+// resolveSync сопоставляет запись с сервера с локальными записями по идентификатору.
+// Записи без идентификатора, сохраненные на сервере до его появления, считаются той же записью,
+// что и локальная запись с таким же именем. Удаление записи, которой на устройстве нет, пропускается.
+//
+// Если имя занято другой записью, одна из них получает имя с суффиксом из своего идентификатора
+// и новое время изменения, чтобы новое имя дошло до сервера. Имя уступает удаленная запись,
+// а из двух удаленных или двух действующих - запись с большим идентификатором,
+// поэтому все устройства разрешают совпадение имен одинаково.
+func (s *Storage) resolveSync(ctx context.Context, kind string, rec syncRecord) (syncRecord, bool, error) {
+	rt := recordTables[kind]
+	if rec.uuid == "" {
+		err := s.db.QueryRowContext(ctx,
+			fmt.Sprintf("SELECT COALESCE(uuid, '') FROM %s WHERE name = ? AND uId = ?", rt.table), rec.name, rec.uID).Scan(&rec.uuid)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return syncRecord{}, false, err
+		}
+	}
+	if rec.deleted {
+		var exists bool
+		err := s.db.QueryRowContext(ctx,
+			fmt.Sprintf("SELECT EXISTS (SELECT 1 FROM %s WHERE uuid = ? AND uId = ?)", rt.table), rec.uuid, rec.uID).Scan(&exists)
+		if err != nil {
+			return syncRecord{}, false, err
+		}
+		if !exists {
+			return syncRecord{}, true, nil
+		}
+	}
+	if rec.uuid == "" {
+		var err error
+		if rec.uuid, err = newUUID(); err != nil {
+			return syncRecord{}, false, err
+		}
+	}
+
+	var otherUUID string
+	var otherDeleted bool
+	err := s.db.QueryRowContext(ctx,
+		fmt.Sprintf("SELECT uuid, deleted FROM %s WHERE name = ? AND uId = ? AND uuid <> ?", rt.table),
+		rec.name, rec.uID, rec.uuid).Scan(&otherUUID, &otherDeleted)
+	if errors.Is(err, sql.ErrNoRows) {
+		return rec, false, nil
+	}
+	if err != nil {
+		return syncRecord{}, false, err
+	}
+	lTime, err := s.tick(ctx)
+	if err != nil {
+		return syncRecord{}, false, err
+	}
+	if rec.deleted && !otherDeleted || rec.deleted == otherDeleted && rec.uuid > otherUUID {
+		rec.name = suffixedName(rec.name, rec.uuid)
+		rec.updated = lTime
+		// Локальная запись сохраняет имя и должна заменить на сервере запись, пришедшую под этим именем.
+		_, err = s.db.ExecContext(ctx, fmt.Sprintf("UPDATE %s SET last_update = ? WHERE uuid = ? AND uId = ?", rt.table),
+			lTime, otherUUID, rec.uID)
+	} else {
+		_, err = s.db.ExecContext(ctx, fmt.Sprintf("UPDATE %s SET name = ?, last_update = ? WHERE uuid = ? AND uId = ?", rt.table),
+			suffixedName(rec.name, otherUUID), lTime, otherUUID, rec.uID)
+	}
+	if err != nil {
+		return syncRecord{}, false, err
+	}
+	return rec, false, nil
+}
+
+// suffixedName возвращает имя записи, уступившей свое имя другой записи.
+func suffixedName(name string, uuid string) string {
+	if len(uuid) > 8 {
+		uuid = uuid[:8]
+	}
+	return fmt.Sprintf("%s (%s)", name, uuid)
+}
+
+// newUUID возвращает случайный идентификатор записи (UUID версии 4).
+func newUUID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16]), nil
+}
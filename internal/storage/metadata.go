@@ -0,0 +1,52 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+)
+
+// replaceMeta заменяет метаданные записи. Значения хранятся в зашифрованном виде.
+func (s *Storage) replaceMeta(ctx context.Context, tx *sql.Tx, kind string, recordID int64, uID int64, meta map[string]string) error {
+	_, err := tx.ExecContext(ctx, "DELETE FROM metadata WHERE kind = ? AND record_id = ?", kind, recordID)
+	if err != nil {
+		return err
+	}
+	for key, value := range meta {
+		sealed, err := s.sealString(value, fieldMetaValue, uID)
+		if err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx, "INSERT INTO metadata(kind, record_id, key, value, uId) VALUES(?,?,?,?,?)",
+			kind, recordID, key, sealed, uID)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// loadMeta добавляет в attrs метаданные записей одного вида.
+func (s *Storage) loadMeta(ctx context.Context, kind string, uID int64, attrs map[string]recordAttrs, filter string, args ...any) error {
+	rows, err := s.queryAttrs(ctx, "SELECT r.name, m.key, m.value FROM metadata m", kind, uID, filter, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var name, key, value string
+		if err := rows.Scan(&name, &key, &value); err != nil {
+			return err
+		}
+		value, err = s.openString(value, fieldMetaValue, uID)
+		if err != nil {
+			return err
+		}
+		a := attrs[name]
+		if a.meta == nil {
+			a.meta = make(map[string]string)
+		}
+		a.meta[key] = value
+		attrs[name] = a
+	}
+	return rows.Err()
+}
@@ -0,0 +1,264 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/Dorrrke/GophKeeper-client/internal/domain/models"
+	"github.com/mattn/go-sqlite3"
+)
+
+func (s *Storage) SaveOTP(ctx context.Context, otp models.OTPModel, uID int64) (int64, error) {
+	stmt, err := s.db.Prepare(`INSERT INTO otp(uuid, name, type, secret, issuer, account, algorithm, digits, period, counter, uId, deleted, last_update)
+	VALUES(?,?,?,?,?,?,?,?,?,?,?,?,?)`)
+	if err != nil {
+		return 0, err
+	}
+	secret, err := s.sealString(otp.Secret, fieldOTPSecret, uID)
+	if err != nil {
+		return 0, err
+	}
+	uuid, err := newUUID()
+	if err != nil {
+		return 0, err
+	}
+	t, err := s.tick(ctx)
+	if err != nil {
+		return 0, err
+	}
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+	res, err := tx.StmtContext(ctx, stmt).ExecContext(ctx, uuid, otp.Name, otp.Type, secret, otp.Issuer, otp.Account, otp.Algorithm,
+		otp.Digits, otp.Period, otp.Counter, uID, false, t)
+	if err != nil {
+		var sqliteErr sqlite3.Error
+		if errors.As(err, &sqliteErr) && sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique {
+			return 0, ErrOTPAlredyExist
+		}
+		return 0, err
+	}
+	oID, err := res.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+	attrs := recordAttrs{meta: otp.Meta, folder: otp.Folder, tags: otp.Tags}
+	if err := s.saveAttrs(ctx, tx, kindOTP, otp.Name, uID, attrs, true, t); err != nil {
+		return 0, err
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return oID, nil
+}
+
+func (s *Storage) GetAllOTP(ctx context.Context, uID int64) ([]models.OTPModel, error) {
+	stmt, err := s.db.Prepare(`SELECT name, type, secret, issuer, account, algorithm, digits, period, counter
+	FROM otp WHERE uId=? AND deleted=0`)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := stmt.QueryContext(ctx, uID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var otps []models.OTPModel
+	for rows.Next() {
+		otp, err := s.scanOTP(rows, uID)
+		if err != nil {
+			return nil, err
+		}
+		otps = append(otps, otp)
+	}
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+	attrs, err := s.getAllAttrs(ctx, kindOTP, uID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range otps {
+		a := attrs[otps[i].Name]
+		otps[i].Meta, otps[i].Folder, otps[i].Tags = a.meta, a.folder, a.tags
+	}
+	return otps, nil
+}
+
+func (s *Storage) GetOTPByName(ctx context.Context, name string, uID int64) (models.OTPModel, error) {
+	stmt, err := s.db.Prepare(`SELECT name, type, secret, issuer, account, algorithm, digits, period, counter
+	FROM otp WHERE name = ? AND uId = ? AND deleted=0`)
+	if err != nil {
+		return models.OTPModel{}, err
+	}
+	otp, err := s.scanOTP(stmt.QueryRowContext(ctx, name, uID), uID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.OTPModel{}, ErrOTPNotExist
+		}
+		return models.OTPModel{}, err
+	}
+	attrs, err := s.getAttrs(ctx, kindOTP, name, uID)
+	if err != nil {
+		return models.OTPModel{}, err
+	}
+	otp.Meta, otp.Folder, otp.Tags = attrs.meta, attrs.folder, attrs.tags
+	return otp, nil
+}
+
+func (s *Storage) DeleteOTP(ctx context.Context, name string, uID int64) error {
+	stmt, err := s.db.Prepare("UPDATE otp SET deleted = 1, last_update = ?  WHERE name = ? AND uId = ?")
+	if err != nil {
+		return err
+	}
+	t, err := s.tick(ctx)
+	if err != nil {
+		return err
+	}
+	_, err = stmt.ExecContext(ctx, t, name, uID)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (s *Storage) UpdateOTP(ctx context.Context, otp models.OTPModel, uID int64) error {
+	stmt, err := s.db.Prepare(`UPDATE otp SET type = ?, secret = ?, issuer = ?, account = ?, algorithm = ?, digits = ?,
+	period = ?, counter = ?, last_update = ? WHERE name = ? and uId = ?`)
+	if err != nil {
+		return err
+	}
+	secret, err := s.sealString(otp.Secret, fieldOTPSecret, uID)
+	if err != nil {
+		return err
+	}
+	lTime, err := s.tick(ctx)
+	if err != nil {
+		return err
+	}
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	_, err = tx.StmtContext(ctx, stmt).ExecContext(ctx, otp.Type, secret, otp.Issuer, otp.Account, otp.Algorithm, otp.Digits,
+		otp.Period, otp.Counter, lTime, otp.Name, uID)
+	if err != nil {
+		return err
+	}
+	attrs := recordAttrs{meta: otp.Meta, folder: otp.Folder, tags: otp.Tags}
+	if err := s.saveAttrs(ctx, tx, kindOTP, otp.Name, uID, attrs, false, lTime); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// getSyncOTP возвращает записи OTP пользователя, включая удаленные, для синхронизации.
+// filter и attrFilter дополняют условия выборки записей и их атрибутов.
+func (s *Storage) getSyncOTP(ctx context.Context, uID int64, filter string, attrFilter string) ([]models.SyncOTPModel, error) {
+	stmt, err := s.db.Prepare(`SELECT name, COALESCE(uuid, ''), type, secret, issuer, account, algorithm, digits, period, counter, uId,
+	deleted, last_update, synced_update FROM otp WHERE uId = ? ` + filter)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := stmt.QueryContext(ctx, uID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var otps []models.SyncOTPModel
+	for rows.Next() {
+		var data models.SyncOTPModel
+		var secret string
+		err := rows.Scan(&data.Name, &data.UUID, &data.Type, &secret, &data.Issuer, &data.Account, &data.Algorithm,
+			&data.Digits, &data.Period, &data.Counter, &data.UserID, &data.Deleted, &data.Updated, &data.Base)
+		if err != nil {
+			return nil, err
+		}
+		data.Secret, err = s.openString(secret, fieldOTPSecret, data.UserID)
+		if err != nil {
+			return nil, err
+		}
+		otps = append(otps, data)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	attrs, err := s.loadAttrs(ctx, kindOTP, uID, attrFilter)
+	if err != nil {
+		return nil, err
+	}
+	for i := range otps {
+		a := attrs[otps[i].Name]
+		otps[i].Meta, otps[i].Folder, otps[i].Tags = a.meta, a.folder, a.tags
+	}
+	return otps, nil
+}
+
+// syncOTP сохраняет записи OTP, полученные с сервера.
+func (s *Storage) syncOTP(ctx context.Context, otps []models.SyncOTPModel) error {
+	stmt, err := s.db.Prepare(`INSERT INTO otp(uuid, name, type, secret, issuer, account, algorithm, digits, period, counter, uId, deleted,
+	last_update, synced_update)
+	VALUES(?,?,?,?,?,?,?,?,?,?,?,?,?,?)
+	ON CONFLICT (uId, uuid) DO UPDATE SET name = excluded.name, type = excluded.type, secret = excluded.secret, issuer = excluded.issuer,
+	account = excluded.account, algorithm = excluded.algorithm, digits = excluded.digits, period = excluded.period,
+	counter = excluded.counter, deleted = excluded.deleted, last_update = excluded.last_update,
+	synced_update = excluded.synced_update`)
+	if err != nil {
+		return err
+	}
+	for _, otp := range otps {
+		rec, skip, err := s.resolveSync(ctx, kindOTP, syncRecord{
+			uuid: otp.UUID, name: otp.Name, deleted: otp.Deleted, updated: otp.Updated, uID: otp.UserID})
+		if err != nil {
+			return err
+		}
+		if skip {
+			continue
+		}
+		secret, err := s.sealString(otp.Secret, fieldOTPSecret, otp.UserID)
+		if err != nil {
+			return err
+		}
+		attrs := recordAttrs{meta: otp.Meta, folder: otp.Folder, tags: otp.Tags}
+		now, err := s.folderTime(ctx, attrs.folder)
+		if err != nil {
+			return err
+		}
+		err = s.inTx(ctx, func(tx *sql.Tx) error {
+			_, err := tx.StmtContext(ctx, stmt).ExecContext(ctx, rec.uuid, rec.name, otp.Type, secret, otp.Issuer, otp.Account,
+				otp.Algorithm, otp.Digits, otp.Period, otp.Counter, otp.UserID, otp.Deleted, rec.updated, otp.Updated)
+			if err != nil {
+				return err
+			}
+			return s.saveAttrs(ctx, tx, kindOTP, rec.name, otp.UserID, attrs, true, now)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func (s *Storage) scanOTP(row rowScanner, uID int64) (models.OTPModel, error) {
+	var otp models.OTPModel
+	var secret string
+	err := row.Scan(&otp.Name, &otp.Type, &secret, &otp.Issuer, &otp.Account, &otp.Algorithm,
+		&otp.Digits, &otp.Period, &otp.Counter)
+	if err != nil {
+		return models.OTPModel{}, err
+	}
+	otp.Secret, err = s.openString(secret, fieldOTPSecret, uID)
+	if err != nil {
+		return models.OTPModel{}, err
+	}
+	return otp, nil
+}
@@ -0,0 +1,97 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/Dorrrke/GophKeeper-client/internal/hlc"
+	"github.com/mattn/go-sqlite3"
+)
+
+// recordAlredyExist - ошибки занятого имени для каждого вида записей, которые можно переименовать.
+var recordAlredyExist = map[string]error{
+	kindCard:  ErrCardAlredyExist,
+	kindLogin: ErrLoginAlredyExist,
+	kindText:  ErrTextAlredyExist,
+	kindBin:   ErrBinAlredyExist,
+	kindOTP:   ErrOTPAlredyExist,
+}
+
+// renamedRecord - прежнее имя переименованной записи, удаление которого еще не отправлено на сервер.
+type renamedRecord struct {
+	kind    string
+	name    string
+	updated hlc.Timestamp
+}
+
+// Rename меняет имя записи. Идентификатор записи сохраняется, поэтому атрибуты и история остаются при ней,
+// а прежнее имя при следующей синхронизации отправляется на сервер как удаленная запись.
+func (s *Storage) Rename(ctx context.Context, kind string, oldName string, newName string, uID int64) error {
+	notExist, ok := recordNotExist[kind]
+	if !ok {
+		return fmt.Errorf("unknown record kind %q", kind)
+	}
+	rt := recordTables[kind]
+	lTime, err := s.tick(ctx)
+	if err != nil {
+		return err
+	}
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	res, err := tx.ExecContext(ctx,
+		fmt.Sprintf("UPDATE %s SET name = ?, last_update = ? WHERE name = ? AND uId = ? AND deleted = 0", rt.table),
+		newName, lTime, oldName, uID)
+	if err != nil {
+		var sqliteErr sqlite3.Error
+		if errors.As(err, &sqliteErr) && sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique {
+			return recordAlredyExist[kind]
+		}
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return notExist
+	}
+	// Запись вернули к имени, удаление которого еще не отправлено: удалять его на сервере уже не нужно.
+	_, err = tx.ExecContext(ctx, "DELETE FROM renames WHERE uId = ? AND kind = ? AND name = ?", uID, kind, newName)
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, `INSERT INTO renames(kind, name, uId, last_update) VALUES(?,?,?,?)
+	ON CONFLICT (uId, kind, name) DO UPDATE SET last_update = excluded.last_update`, kind, oldName, uID, lTime)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// ClearRenames забывает прежние имена переименованных записей после их отправки на сервер.
+func (s *Storage) ClearRenames(ctx context.Context, uID int64) error {
+	_, err := s.db.ExecContext(ctx, "DELETE FROM renames WHERE uId = ?", uID)
+	return err
+}
+
+// getRenames возвращает прежние имена переименованных записей, удаление которых еще не отправлено на сервер.
+func (s *Storage) getRenames(ctx context.Context, uID int64) ([]renamedRecord, error) {
+	rows, err := s.db.QueryContext(ctx, "SELECT kind, name, last_update FROM renames WHERE uId = ?", uID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var renames []renamedRecord
+	for rows.Next() {
+		var r renamedRecord
+		if err := rows.Scan(&r.kind, &r.name, &r.updated); err != nil {
+			return nil, err
+		}
+		renames = append(renames, r)
+	}
+	return renames, rows.Err()
+}
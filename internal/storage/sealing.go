@@ -0,0 +1,189 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/Dorrrke/GophKeeper-client/internal/coder"
+)
+
+// fieldKeyPurpose - назначение ключа, которым шифруются секретные поля.
+const fieldKeyPurpose = "gophkeeper storage fields v1"
+
+// Секретные поля, которые хранятся в базе только в зашифрованном виде.
+const (
+	fieldCardNumber    = "cards.number"
+	fieldCardCVV       = "cards.cvv"
+	fieldLoginPassword = "logins.password"
+	fieldTextData      = "text_data.data"
+	fieldBinData       = "binares_data.data"
+	fieldOTPSecret     = "otp.secret"
+	fieldMetaValue     = "metadata.value"
+	fieldHistoryData   = "history.data"
+	fieldConflictData  = "conflicts.data"
+)
+
+// sealedColumn описывает зашифрованную колонку для миграции существующих записей.
+type sealedColumn struct {
+	table  string
+	idCol  string
+	column string
+	field  string
+}
+
+var sealedColumns = []sealedColumn{
+	{table: "cards", idCol: "cId", column: "number", field: fieldCardNumber},
+	{table: "cards", idCol: "cId", column: "cvv", field: fieldCardCVV},
+	{table: "logins", idCol: "lId", column: "password", field: fieldLoginPassword},
+	{table: "text_data", idCol: "tId", column: "data", field: fieldTextData},
+	{table: "binares_data", idCol: "bId", column: "data", field: fieldBinData},
+	{table: "otp", idCol: "oId", column: "secret", field: fieldOTPSecret},
+	{table: "metadata", idCol: "mId", column: "value", field: fieldMetaValue},
+	{table: "history", idCol: "hId", column: "data", field: fieldHistoryData},
+	{table: "conflicts", idCol: "cfId", column: "data", field: fieldConflictData},
+}
+
+// SetVaultKey задает ключ хранилища, которым шифруются секретные поля.
+func (s *Storage) SetVaultKey(key []byte) error {
+	fieldKey, err := coder.SubKey(key, fieldKeyPurpose)
+	if err != nil {
+		return err
+	}
+	s.fieldKey = fieldKey
+	return nil
+}
+
+// EncryptExisting однократно шифрует секретные поля пользователя, сохраненные до появления шифрования.
+// После этого пользователь отмечается в базе, и открытые значения его полей больше не принимаются.
+func (s *Storage) EncryptExisting(ctx context.Context, uID int64) error {
+	if s.fieldKey == nil {
+		return ErrVaultLocked
+	}
+	sealed, err := s.fieldsSealed(ctx, uID)
+	if err != nil || sealed {
+		return err
+	}
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	for _, col := range sealedColumns {
+		rows, err := tx.QueryContext(ctx,
+			fmt.Sprintf("SELECT %s, %s FROM %s WHERE uId = ?", col.idCol, col.column, col.table), uID)
+		if err != nil {
+			return err
+		}
+		plain := make(map[int64][]byte)
+		for rows.Next() {
+			var id int64
+			var value []byte
+			if err := rows.Scan(&id, &value); err != nil {
+				rows.Close()
+				return err
+			}
+			if !coder.IsSealed(string(value)) {
+				plain[id] = value
+			}
+		}
+		if err := rows.Close(); err != nil {
+			return err
+		}
+		for id, value := range plain {
+			sealed, err := s.seal(value, col.field, uID)
+			if err != nil {
+				return err
+			}
+			_, err = tx.ExecContext(ctx,
+				fmt.Sprintf("UPDATE %s SET %s = ? WHERE %s = ?", col.table, col.column, col.idCol), sealed, id)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	_, err = tx.ExecContext(ctx, "UPDATE users SET fields_sealed = 1 WHERE uId = ?", uID)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// fieldsSealed сообщает, зашифрованы ли уже все секретные поля пользователя.
+func (s *Storage) fieldsSealed(ctx context.Context, uID int64) (bool, error) {
+	var sealed bool
+	err := s.db.QueryRowContext(ctx, "SELECT fields_sealed FROM users WHERE uId = ?", uID).Scan(&sealed)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return false, err
+	}
+	return sealed, nil
+}
+
+// seal шифрует значение секретного поля. Шифротекст привязан к полю и пользователю.
+func (s *Storage) seal(value []byte, field string, uID int64) (string, error) {
+	if s.fieldKey == nil {
+		return "", ErrVaultLocked
+	}
+	return coder.Seal(s.fieldKey, value, fieldAD(field, uID))
+}
+
+// open расшифровывает значение секретного поля.
+// Значения, сохраненные до появления шифрования, возвращаются как есть, пока EncryptExisting их не зашифровал.
+// После этого открытое значение считается поврежденным.
+func (s *Storage) open(value string, field string, uID int64) ([]byte, error) {
+	if !coder.IsSealed(value) {
+		sealed, err := s.fieldsSealed(context.Background(), uID)
+		if err != nil {
+			return nil, err
+		}
+		if sealed {
+			return nil, fmt.Errorf("%w: %s is not encrypted", coder.ErrDecodeData, field)
+		}
+		return []byte(value), nil
+	}
+	if s.fieldKey == nil {
+		return nil, ErrVaultLocked
+	}
+	return coder.Open(s.fieldKey, value, fieldAD(field, uID))
+}
+
+func (s *Storage) sealString(value string, field string, uID int64) (string, error) {
+	return s.seal([]byte(value), field, uID)
+}
+
+func (s *Storage) openString(value string, field string, uID int64) (string, error) {
+	data, err := s.open(value, field, uID)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+func fieldAD(field string, uID int64) []byte {
+	return []byte(fmt.Sprintf("%s:%d", field, uID))
+}
+
+func (s *Storage) sealCard(number string, cvv string, uID int64) (string, string, error) {
+	sealedNumber, err := s.sealString(number, fieldCardNumber, uID)
+	if err != nil {
+		return "", "", err
+	}
+	sealedCVV, err := s.sealString(cvv, fieldCardCVV, uID)
+	if err != nil {
+		return "", "", err
+	}
+	return sealedNumber, sealedCVV, nil
+}
+
+func (s *Storage) openCard(number string, cvv string, uID int64) (string, string, error) {
+	openNumber, err := s.openString(number, fieldCardNumber, uID)
+	if err != nil {
+		return "", "", err
+	}
+	openCVV, err := s.openString(cvv, fieldCardCVV, uID)
+	if err != nil {
+		return "", "", err
+	}
+	return openNumber, openCVV, nil
+}
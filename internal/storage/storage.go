@@ -5,10 +5,12 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"sync"
 	"time"
 
 	errText "github.com/Dorrrke/GophKeeper-client/internal/domain/errors"
 	"github.com/Dorrrke/GophKeeper-client/internal/domain/models"
+	"github.com/Dorrrke/GophKeeper-client/internal/hlc"
 	"github.com/mattn/go-sqlite3"
 )
 
@@ -23,10 +25,22 @@ var (
 	ErrLoginNotExist    = errors.New(errText.LoginNotExistsError)
 	ErrTextNotExist     = errors.New(errText.TextNotExistsError)
 	ErrBinDataNotExist  = errors.New(errText.BinDataNotExistsError)
+	ErrVaultLocked      = errors.New(errText.VaultLockedError)
+	ErrOTPAlredyExist   = errors.New(errText.OTPExistsError)
+	ErrOTPNotExist      = errors.New(errText.OTPNotExistsError)
+	ErrFolderExist      = errors.New(errText.FolderExistsError)
+	ErrFolderNotExist   = errors.New(errText.FolderNotExistsError)
+	ErrVersionNotExist  = errors.New(errText.VersionNotExistsError)
 )
 
 type Storage struct {
-	db *sql.DB
+	db       *sql.DB
+	fieldKey []byte
+	// historyLimit - число хранимых предыдущих версий каждой записи. 0 - без ограничения.
+	historyLimit int
+	// clock - гибридные часы устройства для меток изменения записей, загружаются из базы при первом обращении.
+	clock   *hlc.Clock
+	clockMu sync.Mutex
 }
 
 func New(storagePath string) (*Storage, error) {
@@ -39,7 +53,8 @@ func New(storagePath string) (*Storage, error) {
 }
 
 func (s *Storage) SaveUser(ctx context.Context, user models.UserModel) (int64, error) {
-	stmt, err := s.db.Prepare("INSERT INTO users(login, hash) VALUES(?,?)")
+	// Поля нового пользователя сразу сохраняются зашифрованными, шифровать существующие записи для него не нужно.
+	stmt, err := s.db.Prepare("INSERT INTO users(login, hash, fields_sealed) VALUES(?,?,1)")
 	if err != nil {
 		return 0, err
 	}
@@ -77,14 +92,159 @@ func (s *Storage) GetUserHash(ctx context.Context, login string) (int64, string,
 	return uID, hash, nil
 }
 
+// GetUsers возвращает всех пользователей локальной базы без хешей паролей, упорядоченных по логину.
+func (s *Storage) GetUsers(ctx context.Context) ([]models.UserModel, error) {
+	rows, err := s.db.QueryContext(ctx, "SELECT uId, login FROM users ORDER BY login")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var users []models.UserModel
+	for rows.Next() {
+		var user models.UserModel
+		if err := rows.Scan(&user.UserID, &user.Login); err != nil {
+			return nil, err
+		}
+		users = append(users, user)
+	}
+	return users, rows.Err()
+}
+
+func (s *Storage) UpdateUserHash(ctx context.Context, uID int64, hash string) error {
+	stmt, err := s.db.Prepare("UPDATE users SET hash = ? WHERE uId = ?")
+	if err != nil {
+		return err
+	}
+	_, err = stmt.ExecContext(ctx, hash, uID)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// GetLoginAttempts возвращает неудачные попытки входа под логином.
+func (s *Storage) GetLoginAttempts(ctx context.Context, login string) (models.LoginAttemptsModel, error) {
+	stmt, err := s.db.Prepare("SELECT failures, last_failure, locked_until FROM login_attempts WHERE login = ?")
+	if err != nil {
+		return models.LoginAttemptsModel{}, err
+	}
+	row := stmt.QueryRowContext(ctx, login)
+	var attempts models.LoginAttemptsModel
+	var lastFailure, lockedUntil sql.NullString
+	err = row.Scan(&attempts.Failures, &lastFailure, &lockedUntil)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.LoginAttemptsModel{}, nil
+		}
+		return models.LoginAttemptsModel{}, err
+	}
+	if attempts.LastFailure, err = parseAttemptTime(lastFailure); err != nil {
+		return models.LoginAttemptsModel{}, err
+	}
+	if attempts.LockedUntil, err = parseAttemptTime(lockedUntil); err != nil {
+		return models.LoginAttemptsModel{}, err
+	}
+	return attempts, nil
+}
+
+// SaveLoginAttempts сохраняет неудачные попытки входа под логином.
+func (s *Storage) SaveLoginAttempts(ctx context.Context, login string, attempts models.LoginAttemptsModel) error {
+	stmt, err := s.db.Prepare(`INSERT INTO login_attempts(login, failures, last_failure, locked_until) VALUES(?,?,?,?)
+	ON CONFLICT(login) DO UPDATE SET failures = excluded.failures, last_failure = excluded.last_failure, locked_until = excluded.locked_until`)
+	if err != nil {
+		return err
+	}
+	_, err = stmt.ExecContext(ctx, login, attempts.Failures, formatAttemptTime(attempts.LastFailure), formatAttemptTime(attempts.LockedUntil))
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// ResetLoginAttempts сбрасывает счетчик неудачных попыток после успешного входа.
+func (s *Storage) ResetLoginAttempts(ctx context.Context, login string) error {
+	stmt, err := s.db.Prepare("DELETE FROM login_attempts WHERE login = ?")
+	if err != nil {
+		return err
+	}
+	_, err = stmt.ExecContext(ctx, login)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func formatAttemptTime(t time.Time) sql.NullString {
+	if t.IsZero() {
+		return sql.NullString{}
+	}
+	return sql.NullString{String: t.Format(time.RFC3339Nano), Valid: true}
+}
+
+func parseAttemptTime(value sql.NullString) (time.Time, error) {
+	if !value.Valid || value.String == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse(time.RFC3339Nano, value.String)
+}
+
+func (s *Storage) GetUserKDF(ctx context.Context, uID int64) (models.KDFModel, error) {
+	stmt, err := s.db.Prepare("SELECT kdf_salt, kdf_params FROM users WHERE uId = ?")
+	if err != nil {
+		return models.KDFModel{}, err
+	}
+	row := stmt.QueryRowContext(ctx, uID)
+	var salt []byte
+	var params sql.NullString
+	err = row.Scan(&salt, &params)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.KDFModel{}, ErrUserNotExist
+		}
+		return models.KDFModel{}, err
+	}
+	return models.KDFModel{
+		Salt:   salt,
+		Params: params.String,
+	}, nil
+}
+
+func (s *Storage) SaveUserKDF(ctx context.Context, uID int64, kdf models.KDFModel) error {
+	stmt, err := s.db.Prepare("UPDATE users SET kdf_salt = ?, kdf_params = ? WHERE uId = ?")
+	if err != nil {
+		return err
+	}
+	_, err = stmt.ExecContext(ctx, kdf.Salt, kdf.Params, uID)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
 func (s *Storage) SaveCard(ctx context.Context, card models.CardModel, uID int64) (int64, error) {
-	stmt, err := s.db.Prepare("INSERT INTO cards(name, number, date, cvv, uId, deleted, last_update) VALUES(?,?,?,?,?,?,?)")
+	stmt, err := s.db.Prepare("INSERT INTO cards(name, number, date, cvv, uId, deleted, last_update, uuid) VALUES(?,?,?,?,?,?,?,?)")
 	if err != nil {
 		return 0, err
 	}
 
-	t := time.Now()
-	res, err := stmt.ExecContext(ctx, card.Name, card.Number, card.Date, card.CVVCode, uID, false, t.Format(time.RFC3339))
+	number, cvv, err := s.sealCard(card.Number, card.CVVCode, uID)
+	if err != nil {
+		return 0, err
+	}
+	uuid, err := newUUID()
+	if err != nil {
+		return 0, err
+	}
+	t, err := s.tick(ctx)
+	if err != nil {
+		return 0, err
+	}
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+	res, err := tx.StmtContext(ctx, stmt).ExecContext(ctx, card.Name, number, card.Date, cvv, uID, false, t, uuid)
 	if err != nil {
 		var sqliteErr sqlite3.Error
 		if errors.As(err, &sqliteErr) && sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique {
@@ -98,17 +258,40 @@ func (s *Storage) SaveCard(ctx context.Context, card models.CardModel, uID int64
 	if err != nil {
 		return 0, err
 	}
+	attrs := recordAttrs{meta: card.Meta, folder: card.Folder, tags: card.Tags}
+	if err := s.saveAttrs(ctx, tx, kindCard, card.Name, uID, attrs, true, t); err != nil {
+		return 0, err
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
 
 	return cID, nil
 }
 
 func (s *Storage) SaveLogin(ctx context.Context, loginData models.LoginModel, uID int64) (int64, error) {
-	stmt, err := s.db.Prepare("INSERT INTO logins(name, login, password, uId, deleted, last_update) VALUES(?,?,?,?,?,?)")
+	stmt, err := s.db.Prepare("INSERT INTO logins(name, login, password, uId, deleted, last_update, uuid) VALUES(?,?,?,?,?,?,?)")
+	if err != nil {
+		return 0, err
+	}
+	password, err := s.sealString(loginData.Password, fieldLoginPassword, uID)
+	if err != nil {
+		return 0, err
+	}
+	uuid, err := newUUID()
+	if err != nil {
+		return 0, err
+	}
+	t, err := s.tick(ctx)
+	if err != nil {
+		return 0, err
+	}
+	tx, err := s.db.BeginTx(ctx, nil)
 	if err != nil {
 		return 0, err
 	}
-	t := time.Now()
-	res, err := stmt.ExecContext(ctx, loginData.Name, loginData.Login, loginData.Password, uID, false, t.Format(time.RFC3339))
+	defer tx.Rollback()
+	res, err := tx.StmtContext(ctx, stmt).ExecContext(ctx, loginData.Name, loginData.Login, password, uID, false, t, uuid)
 	if err != nil {
 		var sqliteErr sqlite3.Error
 		if errors.As(err, &sqliteErr) && sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique {
@@ -120,18 +303,41 @@ func (s *Storage) SaveLogin(ctx context.Context, loginData models.LoginModel, uI
 	if err != nil {
 		return 0, err
 	}
+	attrs := recordAttrs{meta: loginData.Meta, folder: loginData.Folder, tags: loginData.Tags}
+	if err := s.saveAttrs(ctx, tx, kindLogin, loginData.Name, uID, attrs, true, t); err != nil {
+		return 0, err
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
 
 	return cID, nil
 }
 
 func (s *Storage) SaveText(ctx context.Context, textData models.TextDataModel, uID int64) (int64, error) {
-	stmt, err := s.db.Prepare("INSERT INTO text_data(name, data, uId, deleted, last_update) VALUES(?,?,?,?,?)")
+	stmt, err := s.db.Prepare("INSERT INTO text_data(name, data, uId, deleted, last_update, uuid) VALUES(?,?,?,?,?,?)")
 	if err != nil {
 		return 0, err
 	}
 
-	t := time.Now()
-	res, err := stmt.ExecContext(ctx, textData.Name, textData.Data, uID, false, t.Format(time.RFC3339))
+	data, err := s.sealString(textData.Data, fieldTextData, uID)
+	if err != nil {
+		return 0, err
+	}
+	uuid, err := newUUID()
+	if err != nil {
+		return 0, err
+	}
+	t, err := s.tick(ctx)
+	if err != nil {
+		return 0, err
+	}
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+	res, err := tx.StmtContext(ctx, stmt).ExecContext(ctx, textData.Name, data, uID, false, t, uuid)
 	if err != nil {
 		var sqliteErr sqlite3.Error
 		if errors.As(err, &sqliteErr) && sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique {
@@ -143,18 +349,41 @@ func (s *Storage) SaveText(ctx context.Context, textData models.TextDataModel, u
 	if err != nil {
 		return 0, err
 	}
+	attrs := recordAttrs{meta: textData.Meta, folder: textData.Folder, tags: textData.Tags}
+	if err := s.saveAttrs(ctx, tx, kindText, textData.Name, uID, attrs, true, t); err != nil {
+		return 0, err
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
 
 	return cID, nil
 }
 
 func (s *Storage) SaveBin(ctx context.Context, binData models.BinaryDataModel, uID int64) (int64, error) {
-	stmt, err := s.db.Prepare("INSERT INTO binares_data(name, data, uId, deleted, last_update) VALUES(?,?,?,?,?)")
+	stmt, err := s.db.Prepare("INSERT INTO binares_data(name, data, uId, deleted, last_update, uuid) VALUES(?,?,?,?,?,?)")
 	if err != nil {
 		return 0, err
 	}
 
-	t := time.Now()
-	res, err := stmt.ExecContext(ctx, binData.Name, binData.Data, uID, false, t.Format(time.RFC3339))
+	data, err := s.seal(binData.Data, fieldBinData, uID)
+	if err != nil {
+		return 0, err
+	}
+	uuid, err := newUUID()
+	if err != nil {
+		return 0, err
+	}
+	t, err := s.tick(ctx)
+	if err != nil {
+		return 0, err
+	}
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+	res, err := tx.StmtContext(ctx, stmt).ExecContext(ctx, binData.Name, data, uID, false, t, uuid)
 	if err != nil {
 		var sqliteErr sqlite3.Error
 		if errors.As(err, &sqliteErr) && sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique {
@@ -166,6 +395,13 @@ func (s *Storage) SaveBin(ctx context.Context, binData models.BinaryDataModel, u
 	if err != nil {
 		return 0, err
 	}
+	attrs := recordAttrs{meta: binData.Meta, folder: binData.Folder, tags: binData.Tags}
+	if err := s.saveAttrs(ctx, tx, kindBin, binData.Name, uID, attrs, true, t); err != nil {
+		return 0, err
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
 
 	return cID, nil
 }
@@ -184,7 +420,12 @@ func (s *Storage) GetAllCards(ctx context.Context, uID int64) ([]models.CardMode
 	var cards []models.CardModel
 	for rows.Next() {
 		var card models.CardModel
-		err := rows.Scan(&card.Name, &card.Number, &card.Date, &card.CVVCode)
+		var number, cvv string
+		err := rows.Scan(&card.Name, &number, &card.Date, &cvv)
+		if err != nil {
+			return nil, err
+		}
+		card.Number, card.CVVCode, err = s.openCard(number, cvv, uID)
 		if err != nil {
 			return nil, err
 		}
@@ -195,11 +436,19 @@ func (s *Storage) GetAllCards(ctx context.Context, uID int64) ([]models.CardMode
 	if err != nil {
 		return nil, err
 	}
+	attrs, err := s.getAllAttrs(ctx, kindCard, uID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range cards {
+		a := attrs[cards[i].Name]
+		cards[i].Meta, cards[i].Folder, cards[i].Tags = a.meta, a.folder, a.tags
+	}
 	return cards, nil
 }
 
 func (s *Storage) GetAllLogins(ctx context.Context, uID int64) ([]models.LoginModel, error) {
-	stmt, err := s.db.Prepare("SELECT name, login, password FROM logins WHERE uId=? AND deleted=0")
+	stmt, err := s.db.Prepare("SELECT name, login, password, last_update FROM logins WHERE uId=? AND deleted=0")
 	if err != nil {
 		return nil, err
 	}
@@ -212,10 +461,20 @@ func (s *Storage) GetAllLogins(ctx context.Context, uID int64) ([]models.LoginMo
 	var logins []models.LoginModel
 	for rows.Next() {
 		var login models.LoginModel
-		err := rows.Scan(&login.Name, &login.Login, &login.Password)
+		var password string
+		var updated sql.NullString
+		err := rows.Scan(&login.Name, &login.Login, &password, &updated)
+		if err != nil {
+			return nil, err
+		}
+		login.Password, err = s.openString(password, fieldLoginPassword, uID)
 		if err != nil {
 			return nil, err
 		}
+		if updated.Valid {
+			ts, _ := hlc.Parse(updated.String)
+			login.Updated = ts.Time()
+		}
 		logins = append(logins, login)
 	}
 
@@ -223,6 +482,14 @@ func (s *Storage) GetAllLogins(ctx context.Context, uID int64) ([]models.LoginMo
 	if err != nil {
 		return nil, err
 	}
+	attrs, err := s.getAllAttrs(ctx, kindLogin, uID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range logins {
+		a := attrs[logins[i].Name]
+		logins[i].Meta, logins[i].Folder, logins[i].Tags = a.meta, a.folder, a.tags
+	}
 	return logins, nil
 }
 
@@ -240,7 +507,12 @@ func (s *Storage) GetAllTextData(ctx context.Context, uID int64) ([]models.TextD
 	var tData []models.TextDataModel
 	for rows.Next() {
 		var data models.TextDataModel
-		err := rows.Scan(&data.Name, &data.Data)
+		var text string
+		err := rows.Scan(&data.Name, &text)
+		if err != nil {
+			return nil, err
+		}
+		data.Data, err = s.openString(text, fieldTextData, uID)
 		if err != nil {
 			return nil, err
 		}
@@ -251,6 +523,14 @@ func (s *Storage) GetAllTextData(ctx context.Context, uID int64) ([]models.TextD
 	if err != nil {
 		return nil, err
 	}
+	attrs, err := s.getAllAttrs(ctx, kindText, uID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range tData {
+		a := attrs[tData[i].Name]
+		tData[i].Meta, tData[i].Folder, tData[i].Tags = a.meta, a.folder, a.tags
+	}
 	return tData, nil
 }
 
@@ -268,7 +548,12 @@ func (s *Storage) GetAllBin(ctx context.Context, uID int64) ([]models.BinaryData
 	var bData []models.BinaryDataModel
 	for rows.Next() {
 		var data models.BinaryDataModel
-		err := rows.Scan(&data.Name, &data.Data)
+		var bin []byte
+		err := rows.Scan(&data.Name, &bin)
+		if err != nil {
+			return nil, err
+		}
+		data.Data, err = s.open(string(bin), fieldBinData, uID)
 		if err != nil {
 			return nil, err
 		}
@@ -279,6 +564,14 @@ func (s *Storage) GetAllBin(ctx context.Context, uID int64) ([]models.BinaryData
 	if err != nil {
 		return nil, err
 	}
+	attrs, err := s.getAllAttrs(ctx, kindBin, uID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range bData {
+		a := attrs[bData[i].Name]
+		bData[i].Meta, bData[i].Folder, bData[i].Tags = a.meta, a.folder, a.tags
+	}
 	return bData, nil
 }
 
@@ -290,13 +583,23 @@ func (s *Storage) GetCardByName(ctx context.Context, name string, uID int64) (mo
 
 	row := stmt.QueryRowContext(ctx, name, uID)
 	var card models.CardModel
-	err = row.Scan(&card.Name, &card.Number, &card.Date, &card.CVVCode)
+	var number, cvv string
+	err = row.Scan(&card.Name, &number, &card.Date, &cvv)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return models.CardModel{}, ErrCardNotExist
 		}
 		return models.CardModel{}, err
 	}
+	card.Number, card.CVVCode, err = s.openCard(number, cvv, uID)
+	if err != nil {
+		return models.CardModel{}, err
+	}
+	attrs, err := s.getAttrs(ctx, kindCard, name, uID)
+	if err != nil {
+		return models.CardModel{}, err
+	}
+	card.Meta, card.Folder, card.Tags = attrs.meta, attrs.folder, attrs.tags
 	return card, nil
 }
 
@@ -308,13 +611,23 @@ func (s *Storage) GetLoginByName(ctx context.Context, name string, uID int64) (m
 
 	row := stmt.QueryRowContext(ctx, name, uID)
 	var login models.LoginModel
-	err = row.Scan(&login.Name, &login.Login, &login.Password)
+	var password string
+	err = row.Scan(&login.Name, &login.Login, &password)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return models.LoginModel{}, ErrLoginNotExist
 		}
 		return models.LoginModel{}, err
 	}
+	login.Password, err = s.openString(password, fieldLoginPassword, uID)
+	if err != nil {
+		return models.LoginModel{}, err
+	}
+	attrs, err := s.getAttrs(ctx, kindLogin, name, uID)
+	if err != nil {
+		return models.LoginModel{}, err
+	}
+	login.Meta, login.Folder, login.Tags = attrs.meta, attrs.folder, attrs.tags
 	return login, nil
 }
 
@@ -326,13 +639,23 @@ func (s *Storage) GetTextDataByName(ctx context.Context, name string, uID int64)
 
 	row := stmt.QueryRowContext(ctx, name, uID)
 	var data models.TextDataModel
-	err = row.Scan(&data.Name, &data.Data)
+	var text string
+	err = row.Scan(&data.Name, &text)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return models.TextDataModel{}, ErrTextNotExist
 		}
 		return models.TextDataModel{}, err
 	}
+	data.Data, err = s.openString(text, fieldTextData, uID)
+	if err != nil {
+		return models.TextDataModel{}, err
+	}
+	attrs, err := s.getAttrs(ctx, kindText, name, uID)
+	if err != nil {
+		return models.TextDataModel{}, err
+	}
+	data.Meta, data.Folder, data.Tags = attrs.meta, attrs.folder, attrs.tags
 	return data, nil
 }
 
@@ -344,13 +667,23 @@ func (s *Storage) GetBinByName(ctx context.Context, name string, uID int64) (mod
 
 	row := stmt.QueryRowContext(ctx, name, uID)
 	var data models.BinaryDataModel
-	err = row.Scan(&data.Name, &data.Data)
+	var bin []byte
+	err = row.Scan(&data.Name, &bin)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return models.BinaryDataModel{}, ErrBinDataNotExist
 		}
 		return models.BinaryDataModel{}, err
 	}
+	data.Data, err = s.open(string(bin), fieldBinData, uID)
+	if err != nil {
+		return models.BinaryDataModel{}, err
+	}
+	attrs, err := s.getAttrs(ctx, kindBin, name, uID)
+	if err != nil {
+		return models.BinaryDataModel{}, err
+	}
+	data.Meta, data.Folder, data.Tags = attrs.meta, attrs.folder, attrs.tags
 	return data, nil
 }
 
@@ -359,8 +692,11 @@ func (s *Storage) DeleteCard(ctx context.Context, name string, uID int64) error
 	if err != nil {
 		return err
 	}
-	t := time.Now()
-	_, err = stmt.ExecContext(ctx, t.Format(time.RFC3339), name, uID)
+	t, err := s.tick(ctx)
+	if err != nil {
+		return err
+	}
+	_, err = stmt.ExecContext(ctx, t, name, uID)
 	if err != nil {
 		return err
 	}
@@ -372,8 +708,11 @@ func (s *Storage) DeleteLogin(ctx context.Context, name string, uID int64) error
 	if err != nil {
 		return err
 	}
-	t := time.Now()
-	_, err = stmt.ExecContext(ctx, t.Format(time.RFC3339), name, uID)
+	t, err := s.tick(ctx)
+	if err != nil {
+		return err
+	}
+	_, err = stmt.ExecContext(ctx, t, name, uID)
 	if err != nil {
 		return err
 	}
@@ -385,8 +724,11 @@ func (s *Storage) DeleteText(ctx context.Context, name string, uID int64) error
 	if err != nil {
 		return err
 	}
-	t := time.Now()
-	_, err = stmt.ExecContext(ctx, t.Format(time.RFC3339), name, uID)
+	t, err := s.tick(ctx)
+	if err != nil {
+		return err
+	}
+	_, err = stmt.ExecContext(ctx, t, name, uID)
 	if err != nil {
 		return err
 	}
@@ -398,8 +740,11 @@ func (s *Storage) DeleteBin(ctx context.Context, name string, uID int64) error {
 	if err != nil {
 		return err
 	}
-	t := time.Now()
-	_, err = stmt.ExecContext(ctx, t.Format(time.RFC3339), name, uID)
+	t, err := s.tick(ctx)
+	if err != nil {
+		return err
+	}
+	_, err = stmt.ExecContext(ctx, t, name, uID)
 	if err != nil {
 		return err
 	}
@@ -407,59 +752,158 @@ func (s *Storage) DeleteBin(ctx context.Context, name string, uID int64) error {
 }
 
 func (s *Storage) UpdateCard(ctx context.Context, card models.CardModel, uID int64) error {
+	prev, err := s.GetCardByName(ctx, card.Name, uID)
+	if err != nil {
+		return err
+	}
 	stmt, err := s.db.Prepare("UPDATE cards SET name = ?, number = ?, date = ?, cvv = ?, last_update = ? WHERE name = ? and uId = ?")
 	if err != nil {
 		return err
 	}
-	lTime := time.Now().Format(time.RFC3339)
-	_, err = stmt.ExecContext(ctx, card.Name, card.Number, card.Date, card.CVVCode, lTime, card.Name, uID)
+	number, cvv, err := s.sealCard(card.Number, card.CVVCode, uID)
 	if err != nil {
 		return err
 	}
-	return nil
+	lTime, err := s.tick(ctx)
+	if err != nil {
+		return err
+	}
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if err := s.saveHistory(ctx, tx, kindCard, card.Name, uID, prev); err != nil {
+		return err
+	}
+	_, err = tx.StmtContext(ctx, stmt).ExecContext(ctx, card.Name, number, card.Date, cvv, lTime, card.Name, uID)
+	if err != nil {
+		return err
+	}
+	attrs := recordAttrs{meta: card.Meta, folder: card.Folder, tags: card.Tags}
+	if err := s.saveAttrs(ctx, tx, kindCard, card.Name, uID, attrs, false, lTime); err != nil {
+		return err
+	}
+	return tx.Commit()
 }
 
 func (s *Storage) UpdateLogin(ctx context.Context, auth models.LoginModel, uID int64) error {
+	prev, err := s.GetLoginByName(ctx, auth.Name, uID)
+	if err != nil {
+		return err
+	}
 	stmt, err := s.db.Prepare("UPDATE logins SET name = ?, login = ?, password = ?, last_update = ? WHERE name = ? and uId = ?")
 	if err != nil {
 		return err
 	}
-	lTime := time.Now().Format(time.RFC3339)
-	_, err = stmt.ExecContext(ctx, auth.Name, auth.Login, auth.Password, lTime, auth.Name, uID)
+	password, err := s.sealString(auth.Password, fieldLoginPassword, uID)
 	if err != nil {
 		return err
 	}
-	return nil
+	lTime, err := s.tick(ctx)
+	if err != nil {
+		return err
+	}
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if err := s.saveHistory(ctx, tx, kindLogin, auth.Name, uID, prev); err != nil {
+		return err
+	}
+	_, err = tx.StmtContext(ctx, stmt).ExecContext(ctx, auth.Name, auth.Login, password, lTime, auth.Name, uID)
+	if err != nil {
+		return err
+	}
+	attrs := recordAttrs{meta: auth.Meta, folder: auth.Folder, tags: auth.Tags}
+	if err := s.saveAttrs(ctx, tx, kindLogin, auth.Name, uID, attrs, false, lTime); err != nil {
+		return err
+	}
+	return tx.Commit()
 }
 
 func (s *Storage) UpdateText(ctx context.Context, data models.TextDataModel, uID int64) error {
+	prev, err := s.GetTextDataByName(ctx, data.Name, uID)
+	if err != nil {
+		return err
+	}
 	stmt, err := s.db.Prepare("UPDATE text_data SET name = ?, data = ?, last_update = ? WHERE name = ? and uId = ?")
 	if err != nil {
 		return err
 	}
-	lTime := time.Now().Format(time.RFC3339)
-	_, err = stmt.ExecContext(ctx, data.Name, data.Data, lTime, data.Name, uID)
+	text, err := s.sealString(data.Data, fieldTextData, uID)
 	if err != nil {
 		return err
 	}
-	return nil
+	lTime, err := s.tick(ctx)
+	if err != nil {
+		return err
+	}
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if err := s.saveHistory(ctx, tx, kindText, data.Name, uID, prev); err != nil {
+		return err
+	}
+	_, err = tx.StmtContext(ctx, stmt).ExecContext(ctx, data.Name, text, lTime, data.Name, uID)
+	if err != nil {
+		return err
+	}
+	attrs := recordAttrs{meta: data.Meta, folder: data.Folder, tags: data.Tags}
+	if err := s.saveAttrs(ctx, tx, kindText, data.Name, uID, attrs, false, lTime); err != nil {
+		return err
+	}
+	return tx.Commit()
 }
 
 func (s *Storage) UpdateBin(ctx context.Context, data models.BinaryDataModel, uID int64) error {
+	prev, err := s.GetBinByName(ctx, data.Name, uID)
+	if err != nil {
+		return err
+	}
 	stmt, err := s.db.Prepare("UPDATE binares_data SET name = ?, data = ?, last_update = ? WHERE name = ? and uId = ?")
 	if err != nil {
 		return err
 	}
-	lTime := time.Now().Format(time.RFC3339)
-	_, err = stmt.ExecContext(ctx, data.Name, data.Data, lTime, data.Name, uID)
+	bin, err := s.seal(data.Data, fieldBinData, uID)
 	if err != nil {
 		return err
 	}
-	return nil
+	lTime, err := s.tick(ctx)
+	if err != nil {
+		return err
+	}
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if err := s.saveHistory(ctx, tx, kindBin, data.Name, uID, prev); err != nil {
+		return err
+	}
+	_, err = tx.StmtContext(ctx, stmt).ExecContext(ctx, data.Name, bin, lTime, data.Name, uID)
+	if err != nil {
+		return err
+	}
+	attrs := recordAttrs{meta: data.Meta, folder: data.Folder, tags: data.Tags}
+	if err := s.saveAttrs(ctx, tx, kindBin, data.Name, uID, attrs, false, lTime); err != nil {
+		return err
+	}
+	return tx.Commit()
 }
 
+// GetAllSaves возвращает все записи пользователя, включая удаленные, для синхронизации.
 func (s *Storage) GetAllSaves(ctx context.Context, uID int64) (models.SyncModel, error) {
-	stmt, err := s.db.Prepare("SELECT name, data, uId, deleted, last_update FROM binares_data WHERE uId = ?")
+	return s.getSaves(ctx, uID, "", "")
+}
+
+// getSaves возвращает записи пользователя для синхронизации. filter дополняет условие выборки записей,
+// attrFilter - то же условие для таблицы записей с псевдонимом r при выборке атрибутов.
+func (s *Storage) getSaves(ctx context.Context, uID int64, filter string, attrFilter string) (models.SyncModel, error) {
+	stmt, err := s.db.Prepare("SELECT name, COALESCE(uuid, ''), data, uId, deleted, last_update, synced_update FROM binares_data WHERE uId = ? " + filter)
 	if err != nil {
 		return models.SyncModel{}, err
 	}
@@ -471,14 +915,19 @@ func (s *Storage) GetAllSaves(ctx context.Context, uID int64) (models.SyncModel,
 	var binData []models.SyncBinaryDataModel
 	for binRows.Next() {
 		var data models.SyncBinaryDataModel
-		err := binRows.Scan(&data.Name, &data.Data, &data.UserID, &data.Deleted, &data.Updated)
+		var bin []byte
+		err := binRows.Scan(&data.Name, &data.UUID, &bin, &data.UserID, &data.Deleted, &data.Updated, &data.Base)
+		if err != nil {
+			return models.SyncModel{}, err
+		}
+		data.Data, err = s.open(string(bin), fieldBinData, data.UserID)
 		if err != nil {
 			return models.SyncModel{}, err
 		}
 		binData = append(binData, data)
 	}
 
-	stmt, err = s.db.Prepare("SELECT name, data, uId, deleted, last_update FROM text_data WHERE uId = ?")
+	stmt, err = s.db.Prepare("SELECT name, COALESCE(uuid, ''), data, uId, deleted, last_update, synced_update FROM text_data WHERE uId = ? " + filter)
 	if err != nil {
 		return models.SyncModel{}, err
 	}
@@ -490,14 +939,19 @@ func (s *Storage) GetAllSaves(ctx context.Context, uID int64) (models.SyncModel,
 	var textData []models.SyncTextDataModel
 	for textRows.Next() {
 		var data models.SyncTextDataModel
-		err := textRows.Scan(&data.Name, &data.Data, &data.UserID, &data.Deleted, &data.Updated)
+		var text string
+		err := textRows.Scan(&data.Name, &data.UUID, &text, &data.UserID, &data.Deleted, &data.Updated, &data.Base)
+		if err != nil {
+			return models.SyncModel{}, err
+		}
+		data.Data, err = s.openString(text, fieldTextData, data.UserID)
 		if err != nil {
 			return models.SyncModel{}, err
 		}
 		textData = append(textData, data)
 	}
 
-	stmt, err = s.db.Prepare("SELECT name, login, password, uId, deleted, last_update FROM logins WHERE uId = ?")
+	stmt, err = s.db.Prepare("SELECT name, COALESCE(uuid, ''), login, password, uId, deleted, last_update, synced_update FROM logins WHERE uId = ? " + filter)
 	if err != nil {
 		return models.SyncModel{}, err
 	}
@@ -509,14 +963,19 @@ func (s *Storage) GetAllSaves(ctx context.Context, uID int64) (models.SyncModel,
 	var loginData []models.SyncLoginModel
 	for authRows.Next() {
 		var data models.SyncLoginModel
-		err := authRows.Scan(&data.Name, &data.Login, &data.Password, &data.UserID, &data.Deleted, &data.Updated)
+		var password string
+		err := authRows.Scan(&data.Name, &data.UUID, &data.Login, &password, &data.UserID, &data.Deleted, &data.Updated, &data.Base)
+		if err != nil {
+			return models.SyncModel{}, err
+		}
+		data.Password, err = s.openString(password, fieldLoginPassword, data.UserID)
 		if err != nil {
 			return models.SyncModel{}, err
 		}
 		loginData = append(loginData, data)
 	}
 
-	stmt, err = s.db.Prepare("SELECT name, number, date, cvv, uId, deleted, last_update FROM cards WHERE uId = ?")
+	stmt, err = s.db.Prepare("SELECT name, COALESCE(uuid, ''), number, date, cvv, uId, deleted, last_update, synced_update FROM cards WHERE uId = ? " + filter)
 	if err != nil {
 		return models.SyncModel{}, err
 	}
@@ -528,105 +987,252 @@ func (s *Storage) GetAllSaves(ctx context.Context, uID int64) (models.SyncModel,
 	var cardData []models.SyncCardModel
 	for cardsRows.Next() {
 		var data models.SyncCardModel
-		err := cardsRows.Scan(&data.Name, &data.Number, &data.Date, &data.CVVCode, &data.UserID, &data.Deleted, &data.Updated)
+		var number, cvv string
+		err := cardsRows.Scan(&data.Name, &data.UUID, &number, &data.Date, &cvv, &data.UserID, &data.Deleted, &data.Updated, &data.Base)
+		if err != nil {
+			return models.SyncModel{}, err
+		}
+		data.Number, data.CVVCode, err = s.openCard(number, cvv, data.UserID)
 		if err != nil {
 			return models.SyncModel{}, err
 		}
 		cardData = append(cardData, data)
 	}
 
-	return models.SyncModel{
-		Cards: cardData,
-		Auth:  loginData,
-		Texts: textData,
-		Bins:  binData,
-	}, err
-}
-
-func (s *Storage) ClearDB(ctx context.Context, uId int64) error {
-	stmt, err := s.db.Prepare("DELETE FROM logins WHERE deleted = true AND uId = ?")
-	if err != nil {
-		return err
-	}
-	if _, err := stmt.ExecContext(ctx, uId); err != nil {
-		return err
-	}
-	stmt, err = s.db.Prepare("DELETE FROM text_data WHERE deleted = true AND uId = $1")
+	otpData, err := s.getSyncOTP(ctx, uID, filter, attrFilter)
 	if err != nil {
-		return err
+		return models.SyncModel{}, err
 	}
-	if _, err := stmt.ExecContext(ctx, uId); err != nil {
-		return err
+
+	for _, kind := range []string{kindCard, kindLogin, kindText, kindBin} {
+		attrs, err := s.loadAttrs(ctx, kind, uID, attrFilter)
+		if err != nil {
+			return models.SyncModel{}, err
+		}
+		switch kind {
+		case kindCard:
+			for i := range cardData {
+				a := attrs[cardData[i].Name]
+				cardData[i].Meta, cardData[i].Folder, cardData[i].Tags = a.meta, a.folder, a.tags
+			}
+		case kindLogin:
+			for i := range loginData {
+				a := attrs[loginData[i].Name]
+				loginData[i].Meta, loginData[i].Folder, loginData[i].Tags = a.meta, a.folder, a.tags
+			}
+		case kindText:
+			for i := range textData {
+				a := attrs[textData[i].Name]
+				textData[i].Meta, textData[i].Folder, textData[i].Tags = a.meta, a.folder, a.tags
+			}
+		case kindBin:
+			for i := range binData {
+				a := attrs[binData[i].Name]
+				binData[i].Meta, binData[i].Folder, binData[i].Tags = a.meta, a.folder, a.tags
+			}
+		}
 	}
-	stmt, err = s.db.Prepare("DELETE FROM binares_data WHERE deleted = true AND uId = $1")
+
+	renames, err := s.getRenames(ctx, uID)
 	if err != nil {
-		return err
+		return models.SyncModel{}, err
 	}
-	if _, err := stmt.ExecContext(ctx, uId); err != nil {
-		return err
+	for _, r := range renames {
+		switch r.kind {
+		case kindCard:
+			cardData = append(cardData, models.SyncCardModel{UserID: uID, Name: r.name, Deleted: true, Updated: r.updated})
+		case kindLogin:
+			loginData = append(loginData, models.SyncLoginModel{UserID: uID, Name: r.name, Deleted: true, Updated: r.updated})
+		case kindText:
+			textData = append(textData, models.SyncTextDataModel{UserID: uID, Name: r.name, Deleted: true, Updated: r.updated})
+		case kindBin:
+			binData = append(binData, models.SyncBinaryDataModel{UserID: uID, Name: r.name, Deleted: true, Updated: r.updated})
+		case kindOTP:
+			otpData = append(otpData, models.SyncOTPModel{UserID: uID, Name: r.name, Deleted: true, Updated: r.updated})
+		}
 	}
-	stmt, err = s.db.Prepare("DELETE FROM cards WHERE deleted = true AND uId = $1")
+
+	folders, err := s.getSyncFolders(ctx, uID, filter)
 	if err != nil {
-		return err
+		return models.SyncModel{}, err
 	}
-	if _, err := stmt.ExecContext(ctx, uId); err != nil {
-		return err
+
+	return models.SyncModel{
+		Cards:   cardData,
+		Auth:    loginData,
+		Texts:   textData,
+		Bins:    binData,
+		OTPs:    otpData,
+		Folders: folders,
+	}, err
+}
+
+// ClearDB окончательно удаляет записи пользователя, удаленные не позднее before, вместе с их атрибутами и историей.
+// Удаляются только записи, удаление которых уже подтверждено сервером, иначе они вернутся при следующей синхронизации.
+func (s *Storage) ClearDB(ctx context.Context, uId int64, before time.Time) error {
+	for _, table := range []string{"logins", "text_data", "binares_data", "cards", "otp", "folders"} {
+		stmt, err := s.db.Prepare(fmt.Sprintf(`DELETE FROM %s WHERE deleted = true AND uId = ? AND last_update < ?
+		AND synced_update IS last_update`, table))
+		if err != nil {
+			return err
+		}
+		// Метки сортируются как строки: граница меньше всех меток следующей после before миллисекунды.
+		bound := hlc.Timestamp{Wall: before.UnixMilli() + 1}
+		if _, err := stmt.ExecContext(ctx, uId, bound.String()); err != nil {
+			return err
+		}
 	}
-	return nil
+	return s.clearOrphanAttrs(ctx, uId)
 }
 
+// Sync сохраняет записи, полученные с сервера. Записи сопоставляются с локальными по идентификатору,
+// поэтому переименование на другом устройстве меняет имя локальной записи, а не создает новую.
 func (s *Storage) Sync(ctx context.Context, model models.SyncModel) error {
-	stmt, err := s.db.Prepare(`INSERT INTO cards (name, number, date, cvv, uId, deleted, last_update) VALUES (?,?,?,?,?,?,?)
-	ON CONFLICT (name) DO UPDATE SET name =?, number = ?, date = ?, cvv = ?, uId = ?, deleted = ?, last_update = ? WHERE name = ? and uId = ?`)
+	if err := s.observe(ctx, model); err != nil {
+		return err
+	}
+	stmt, err := s.db.Prepare(`INSERT INTO cards (uuid, name, number, date, cvv, uId, deleted, last_update, synced_update) VALUES (?,?,?,?,?,?,?,?,?)
+	ON CONFLICT (uId, uuid) DO UPDATE SET name = excluded.name, number = excluded.number, date = excluded.date, cvv = excluded.cvv,
+	deleted = excluded.deleted, last_update = excluded.last_update, synced_update = excluded.synced_update`)
 	if err != nil {
 		return err
 	}
 	for _, card := range model.Cards {
-		_, err := stmt.ExecContext(ctx, card.Name, card.Number, card.Date, card.CVVCode, card.UserID, card.Deleted, card.Updated,
-			card.Name, card.Number, card.Date, card.CVVCode, card.UserID, card.Deleted, card.Updated, card.Name, card.UserID)
+		rec, skip, err := s.resolveSync(ctx, kindCard, syncRecord{
+			uuid: card.UUID, name: card.Name, deleted: card.Deleted, updated: card.Updated, uID: card.UserID})
+		if err != nil {
+			return err
+		}
+		if skip {
+			continue
+		}
+		number, cvv, err := s.sealCard(card.Number, card.CVVCode, card.UserID)
+		if err != nil {
+			return err
+		}
+		attrs := recordAttrs{meta: card.Meta, folder: card.Folder, tags: card.Tags}
+		now, err := s.folderTime(ctx, attrs.folder)
+		if err != nil {
+			return err
+		}
+		err = s.inTx(ctx, func(tx *sql.Tx) error {
+			if _, err := tx.StmtContext(ctx, stmt).ExecContext(ctx, rec.uuid, rec.name, number, card.Date, cvv, card.UserID, card.Deleted, rec.updated, card.Updated); err != nil {
+				return err
+			}
+			return s.saveAttrs(ctx, tx, kindCard, rec.name, card.UserID, attrs, true, now)
+		})
 		if err != nil {
 			return err
 		}
 	}
 
-	stmt, err = s.db.Prepare(`INSERT INTO logins (name, login, password, uId, deleted, last_update) VALUES(?,?,?,?,?,?)
-	ON CONFLICT (name) DO UPDATE SET name =?, login = ?, password = ?, uId = ?, deleted = ?, last_update = ? WHERE name = ? and uId = ?`)
+	stmt, err = s.db.Prepare(`INSERT INTO logins (uuid, name, login, password, uId, deleted, last_update, synced_update) VALUES(?,?,?,?,?,?,?,?)
+	ON CONFLICT (uId, uuid) DO UPDATE SET name = excluded.name, login = excluded.login, password = excluded.password,
+	deleted = excluded.deleted, last_update = excluded.last_update, synced_update = excluded.synced_update`)
 	if err != nil {
 		return err
 	}
 	for _, auth := range model.Auth {
-		_, err := stmt.ExecContext(ctx, auth.Name, auth.Login, auth.Password, auth.UserID, auth.Deleted, auth.Updated,
-			auth.Name, auth.Login, auth.Password, auth.UserID, auth.Deleted, auth.Updated, auth.Name, auth.UserID)
+		rec, skip, err := s.resolveSync(ctx, kindLogin, syncRecord{
+			uuid: auth.UUID, name: auth.Name, deleted: auth.Deleted, updated: auth.Updated, uID: auth.UserID})
+		if err != nil {
+			return err
+		}
+		if skip {
+			continue
+		}
+		password, err := s.sealString(auth.Password, fieldLoginPassword, auth.UserID)
+		if err != nil {
+			return err
+		}
+		attrs := recordAttrs{meta: auth.Meta, folder: auth.Folder, tags: auth.Tags}
+		now, err := s.folderTime(ctx, attrs.folder)
+		if err != nil {
+			return err
+		}
+		err = s.inTx(ctx, func(tx *sql.Tx) error {
+			if _, err := tx.StmtContext(ctx, stmt).ExecContext(ctx, rec.uuid, rec.name, auth.Login, password, auth.UserID, auth.Deleted, rec.updated, auth.Updated); err != nil {
+				return err
+			}
+			return s.saveAttrs(ctx, tx, kindLogin, rec.name, auth.UserID, attrs, true, now)
+		})
 		if err != nil {
 			return err
 		}
 	}
 
+	stmt, err = s.db.Prepare(`INSERT INTO text_data(uuid, name, data, uId, deleted, last_update, synced_update) VALUES(?,?,?,?,?,?,?)
+	ON CONFLICT (uId, uuid) DO UPDATE SET name = excluded.name, data = excluded.data,
+	deleted = excluded.deleted, last_update = excluded.last_update, synced_update = excluded.synced_update`)
+	if err != nil {
+		return err
+	}
 	for _, text := range model.Texts {
-		stmt, err = s.db.Prepare(`INSERT INTO text_data(name, data, uId, deleted, last_update) VALUES(?,?,?,?,?)
-		ON CONFLICT (name) DO UPDATE SET name = ?, data = ?, uId = ?, deleted = ?, last_update = ? WHERE name = ? and uId = ?`)
+		rec, skip, err := s.resolveSync(ctx, kindText, syncRecord{
+			uuid: text.UUID, name: text.Name, deleted: text.Deleted, updated: text.Updated, uID: text.UserID})
+		if err != nil {
+			return err
+		}
+		if skip {
+			continue
+		}
+		data, err := s.sealString(text.Data, fieldTextData, text.UserID)
 		if err != nil {
 			return err
 		}
-		_, err := stmt.ExecContext(ctx, text.Name, text.Data, text.UserID, text.Deleted, text.Updated,
-			text.Name, text.Data, text.UserID, text.Deleted, text.Updated, text.Name, text.UserID)
+		attrs := recordAttrs{meta: text.Meta, folder: text.Folder, tags: text.Tags}
+		now, err := s.folderTime(ctx, attrs.folder)
+		if err != nil {
+			return err
+		}
+		err = s.inTx(ctx, func(tx *sql.Tx) error {
+			if _, err := tx.StmtContext(ctx, stmt).ExecContext(ctx, rec.uuid, rec.name, data, text.UserID, text.Deleted, rec.updated, text.Updated); err != nil {
+				return err
+			}
+			return s.saveAttrs(ctx, tx, kindText, rec.name, text.UserID, attrs, true, now)
+		})
 		if err != nil {
 			return err
 		}
 	}
 
-	stmt, err = s.db.Prepare(`INSERT INTO binares_data(name, data, uId, deleted, last_update) VALUES(?,?,?,?,?)
-	ON CONFLICT (name) DO UPDATE SET name =?, data = ?, uId = ?, deleted = ?, last_update = ? WHERE name = ? and uId = ?`)
+	stmt, err = s.db.Prepare(`INSERT INTO binares_data(uuid, name, data, uId, deleted, last_update, synced_update) VALUES(?,?,?,?,?,?,?)
+	ON CONFLICT (uId, uuid) DO UPDATE SET name = excluded.name, data = excluded.data,
+	deleted = excluded.deleted, last_update = excluded.last_update, synced_update = excluded.synced_update`)
 	if err != nil {
 		return err
 	}
 	for _, bin := range model.Bins {
-		_, err := stmt.ExecContext(ctx, bin.Name, bin.Data, bin.UserID, bin.Deleted, bin.Updated,
-			bin.Name, bin.Data, bin.UserID, bin.Deleted, bin.Updated, bin.Name, bin.UserID)
+		rec, skip, err := s.resolveSync(ctx, kindBin, syncRecord{
+			uuid: bin.UUID, name: bin.Name, deleted: bin.Deleted, updated: bin.Updated, uID: bin.UserID})
+		if err != nil {
+			return err
+		}
+		if skip {
+			continue
+		}
+		data, err := s.seal(bin.Data, fieldBinData, bin.UserID)
+		if err != nil {
+			return err
+		}
+		attrs := recordAttrs{meta: bin.Meta, folder: bin.Folder, tags: bin.Tags}
+		now, err := s.folderTime(ctx, attrs.folder)
+		if err != nil {
+			return err
+		}
+		err = s.inTx(ctx, func(tx *sql.Tx) error {
+			if _, err := tx.StmtContext(ctx, stmt).ExecContext(ctx, rec.uuid, rec.name, data, bin.UserID, bin.Deleted, rec.updated, bin.Updated); err != nil {
+				return err
+			}
+			return s.saveAttrs(ctx, tx, kindBin, rec.name, bin.UserID, attrs, true, now)
+		})
 		if err != nil {
 			return err
 		}
 	}
 
-	return nil
+	if err := s.syncFolders(ctx, model.Folders); err != nil {
+		return err
+	}
+	return s.syncOTP(ctx, model.OTPs)
 }
@@ -0,0 +1,641 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Dorrrke/GophKeeper-client/internal/coder"
+	"github.com/Dorrrke/GophKeeper-client/internal/domain/models"
+	"github.com/Dorrrke/GophKeeper-client/internal/folder"
+	"github.com/Dorrrke/GophKeeper-client/internal/hlc"
+	"github.com/golang-migrate/migrate/v4"
+	_ "github.com/golang-migrate/migrate/v4/database/sqlite3"
+	_ "github.com/golang-migrate/migrate/v4/source/file"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestStorage создает хранилище во временной базе со всеми миграциями и открытым ключом хранилища.
+func newTestStorage(t *testing.T) *Storage {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "test.db")
+	m, err := migrate.New("file://../services/migrations", "sqlite3://"+path)
+	require.NoError(t, err)
+	require.NoError(t, m.Up())
+	srcErr, dbErr := m.Close()
+	require.NoError(t, srcErr)
+	require.NoError(t, dbErr)
+
+	s, err := New(path)
+	require.NoError(t, err)
+	t.Cleanup(func() { s.db.Close() })
+	require.NoError(t, s.SetVaultKey([]byte("0123456789abcdef0123456789abcdef")))
+	return s
+}
+
+func loginUUID(t *testing.T, s *Storage, name string, uID int64) string {
+	t.Helper()
+	var uuid string
+	require.NoError(t, s.db.QueryRow("SELECT uuid FROM logins WHERE name = ? AND uId = ?", name, uID).Scan(&uuid))
+	return uuid
+}
+
+func TestSyncMatchesByUUID(t *testing.T) {
+	ctx := context.Background()
+	s := newTestStorage(t)
+	_, err := s.SaveLogin(ctx, models.LoginModel{Name: "github", Login: "gopher", Password: "old"}, 1)
+	require.NoError(t, err)
+	uuid := loginUUID(t, s, "github", 1)
+	require.NoError(t, s.UpdateLogin(ctx, models.LoginModel{Name: "github", Login: "gopher", Password: "new"}, 1))
+
+	// Другое устройство переименовало запись и отправило удаление прежнего имени.
+	err = s.Sync(ctx, models.SyncModel{Auth: []models.SyncLoginModel{
+		{UserID: 1, Name: "github", Deleted: true, Updated: hlc.MustParse("2030-01-01T00:00:00Z")},
+		{UserID: 1, Name: "work github", UUID: uuid, Login: "gopher", Password: "new", Updated: hlc.MustParse("2030-01-01T00:00:00Z")},
+		{UserID: 1, Name: "unknown", Deleted: true, Updated: hlc.MustParse("2030-01-01T00:00:00Z")},
+	}})
+	require.NoError(t, err)
+
+	logins, err := s.GetAllLogins(ctx, 1)
+	require.NoError(t, err)
+	require.Len(t, logins, 1)
+	assert.Equal(t, "work github", logins[0].Name)
+	history, err := s.GetHistory(ctx, kindLogin, "work github", 1)
+	require.NoError(t, err)
+	assert.Len(t, history, 1)
+	trash, err := s.GetTrash(ctx, 1)
+	require.NoError(t, err)
+	assert.Empty(t, trash)
+}
+
+func TestSyncLegacyRecordWithoutUUID(t *testing.T) {
+	ctx := context.Background()
+	s := newTestStorage(t)
+	_, err := s.SaveText(ctx, models.TextDataModel{Name: "note", Data: "local"}, 1)
+	require.NoError(t, err)
+
+	err = s.Sync(ctx, models.SyncModel{Texts: []models.SyncTextDataModel{
+		{UserID: 1, Name: "note", Data: "remote", Updated: hlc.MustParse("2030-01-01T00:00:00Z")},
+	}})
+	require.NoError(t, err)
+	text, err := s.GetTextDataByName(ctx, "note", 1)
+	require.NoError(t, err)
+	assert.Equal(t, "remote", text.Data)
+}
+
+func TestSyncSharedUUIDAcrossUsers(t *testing.T) {
+	ctx := context.Background()
+	s := newTestStorage(t)
+	_, err := s.SaveLogin(ctx, models.LoginModel{Name: "github", Login: "user-1", Password: "user-1", Tags: []string{"work"}}, 1)
+	require.NoError(t, err)
+	uuid := loginUUID(t, s, "github", 1)
+
+	// Запись второго пользователя с тем же идентификатором не затрагивает запись первого.
+	for _, password := range []string{"user-2", "user-2 new"} {
+		err = s.Sync(ctx, models.SyncModel{Auth: []models.SyncLoginModel{
+			{UserID: 2, Name: "github", UUID: uuid, Login: "user-2", Password: password, Tags: []string{"home"},
+				Updated: hlc.MustParse("2030-01-01T00:00:00Z")},
+		}})
+		require.NoError(t, err)
+	}
+
+	login, err := s.GetLoginByName(ctx, "github", 1)
+	require.NoError(t, err)
+	assert.Equal(t, "user-1", login.Password)
+	assert.Equal(t, []string{"work"}, login.Tags)
+	login, err = s.GetLoginByName(ctx, "github", 2)
+	require.NoError(t, err)
+	assert.Equal(t, "user-2 new", login.Password)
+	assert.Equal(t, []string{"home"}, login.Tags)
+	assert.Equal(t, uuid, loginUUID(t, s, "github", 2))
+}
+
+func TestSyncNameCollision(t *testing.T) {
+	const low, high = "00000000-0000-4000-8000-000000000001", "ffffffff-0000-4000-8000-000000000002"
+	tests := []struct {
+		name      string
+		local     string
+		remote    string
+		deleted   bool
+		wantLocal string
+		wantOther string
+	}{
+		{name: "remote uuid is lower", local: high, remote: low, wantLocal: "bank (ffffffff)", wantOther: "bank"},
+		{name: "local uuid is lower", local: low, remote: high, wantLocal: "bank", wantOther: "bank (ffffffff)"},
+		{name: "remote is deleted", local: high, remote: low, deleted: true, wantLocal: "bank", wantOther: "bank (00000000)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			s := newTestStorage(t)
+			// Запись с тем же идентификатором уже была на устройстве, чтобы удаление с сервера не пропускалось.
+			err := s.Sync(ctx, models.SyncModel{Auth: []models.SyncLoginModel{
+				{UserID: 1, Name: "bank", UUID: tt.local, Login: "local", Updated: hlc.MustParse("2024-01-01T00:00:00Z")},
+				{UserID: 1, Name: "old bank", UUID: tt.remote, Login: "remote", Updated: hlc.MustParse("2024-01-01T00:00:00Z")},
+			}})
+			require.NoError(t, err)
+
+			err = s.Sync(ctx, models.SyncModel{Auth: []models.SyncLoginModel{
+				{UserID: 1, Name: "bank", UUID: tt.remote, Login: "remote", Deleted: tt.deleted, Updated: hlc.MustParse("2024-01-02T00:00:00Z")},
+			}})
+			require.NoError(t, err)
+
+			var localName, otherName string
+			require.NoError(t, s.db.QueryRow("SELECT name FROM logins WHERE uuid = ?", tt.local).Scan(&localName))
+			require.NoError(t, s.db.QueryRow("SELECT name FROM logins WHERE uuid = ?", tt.remote).Scan(&otherName))
+			assert.Equal(t, tt.wantLocal, localName)
+			assert.Equal(t, tt.wantOther, otherName)
+		})
+	}
+}
+
+func TestRenameKeepsIdentity(t *testing.T) {
+	ctx := context.Background()
+	s := newTestStorage(t)
+	_, err := s.SaveCard(ctx, models.CardModel{Name: "visa", Number: "4111111111111111", Date: "01/30", CVVCode: "123"}, 1)
+	require.NoError(t, err)
+	_, err = s.SaveCard(ctx, models.CardModel{Name: "mc", Number: "5500000000000004", Date: "01/30", CVVCode: "123"}, 1)
+	require.NoError(t, err)
+
+	assert.ErrorIs(t, s.Rename(ctx, kindCard, "visa", "mc", 1), ErrCardAlredyExist)
+	assert.ErrorIs(t, s.Rename(ctx, kindCard, "amex", "gold", 1), ErrCardNotExist)
+	require.NoError(t, s.Rename(ctx, kindCard, "visa", "salary", 1))
+
+	saves, err := s.GetAllSaves(ctx, 1)
+	require.NoError(t, err)
+	var live, deleted []string
+	for _, card := range saves.Cards {
+		if card.Deleted {
+			assert.Empty(t, card.UUID)
+			deleted = append(deleted, card.Name)
+			continue
+		}
+		assert.NotEmpty(t, card.UUID)
+		live = append(live, card.Name)
+	}
+	assert.ElementsMatch(t, []string{"salary", "mc"}, live)
+	assert.Equal(t, []string{"visa"}, deleted)
+
+	require.NoError(t, s.ClearRenames(ctx, 1))
+	saves, err = s.GetAllSaves(ctx, 1)
+	require.NoError(t, err)
+	assert.Len(t, saves.Cards, 2)
+}
+
+func TestOTPIdentity(t *testing.T) {
+	ctx := context.Background()
+	s := newTestStorage(t)
+	for _, uID := range []int64{1, 2} {
+		_, err := s.SaveOTP(ctx, models.OTPModel{Name: "github", Type: "totp", Secret: "JBSWY3DPEHPK3PXP", Digits: 6, Period: 30,
+			Tags: []string{"2fa"}}, uID)
+		require.NoError(t, err)
+	}
+	saves, err := s.GetAllSaves(ctx, 1)
+	require.NoError(t, err)
+	require.Len(t, saves.OTPs, 1)
+	uuid := saves.OTPs[0].UUID
+	require.NotEmpty(t, uuid)
+
+	require.NoError(t, s.Rename(ctx, kindOTP, "github", "work github", 1))
+	saves, err = s.GetChangedSaves(ctx, 1)
+	require.NoError(t, err)
+	require.Len(t, saves.OTPs, 2)
+	for _, otp := range saves.OTPs {
+		if otp.Deleted {
+			assert.Equal(t, "github", otp.Name)
+			continue
+		}
+		assert.Equal(t, "work github", otp.Name)
+		assert.Equal(t, uuid, otp.UUID)
+	}
+	require.NoError(t, s.MarkSynced(ctx, saves, 1))
+	require.NoError(t, s.ClearRenames(ctx, 1))
+
+	// Другое устройство переименовало запись: она обновляется по идентификатору, а не сохраняется второй записью.
+	err = s.Sync(ctx, models.SyncModel{OTPs: []models.SyncOTPModel{
+		{UserID: 1, Name: "github", Deleted: true, Updated: hlc.MustParse("2030-01-01T00:00:00Z")},
+		{UserID: 1, Name: "mail", UUID: uuid, Type: "totp", Secret: "JBSWY3DPEHPK3PXP", Digits: 8, Period: 30,
+			Tags: []string{"2fa"}, Updated: hlc.MustParse("2030-01-01T00:00:00Z")},
+	}})
+	require.NoError(t, err)
+	otps, err := s.GetAllOTP(ctx, 1)
+	require.NoError(t, err)
+	require.Len(t, otps, 1)
+	assert.Equal(t, "mail", otps[0].Name)
+	assert.Equal(t, 8, otps[0].Digits)
+	assert.Equal(t, []string{"2fa"}, otps[0].Tags)
+
+	otp, err := s.GetOTPByName(ctx, "github", 2)
+	require.NoError(t, err)
+	assert.Equal(t, 6, otp.Digits)
+}
+
+// seedUsers создает двух пользователей с одинаковыми именами записей всех видов.
+// У второго пользователя есть еще запись private, которой нет у первого.
+func seedUsers(t *testing.T, s *Storage) {
+	t.Helper()
+	ctx := context.Background()
+	for _, uID := range []int64{1, 2} {
+		secret := fmt.Sprintf("user-%d", uID)
+		id, err := s.SaveUser(ctx, models.UserModel{Login: secret, Hash: "hash-" + secret})
+		require.NoError(t, err)
+		require.Equal(t, uID, id)
+		require.NoError(t, s.CreateFolder(ctx, "Work", uID))
+		_, err = s.SaveCard(ctx, models.CardModel{Name: "visa", Number: "4111111111111111", Date: "01/30", CVVCode: "123",
+			Meta: map[string]string{"bank": secret}, Folder: "Work", Tags: []string{secret}}, uID)
+		require.NoError(t, err)
+		_, err = s.SaveLogin(ctx, models.LoginModel{Name: "github", Login: secret, Password: secret, Folder: "Work"}, uID)
+		require.NoError(t, err)
+		_, err = s.SaveText(ctx, models.TextDataModel{Name: "note", Data: secret, Tags: []string{"shared"}}, uID)
+		require.NoError(t, err)
+		_, err = s.SaveBin(ctx, models.BinaryDataModel{Name: "file", Data: []byte(secret)}, uID)
+		require.NoError(t, err)
+		_, err = s.SaveOTP(ctx, models.OTPModel{Name: "mail", Type: "totp", Secret: "JBSWY3DPEHPK3PXP", Algorithm: "SHA1",
+			Digits: 6, Period: 30, Account: secret}, uID)
+		require.NoError(t, err)
+		require.NoError(t, s.LogAccess(ctx, models.AccessLogModel{Kind: kindLogin, Name: "github", Action: "reveal",
+			At: time.Now()}, uID))
+	}
+	_, err := s.SaveText(ctx, models.TextDataModel{Name: "private", Data: "user-2"}, 2)
+	require.NoError(t, err)
+}
+
+func TestUserIsolation(t *testing.T) {
+	tests := []struct {
+		name string
+		// act работает с записями первого пользователя и проверяет, что ему не видны записи второго.
+		act func(t *testing.T, ctx context.Context, s *Storage)
+	}{
+		{name: "get", act: func(t *testing.T, ctx context.Context, s *Storage) {
+			card, err := s.GetCardByName(ctx, "visa", 1)
+			require.NoError(t, err)
+			assert.Equal(t, "user-1", card.Meta["bank"])
+			login, err := s.GetLoginByName(ctx, "github", 1)
+			require.NoError(t, err)
+			assert.Equal(t, "user-1", login.Password)
+			text, err := s.GetTextDataByName(ctx, "note", 1)
+			require.NoError(t, err)
+			assert.Equal(t, "user-1", text.Data)
+			bin, err := s.GetBinByName(ctx, "file", 1)
+			require.NoError(t, err)
+			assert.Equal(t, []byte("user-1"), bin.Data)
+			otp, err := s.GetOTPByName(ctx, "mail", 1)
+			require.NoError(t, err)
+			assert.Equal(t, "user-1", otp.Account)
+			_, err = s.GetTextDataByName(ctx, "private", 1)
+			assert.ErrorIs(t, err, ErrTextNotExist)
+		}},
+		{name: "get all", act: func(t *testing.T, ctx context.Context, s *Storage) {
+			cards, err := s.GetAllCards(ctx, 1)
+			require.NoError(t, err)
+			assert.Len(t, cards, 1)
+			logins, err := s.GetAllLogins(ctx, 1)
+			require.NoError(t, err)
+			require.Len(t, logins, 1)
+			assert.Equal(t, "user-1", logins[0].Login)
+			texts, err := s.GetAllTextData(ctx, 1)
+			require.NoError(t, err)
+			assert.Len(t, texts, 1)
+			bins, err := s.GetAllBin(ctx, 1)
+			require.NoError(t, err)
+			assert.Len(t, bins, 1)
+			otps, err := s.GetAllOTP(ctx, 1)
+			require.NoError(t, err)
+			assert.Len(t, otps, 1)
+			folders, err := s.GetFolders(ctx, 1)
+			require.NoError(t, err)
+			assert.Equal(t, []string{"Work"}, folders)
+			log, err := s.GetAccessLog(ctx, 1, 10)
+			require.NoError(t, err)
+			assert.Len(t, log, 1)
+		}},
+		{name: "save duplicate", act: func(t *testing.T, ctx context.Context, s *Storage) {
+			_, err := s.SaveCard(ctx, models.CardModel{Name: "visa", Number: "4111111111111111", Date: "01/30", CVVCode: "123"}, 1)
+			assert.ErrorIs(t, err, ErrCardAlredyExist)
+			_, err = s.SaveOTP(ctx, models.OTPModel{Name: "mail", Type: "totp", Secret: "JBSWY3DPEHPK3PXP"}, 1)
+			assert.ErrorIs(t, err, ErrOTPAlredyExist)
+			assert.ErrorIs(t, s.CreateFolder(ctx, "Work", 1), ErrFolderExist)
+			_, err = s.SaveText(ctx, models.TextDataModel{Name: "private", Data: "user-1"}, 1)
+			assert.NoError(t, err)
+		}},
+		{name: "update", act: func(t *testing.T, ctx context.Context, s *Storage) {
+			require.NoError(t, s.UpdateCard(ctx, models.CardModel{Name: "visa", Number: "5500000000000004", Date: "02/31", CVVCode: "321"}, 1))
+			require.NoError(t, s.UpdateLogin(ctx, models.LoginModel{Name: "github", Login: "new", Password: "new"}, 1))
+			require.NoError(t, s.UpdateText(ctx, models.TextDataModel{Name: "note", Data: "new"}, 1))
+			require.NoError(t, s.UpdateBin(ctx, models.BinaryDataModel{Name: "file", Data: []byte("new")}, 1))
+			require.NoError(t, s.UpdateOTP(ctx, models.OTPModel{Name: "mail", Type: "hotp", Secret: "JBSWY3DPEHPK3PXP"}, 1))
+			assert.ErrorIs(t, s.UpdateText(ctx, models.TextDataModel{Name: "private", Data: "new"}, 1), ErrTextNotExist)
+		}},
+		{name: "history", act: func(t *testing.T, ctx context.Context, s *Storage) {
+			require.NoError(t, s.UpdateLogin(ctx, models.LoginModel{Name: "github", Login: "new", Password: "new"}, 1))
+			history, err := s.GetHistory(ctx, kindLogin, "github", 1)
+			require.NoError(t, err)
+			require.Len(t, history, 1)
+			_, err = s.GetHistoryVersion(ctx, kindLogin, "github", history[0].Version, 1)
+			require.NoError(t, err)
+			_, err = s.GetHistory(ctx, kindText, "private", 1)
+			assert.ErrorIs(t, err, ErrTextNotExist)
+		}},
+		{name: "delete and trash", act: func(t *testing.T, ctx context.Context, s *Storage) {
+			require.NoError(t, s.DeleteCard(ctx, "visa", 1))
+			require.NoError(t, s.DeleteLogin(ctx, "github", 1))
+			require.NoError(t, s.DeleteText(ctx, "note", 1))
+			require.NoError(t, s.DeleteBin(ctx, "file", 1))
+			require.NoError(t, s.DeleteOTP(ctx, "mail", 1))
+			trash, err := s.GetTrash(ctx, 1)
+			require.NoError(t, err)
+			assert.Len(t, trash, 4)
+			require.NoError(t, s.RestoreDeleted(ctx, kindCard, "visa", 1))
+			assert.ErrorIs(t, s.RestoreDeleted(ctx, kindText, "private", 1), ErrTextNotExist)
+			require.NoError(t, s.ClearDB(ctx, 1, time.Now().Add(time.Hour)))
+		}},
+		{name: "rename", act: func(t *testing.T, ctx context.Context, s *Storage) {
+			require.NoError(t, s.Rename(ctx, kindLogin, "github", "work github", 1))
+			require.NoError(t, s.Rename(ctx, kindText, "note", "private", 1))
+			assert.ErrorIs(t, s.Rename(ctx, kindText, "private", "note", 2), ErrTextAlredyExist)
+			require.NoError(t, s.ClearRenames(ctx, 1))
+		}},
+		{name: "folders", act: func(t *testing.T, ctx context.Context, s *Storage) {
+			require.NoError(t, s.CreateFolder(ctx, "Home", 1))
+			require.NoError(t, s.MoveFolder(ctx, "Work", "Home/Work", 1))
+			folders, err := s.GetFolders(ctx, 1)
+			require.NoError(t, err)
+			assert.Equal(t, []string{"Home", "Home/Work"}, folders)
+		}},
+		{name: "sync", act: func(t *testing.T, ctx context.Context, s *Storage) {
+			saves, err := s.GetAllSaves(ctx, 1)
+			require.NoError(t, err)
+			assert.Len(t, saves.Texts, 1)
+			err = s.Sync(ctx, models.SyncModel{
+				Cards: []models.SyncCardModel{{UserID: 1, Name: "visa", Number: "5500000000000004", Date: "02/31", CVVCode: "321",
+					Updated: hlc.MustParse("2030-01-01T00:00:00Z")}},
+				Auth:    []models.SyncLoginModel{{UserID: 1, Name: "github", Login: "remote", Updated: hlc.MustParse("2030-01-01T00:00:00Z")}},
+				Texts:   []models.SyncTextDataModel{{UserID: 1, Name: "private", Data: "remote", Updated: hlc.MustParse("2030-01-01T00:00:00Z")}},
+				Bins:    []models.SyncBinaryDataModel{{UserID: 1, Name: "file", Deleted: true, Updated: hlc.MustParse("2030-01-01T00:00:00Z")}},
+				OTPs:    []models.SyncOTPModel{{UserID: 1, Name: "mail", Type: "totp", Secret: "JBSWY3DPEHPK3PXP", Updated: hlc.MustParse("2030-01-01T00:00:00Z")}},
+				Folders: []models.SyncFolderModel{{UserID: 1, Path: "Work", Deleted: true, Updated: hlc.MustParse("2030-01-01T00:00:00Z")}},
+			})
+			require.NoError(t, err)
+			login, err := s.GetLoginByName(ctx, "github", 1)
+			require.NoError(t, err)
+			assert.Equal(t, "remote", login.Login)
+			text, err := s.GetTextDataByName(ctx, "private", 1)
+			require.NoError(t, err)
+			assert.Equal(t, "remote", text.Data)
+		}},
+		{name: "access log", act: func(t *testing.T, ctx context.Context, s *Storage) {
+			require.NoError(t, s.LogAccess(ctx, models.AccessLogModel{Kind: kindCard, Name: "visa", Action: "reveal", At: time.Now()}, 1))
+			log, err := s.GetAccessLog(ctx, 1, 10)
+			require.NoError(t, err)
+			assert.Len(t, log, 2)
+		}},
+		{name: "encrypt existing", act: func(t *testing.T, ctx context.Context, s *Storage) {
+			require.NoError(t, s.EncryptExisting(ctx, 1))
+		}},
+		{name: "users", act: func(t *testing.T, ctx context.Context, s *Storage) {
+			users, err := s.GetUsers(ctx)
+			require.NoError(t, err)
+			assert.Equal(t, []models.UserModel{{UserID: 1, Login: "user-1"}, {UserID: 2, Login: "user-2"}}, users)
+			require.NoError(t, s.UpdateUserHash(ctx, 1, "new-hash"))
+			require.NoError(t, s.SaveUserKDF(ctx, 1, models.KDFModel{Salt: []byte("salt"), Params: "params"}))
+			require.NoError(t, s.SaveLoginAttempts(ctx, "user-1", models.LoginAttemptsModel{Failures: 3}))
+			require.NoError(t, s.ResetLoginAttempts(ctx, "user-1"))
+			require.NoError(t, s.SaveLoginAttempts(ctx, "user-1", models.LoginAttemptsModel{Failures: 1}))
+			uID, hash, err := s.GetUserHash(ctx, "user-1")
+			require.NoError(t, err)
+			assert.Equal(t, int64(1), uID)
+			assert.Equal(t, "new-hash", hash)
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			s := newTestStorage(t)
+			seedUsers(t, s)
+			before, err := s.GetAllSaves(ctx, 2)
+			require.NoError(t, err)
+
+			tt.act(t, ctx, s)
+
+			after, err := s.GetAllSaves(ctx, 2)
+			require.NoError(t, err)
+			assert.Equal(t, before, after)
+			trash, err := s.GetTrash(ctx, 2)
+			require.NoError(t, err)
+			assert.Empty(t, trash)
+			log, err := s.GetAccessLog(ctx, 2, 10)
+			require.NoError(t, err)
+			assert.Len(t, log, 1)
+			history, err := s.GetHistory(ctx, kindLogin, "github", 2)
+			require.NoError(t, err)
+			assert.Empty(t, history)
+			_, hash, err := s.GetUserHash(ctx, "user-2")
+			require.NoError(t, err)
+			assert.Equal(t, "hash-user-2", hash)
+			kdf, err := s.GetUserKDF(ctx, 2)
+			require.NoError(t, err)
+			assert.Empty(t, kdf.Salt)
+			attempts, err := s.GetLoginAttempts(ctx, "user-2")
+			require.NoError(t, err)
+			assert.Zero(t, attempts)
+		})
+	}
+}
+
+func TestSaveWithAttrsIsAtomic(t *testing.T) {
+	ctx := context.Background()
+	s := newTestStorage(t)
+	_, err := s.SaveText(ctx, models.TextDataModel{Name: "note", Data: "data", Folder: "Work/../Home"}, 1)
+	require.ErrorIs(t, err, folder.ErrInvalid)
+	_, err = s.GetTextDataByName(ctx, "note", 1)
+	assert.ErrorIs(t, err, ErrTextNotExist)
+
+	_, err = s.SaveText(ctx, models.TextDataModel{Name: "note", Data: "old", Folder: "Work"}, 1)
+	require.NoError(t, err)
+	err = s.UpdateText(ctx, models.TextDataModel{Name: "note", Data: "new", Folder: "Work/../Home"}, 1)
+	require.ErrorIs(t, err, folder.ErrInvalid)
+	text, err := s.GetTextDataByName(ctx, "note", 1)
+	require.NoError(t, err)
+	assert.Equal(t, "old", text.Data)
+	assert.Equal(t, "Work", text.Folder)
+	// Неудачное обновление не оставляет версию в истории.
+	history, err := s.GetHistory(ctx, kindText, "note", 1)
+	require.NoError(t, err)
+	assert.Empty(t, history)
+}
+
+func TestChangedSaves(t *testing.T) {
+	ctx := context.Background()
+	s := newTestStorage(t)
+	_, err := s.SaveLogin(ctx, models.LoginModel{Name: "github", Login: "gopher", Password: "pw", Tags: []string{"dev"}}, 1)
+	require.NoError(t, err)
+	_, err = s.SaveBin(ctx, models.BinaryDataModel{Name: "file", Data: []byte("blob")}, 1)
+	require.NoError(t, err)
+	require.NoError(t, s.CreateFolder(ctx, "Work", 1))
+
+	pushed, err := s.GetChangedSaves(ctx, 1)
+	require.NoError(t, err)
+	require.Len(t, pushed.Auth, 1)
+	assert.Equal(t, []string{"dev"}, pushed.Auth[0].Tags)
+	assert.Len(t, pushed.Bins, 1)
+	assert.Len(t, pushed.Folders, 1)
+	require.NoError(t, s.MarkSynced(ctx, pushed, 1))
+
+	changed, err := s.GetChangedSaves(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, models.SyncModel{}, changed)
+
+	// Запись изменили уже после отправки: отметка о синхронизации ее не затрагивает.
+	require.NoError(t, s.UpdateLogin(ctx, models.LoginModel{Name: "github", Login: "gopher", Password: "new"}, 1))
+	_, err = s.db.Exec("UPDATE logins SET last_update = '2030-01-01T00:00:00.000Z/0000/0000000000000000' WHERE name = 'github'")
+	require.NoError(t, err)
+	stale := models.SyncModel{Auth: []models.SyncLoginModel{{Name: "github", UUID: loginUUID(t, s, "github", 1),
+		Updated: pushed.Auth[0].Updated}}}
+	require.NoError(t, s.MarkSynced(ctx, stale, 1))
+
+	// Записи с сервера уже подтверждены им.
+	err = s.Sync(ctx, models.SyncModel{Texts: []models.SyncTextDataModel{
+		{UserID: 1, Name: "note", Data: "remote", Updated: hlc.MustParse("2030-01-01T00:00:00Z")},
+	}})
+	require.NoError(t, err)
+	require.NoError(t, s.Rename(ctx, kindBin, "file", "archive", 1))
+
+	changed, err = s.GetChangedSaves(ctx, 1)
+	require.NoError(t, err)
+	require.Len(t, changed.Auth, 1)
+	assert.Equal(t, "new", changed.Auth[0].Password)
+	assert.Empty(t, changed.Texts)
+	require.Len(t, changed.Bins, 2)
+	assert.Equal(t, "archive", changed.Bins[0].Name)
+	assert.Equal(t, []byte("blob"), changed.Bins[0].Data)
+	assert.True(t, changed.Bins[1].Deleted)
+}
+
+func TestClearDBKeepsUnsyncedDeletions(t *testing.T) {
+	ctx := context.Background()
+	s := newTestStorage(t)
+	for _, name := range []string{"old", "fresh"} {
+		_, err := s.SaveText(ctx, models.TextDataModel{Name: name, Data: "data"}, 1)
+		require.NoError(t, err)
+		require.NoError(t, s.DeleteText(ctx, name, 1))
+	}
+	pushed, err := s.GetChangedSaves(ctx, 1)
+	require.NoError(t, err)
+	require.Len(t, pushed.Texts, 2)
+	pushed.Texts = pushed.Texts[:1]
+	require.NoError(t, s.MarkSynced(ctx, pushed, 1))
+
+	// Удаление, не отправленное на сервер, остается в корзине, чтобы запись не вернулась при синхронизации.
+	require.NoError(t, s.ClearDB(ctx, 1, time.Now().Add(time.Hour)))
+	trash, err := s.GetTrash(ctx, 1)
+	require.NoError(t, err)
+	require.Len(t, trash, 1)
+	assert.NotEqual(t, pushed.Texts[0].Name, trash[0].Name)
+	changed, err := s.GetChangedSaves(ctx, 1)
+	require.NoError(t, err)
+	require.Len(t, changed.Texts, 1)
+	assert.True(t, changed.Texts[0].Deleted)
+
+	require.NoError(t, s.MarkSynced(ctx, changed, 1))
+	require.NoError(t, s.ClearDB(ctx, 1, time.Now().Add(time.Hour)))
+	trash, err = s.GetTrash(ctx, 1)
+	require.NoError(t, err)
+	assert.Empty(t, trash)
+}
+
+func TestConflicts(t *testing.T) {
+	ctx := context.Background()
+	s := newTestStorage(t)
+	_, err := s.SaveLogin(ctx, models.LoginModel{Name: "github", Login: "gopher", Password: "local"}, 1)
+	require.NoError(t, err)
+	uuid := loginUUID(t, s, "github", 1)
+
+	remote := models.SyncModel{Auth: []models.SyncLoginModel{
+		{UserID: 1, Name: "github", UUID: uuid, Login: "gopher", Password: "remote", Updated: hlc.MustParse("2030-01-01T00:00:00Z")},
+	}}
+	conflict := models.ConflictModel{Kind: kindLogin, Name: "github", UUID: uuid, Remote: remote, Detected: time.Now()}
+	require.NoError(t, s.SaveConflict(ctx, conflict, 1))
+	remote.Auth[0].Password = "newer"
+	conflict.Remote = remote
+	require.NoError(t, s.SaveConflict(ctx, conflict, 1))
+
+	var sealed string
+	require.NoError(t, s.db.QueryRow("SELECT data FROM conflicts WHERE uId = 1").Scan(&sealed))
+	assert.NotContains(t, sealed, "newer")
+
+	conflicts, err := s.GetConflicts(ctx, 1)
+	require.NoError(t, err)
+	require.Len(t, conflicts, 1)
+	assert.Equal(t, uuid, conflicts[0].UUID)
+	assert.Equal(t, remote, conflicts[0].Remote)
+	conflicts, err = s.GetConflicts(ctx, 2)
+	require.NoError(t, err)
+	assert.Empty(t, conflicts)
+
+	// Локальная версия переносится на версию с сервера и остается неотправленной.
+	base := hlc.MustParse("2030-01-01T00:00:00Z")
+	require.NoError(t, s.RebaseRecord(ctx, kindLogin, uuid, base, 1))
+	changed, err := s.GetChangedSaves(ctx, 1)
+	require.NoError(t, err)
+	require.Len(t, changed.Auth, 1)
+	assert.Equal(t, "local", changed.Auth[0].Password)
+	assert.Equal(t, base, changed.Auth[0].Base)
+	assert.True(t, changed.Auth[0].Updated.After(base))
+	assert.ErrorIs(t, s.RebaseRecord(ctx, kindLogin, uuid, hlc.Timestamp{}, 2), ErrLoginNotExist)
+
+	require.NoError(t, s.DeleteConflict(ctx, kindLogin, uuid, 1))
+	conflicts, err = s.GetConflicts(ctx, 1)
+	require.NoError(t, err)
+	assert.Empty(t, conflicts)
+}
+
+func TestSyncAdvancesClock(t *testing.T) {
+	ctx := context.Background()
+	s := newTestStorage(t)
+	remote := hlc.Timestamp{Wall: hlc.MustParse("2030-01-01T00:00:00Z").Wall, Counter: 3, Device: "bbbbbbbbbbbbbbbb"}
+	err := s.Sync(ctx, models.SyncModel{Auth: []models.SyncLoginModel{
+		{UserID: 1, Name: "github", Login: "gopher", Password: "remote", Updated: remote},
+	}})
+	require.NoError(t, err)
+
+	// В базе сохраняется метка часов этого устройства, а не метка другого устройства.
+	var device string
+	var last hlc.Timestamp
+	require.NoError(t, s.db.QueryRow("SELECT device, last FROM clock").Scan(&device, &last))
+	assert.Equal(t, device, last.Device)
+	assert.Equal(t, remote.Wall, last.Wall)
+	assert.Equal(t, remote.Counter, last.Counter)
+}
+
+func TestEncryptExistingOnce(t *testing.T) {
+	ctx := context.Background()
+	s := newTestStorage(t)
+	uID, err := s.SaveUser(ctx, models.UserModel{Login: "gopher", Hash: "hash"})
+	require.NoError(t, err)
+	// Пользователь и запись, сохраненные до появления шифрования.
+	_, err = s.db.Exec("UPDATE users SET fields_sealed = 0 WHERE uId = ?", uID)
+	require.NoError(t, err)
+	_, err = s.db.Exec("INSERT INTO logins(uuid, name, login, password, uId, deleted) VALUES('u1', 'github', 'gopher', 'plain', ?, 0)", uID)
+	require.NoError(t, err)
+	login, err := s.GetLoginByName(ctx, "github", uID)
+	require.NoError(t, err)
+	assert.Equal(t, "plain", login.Password)
+
+	require.NoError(t, s.EncryptExisting(ctx, uID))
+	var stored string
+	require.NoError(t, s.db.QueryRow("SELECT password FROM logins WHERE uuid = 'u1'").Scan(&stored))
+	assert.True(t, coder.IsSealed(stored))
+	login, err = s.GetLoginByName(ctx, "github", uID)
+	require.NoError(t, err)
+	assert.Equal(t, "plain", login.Password)
+
+	// Открытое значение после шифрования не принимается и повторным вызовом не шифруется.
+	_, err = s.db.Exec("UPDATE logins SET password = 'injected' WHERE uuid = 'u1'")
+	require.NoError(t, err)
+	require.NoError(t, s.EncryptExisting(ctx, uID))
+	_, err = s.GetLoginByName(ctx, "github", uID)
+	assert.ErrorIs(t, err, coder.ErrDecodeData)
+}
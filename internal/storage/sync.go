@@ -0,0 +1,71 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Dorrrke/GophKeeper-client/internal/domain/models"
+	"github.com/Dorrrke/GophKeeper-client/internal/hlc"
+)
+
+// Запись считается измененной, пока метка ее изменения не совпадает с меткой, подтвержденной сервером.
+const (
+	changedFilter     = "AND synced_update IS NOT last_update"
+	changedAttrFilter = "AND r.synced_update IS NOT r.last_update"
+)
+
+// GetChangedSaves возвращает записи пользователя, изменения которых еще не отправлены на сервер.
+func (s *Storage) GetChangedSaves(ctx context.Context, uID int64) (models.SyncModel, error) {
+	return s.getSaves(ctx, uID, changedFilter, changedAttrFilter)
+}
+
+// MarkSynced отмечает отправленные записи как подтвержденные сервером.
+// Запись, измененная после отправки, остается измененной и уйдет на сервер при следующей синхронизации.
+func (s *Storage) MarkSynced(ctx context.Context, pushed models.SyncModel, uID int64) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	mark := func(table string, keyCol string, key string, updated hlc.Timestamp) error {
+		// Прежние имена переименованных записей хранятся отдельно и идентификатора не имеют.
+		if key == "" {
+			return nil
+		}
+		_, err := tx.ExecContext(ctx,
+			fmt.Sprintf("UPDATE %s SET synced_update = last_update WHERE %s = ? AND uId = ? AND last_update = ?", table, keyCol),
+			key, uID, updated)
+		return err
+	}
+	for _, data := range pushed.Cards {
+		if err := mark("cards", "uuid", data.UUID, data.Updated); err != nil {
+			return err
+		}
+	}
+	for _, data := range pushed.Auth {
+		if err := mark("logins", "uuid", data.UUID, data.Updated); err != nil {
+			return err
+		}
+	}
+	for _, data := range pushed.Texts {
+		if err := mark("text_data", "uuid", data.UUID, data.Updated); err != nil {
+			return err
+		}
+	}
+	for _, data := range pushed.Bins {
+		if err := mark("binares_data", "uuid", data.UUID, data.Updated); err != nil {
+			return err
+		}
+	}
+	for _, data := range pushed.OTPs {
+		if err := mark("otp", "uuid", data.UUID, data.Updated); err != nil {
+			return err
+		}
+	}
+	for _, data := range pushed.Folders {
+		if err := mark("folders", "path", data.Path, data.Updated); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,75 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/Dorrrke/GophKeeper-client/internal/domain/models"
+	"github.com/Dorrrke/GophKeeper-client/internal/hlc"
+)
+
+// trashKinds - виды записей, которые попадают в корзину, в порядке вывода.
+var trashKinds = []string{kindCard, kindLogin, kindText, kindBin}
+
+// GetTrash возвращает удаленные записи пользователя, начиная с последних удаленных.
+func (s *Storage) GetTrash(ctx context.Context, uID int64) ([]models.TrashModel, error) {
+	var items []models.TrashModel
+	for _, kind := range trashKinds {
+		rt := recordTables[kind]
+		stmt, err := s.db.Prepare(fmt.Sprintf("SELECT name, last_update FROM %s WHERE deleted = 1 AND uId = ?", rt.table))
+		if err != nil {
+			return nil, err
+		}
+		rows, err := stmt.QueryContext(ctx, uID)
+		if err != nil {
+			return nil, err
+		}
+		for rows.Next() {
+			item := models.TrashModel{Kind: kind}
+			var deleted hlc.Timestamp
+			if err := rows.Scan(&item.Name, &deleted); err != nil {
+				rows.Close()
+				return nil, err
+			}
+			item.Deleted = deleted.Time()
+			items = append(items, item)
+		}
+		if err := rows.Close(); err != nil {
+			return nil, err
+		}
+	}
+	slices.SortStableFunc(items, func(a, b models.TrashModel) int {
+		return b.Deleted.Compare(a.Deleted)
+	})
+	return items, nil
+}
+
+// RestoreDeleted возвращает запись из корзины. Метка изменения обновляется, чтобы восстановление дошло до сервера.
+func (s *Storage) RestoreDeleted(ctx context.Context, kind string, name string, uID int64) error {
+	notExist, ok := recordNotExist[kind]
+	if !ok {
+		return fmt.Errorf("unknown record kind %q", kind)
+	}
+	rt := recordTables[kind]
+	stmt, err := s.db.Prepare(fmt.Sprintf("UPDATE %s SET deleted = 0, last_update = ? WHERE name = ? AND uId = ? AND deleted = 1", rt.table))
+	if err != nil {
+		return err
+	}
+	lTime, err := s.tick(ctx)
+	if err != nil {
+		return err
+	}
+	res, err := stmt.ExecContext(ctx, lTime, name, uID)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return notExist
+	}
+	return nil
+}